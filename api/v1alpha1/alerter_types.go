@@ -0,0 +1,108 @@
+/*
+
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// Alerters defines typed alerter settings which are applied to every rule.
+// Secret values are never rendered into the rule configmaps, they are delivered to elastalert as a mounted file.
+// +k8s:openapi-gen=true
+type Alerters struct {
+	// +optional
+	Slack *SlackAlerter `json:"slack,omitempty"`
+	// +optional
+	Email *EmailAlerter `json:"email,omitempty"`
+	// +optional
+	PagerDuty *PagerDutyAlerter `json:"pagerduty,omitempty"`
+	// +optional
+	Opsgenie *OpsgenieAlerter `json:"opsgenie,omitempty"`
+	// +optional
+	MsTeams *MsTeamsAlerter `json:"msTeams,omitempty"`
+	// +optional
+	HTTPPost *HTTPPostAlerter `json:"httpPost,omitempty"`
+}
+
+// SlackAlerter configures the slack alerter.
+type SlackAlerter struct {
+	// WebhookURL selects the secret key holding slack_webhook_url.
+	WebhookURL v1.SecretKeySelector `json:"webhookUrl"`
+	// +optional
+	ChannelOverride string `json:"channelOverride,omitempty"`
+	// +optional
+	UsernameOverride string `json:"usernameOverride,omitempty"`
+}
+
+// EmailAlerter configures the email alerter.
+type EmailAlerter struct {
+	To []string `json:"to"`
+	// +optional
+	FromAddr string `json:"fromAddr,omitempty"`
+	// +optional
+	SMTPHost string `json:"smtpHost,omitempty"`
+	// +optional
+	SMTPPort int `json:"smtpPort,omitempty"`
+	// +optional
+	SMTPSSL bool `json:"smtpSsl,omitempty"`
+	// Auth is rendered as the file referenced by smtp_auth_file.
+	// +optional
+	Auth *SMTPAuth `json:"auth,omitempty"`
+}
+
+// SMTPAuth selects the secret keys holding the smtp credentials.
+type SMTPAuth struct {
+	User     v1.SecretKeySelector `json:"user"`
+	Password v1.SecretKeySelector `json:"password"`
+}
+
+// PagerDutyAlerter configures the pagerduty alerter.
+type PagerDutyAlerter struct {
+	// ServiceKey selects the secret key holding pagerduty_service_key.
+	ServiceKey v1.SecretKeySelector `json:"serviceKey"`
+	ClientName string               `json:"clientName"`
+}
+
+// OpsgenieAlerter configures the opsgenie alerter.
+type OpsgenieAlerter struct {
+	// Key selects the secret key holding opsgenie_key.
+	Key v1.SecretKeySelector `json:"key"`
+	// +optional
+	Recipients []string `json:"recipients,omitempty"`
+	// +optional
+	Teams []string `json:"teams,omitempty"`
+}
+
+// MsTeamsAlerter configures the ms_teams alerter.
+type MsTeamsAlerter struct {
+	// WebhookURL selects the secret key holding ms_teams_webhook_url.
+	WebhookURL   v1.SecretKeySelector `json:"webhookUrl"`
+	AlertSummary string               `json:"alertSummary"`
+}
+
+// HTTPPostAlerter configures the post alerter.
+type HTTPPostAlerter struct {
+	// URL selects the secret key holding http_post_url.
+	URL v1.SecretKeySelector `json:"url"`
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+	// +optional
+	StaticPayload map[string]string `json:"staticPayload,omitempty"`
+	// +optional
+	Timeout int `json:"timeout,omitempty"`
+}
@@ -0,0 +1,81 @@
+/*
+
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AlertProfileRuleKey is the rule key referencing an alert profile by name.
+	AlertProfileRuleKey = "alert_profile"
+)
+
+// AlertProfileSpec defines an alert block shared by rules.
+// +k8s:openapi-gen=true
+type AlertProfileSpec struct {
+	// Alert holds the alert settings of the profile, it must define "alert".
+	Alert FreeForm `json:"alert"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// ElastalertAlertProfile is a namespace-wide alert block that rules of the Elastalerts in the same namespace reference by name.
+type ElastalertAlertProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AlertProfileSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ElastalertAlertProfileList contains a list of ElastalertAlertProfile
+type ElastalertAlertProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElastalertAlertProfile `json:"items"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// ClusterElastalertAlertProfile is a cluster-wide alert block that rules of any Elastalert reference by name.
+// An ElastalertAlertProfile of the same name in the namespace of the Elastalert takes precedence.
+type ClusterElastalertAlertProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AlertProfileSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterElastalertAlertProfileList contains a list of ClusterElastalertAlertProfile
+type ClusterElastalertAlertProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterElastalertAlertProfile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&ElastalertAlertProfile{}, &ElastalertAlertProfileList{},
+		&ClusterElastalertAlertProfile{}, &ClusterElastalertAlertProfileList{},
+	)
+}
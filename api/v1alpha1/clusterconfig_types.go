@@ -0,0 +1,62 @@
+/*
+
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultClusterConfigName is the ElastalertClusterConfig used by Elastalerts which do not name one.
+	DefaultClusterConfigName = "default"
+)
+
+// ElastalertClusterConfigSpec defines the defaults shared by Elastalerts.
+// +k8s:openapi-gen=true
+type ElastalertClusterConfigSpec struct {
+	// Config is merged underneath spec.config of every Elastalert using it, keys of the Elastalert win.
+	// +optional
+	Config FreeForm `json:"config,omitempty"`
+	// Cert is used by Elastalerts which define no cert.
+	// +optional
+	Cert string `json:"cert,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// ElastalertClusterConfig holds cluster-wide defaults for the config and cert of Elastalerts.
+type ElastalertClusterConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ElastalertClusterConfigSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ElastalertClusterConfigList contains a list of ElastalertClusterConfig
+type ElastalertClusterConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElastalertClusterConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ElastalertClusterConfig{}, &ElastalertClusterConfigList{})
+}
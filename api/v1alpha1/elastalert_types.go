@@ -30,22 +30,60 @@ const (
 	ElastAlertInitializing = "INITIALIZING"
 	// +k8s:openapi-gen=true
 	ElastAlertPhraseSucceeded = "RUNNING"
+	// +k8s:openapi-gen=true
+	ElastAlertPhraseSuspended = "SUSPENDED"
+	// +k8s:openapi-gen=true
+	ElastAlertPhraseUpgrading = "UPGRADING"
+	// +k8s:openapi-gen=true
+	ElastAlertPhraseDegraded = "DEGRADED"
+
+	// ElastAlertReadyType sums the other conditions up, it is True when the resources of the generation are applied and
+	// every replica is available.
+	ElastAlertReadyType = "Ready"
+	// ElastAlertReconciledType tells whether the resources of the generation were applied.
+	ElastAlertReconciledType = "Reconciled"
+	// ElastAlertAvailableType tells whether every replica of the deployment is available, as the health checks found.
+	ElastAlertAvailableType = "Available"
+	// ElastAlertDegradedType tells whether replicas are unavailable, including while the health policy tolerates it.
+	ElastAlertDegradedType = "Degraded"
+
+	ElastAlertRunningReason = "Running"
+
+	ElastAlertInitializingReason = "Initializing"
+
+	ElastAlertReconcilingReason = "Reconciling"
+
+	ElastAlertReconcileSucceededReason = "ReconcileSucceeded"
+
+	ElastAlertReconcileFailedReason = "ReconcileFailed"
 
-	ElastAlertAvailableReason = "NewElastAlertAvailable"
+	ElastAlertReplicasAvailableReason = "ReplicasAvailable"
 
-	ElastAlertAvailableType = "Progressing"
+	ElastAlertReplicasUnavailableReason = "ReplicasUnavailable"
 
-	ElastAlertAvailableStatus = "True"
+	ElastAlertDeploymentMissingReason = "DeploymentMissing"
 
-	ElastAlertUnAvailableReason = "ElastAlertUnAvailable"
+	ElastAlertRolloutStuckReason = "RolloutStuck"
 
-	ElastAlertUnKnowReason = "ResourcesCreating"
+	ElastAlertCrashLoopBackOffReason = "CrashLoopBackOff"
 
-	ElastAlertUnAvailableType = "Stopped"
+	ElastAlertHealthCheckFailingReason = "HealthCheckFailing"
 
-	ElastAlertUnAvailableStatus = "False"
+	ElastAlertAsExpectedReason = "AsExpected"
 
-	ElastAlertUnKnownStatus = "Unknown"
+	ElastAlertUpgradeInProgressType = "UpgradeInProgress"
+
+	ElastAlertUpgradeInProgressReason = "ImagesRollingOut"
+
+	ElastAlertSuspendedReason = "SpecSuspended"
+
+	ElastAlertPausedType = "Paused"
+
+	ElastAlertPausedReason = "PausedAnnotation"
+
+	ElasticsearchReachableType = "ElasticsearchReachable"
+
+	ElasticsearchReachableReason = "PreflightSucceeded"
 
 	ResourcesCreating = "starting"
 
@@ -71,6 +109,33 @@ const (
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// Flavor selects the ElastAlert implementation run by an Elastalert.
+type Flavor string
+
+const (
+	// FlavorElastAlert runs the original Yelp ElastAlert. This is the default.
+	FlavorElastAlert Flavor = "elastalert"
+	// FlavorElastAlert2 runs ElastAlert 2.
+	FlavorElastAlert2 Flavor = "elastalert2"
+)
+
+// MergeStrategy defines how the overall alert settings are merged into a rule.
+type MergeStrategy string
+
+const (
+	// MergeStrategySkipIfPresent merges overall into rules which define no alert, keys of overall win. This is the default.
+	MergeStrategySkipIfPresent MergeStrategy = "skip-if-present"
+	// MergeStrategyDeepMerge merges overall into every rule recursively, keys of the rule win.
+	MergeStrategyDeepMerge MergeStrategy = "deep-merge"
+	// MergeStrategyAppendAlertList deep merges overall into every rule and appends the alerters of overall to the alert list of the rule.
+	MergeStrategyAppendAlertList MergeStrategy = "append-alert-list"
+	// MergeStrategyOverride sets the keys of overall in every rule, overwriting the ones of the rule.
+	MergeStrategyOverride MergeStrategy = "override"
+
+	// MergeStrategyRuleKey is the rule key selecting the merge strategy of overall for a single rule.
+	MergeStrategyRuleKey = "overall_merge_strategy"
+)
+
 // ElastalertSpec defines the desired state of Elastalert
 // +k8s:openapi-gen=true
 type ElastalertSpec struct {
@@ -80,18 +145,87 @@ type ElastalertSpec struct {
 	Image           string             `json:"image,omitempty"`
 	Cert            string             `json:"cert,omitempty"`
 
+	// Flavor selects the default image, command line and rule dialect.
+	// +kubebuilder:validation:Enum=elastalert;elastalert2
+	// +optional
+	Flavor Flavor `json:"flavor,omitempty"`
+	// Suspend scales the Deployment to zero to stop alerting, the ConfigMaps are kept.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+	// MaintenanceWindows suspend the instance or mute some of its rules on a schedule.
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+	// WritebackCleanup adds a finalizer removing the silences, pending aggregates and status documents of the rules
+	// from the writeback index on deletion, or the whole index if the operator created it.
+	// +optional
+	WritebackCleanup *WritebackCleanup `json:"writebackCleanup,omitempty"`
+	// WritebackIndex lets the operator create writeback_index and the indices derived from it, and apply a retention.
+	// +optional
+	WritebackIndex *WritebackIndexLifecycle `json:"writebackIndex,omitempty"`
+	// HealthPolicy tunes how often the health of the pods is checked and how tolerant the checks are.
+	// +optional
+	HealthPolicy *HealthPolicy `json:"healthPolicy,omitempty"`
+	// ValidateRuleMappings checks the fields the rules reference against the mappings of their index when the config
+	// is applied, and reports the findings in status.rules.
+	// +optional
+	ValidateRuleMappings bool `json:"validateRuleMappings,omitempty"`
+	// LintRules checks the rules against ElastAlert semantics when the config is applied, such as the options their
+	// type requires, and reports the findings in status.rules.
+	// +optional
+	LintRules bool `json:"lintRules,omitempty"`
+
 	ConfigSetting FreeForm   `json:"config"`
 	Rule          []FreeForm `json:"rule"`
 	// +optional
 	Alert FreeForm `json:"overall,omitempty"`
+	// OverallMergeStrategy decides how overall is merged into rules, rules may override it with overall_merge_strategy.
+	// +kubebuilder:validation:Enum=skip-if-present;deep-merge;append-alert-list;override
+	// +optional
+	OverallMergeStrategy MergeStrategy `json:"overallMergeStrategy,omitempty"`
+	// +optional
+	Alerters *Alerters `json:"alerters,omitempty"`
+	// Clusters are elasticsearch clusters which rules reference by name with es_cluster.
+	// +optional
+	Clusters []ElasticsearchCluster `json:"clusters,omitempty"`
+	// ClusterConfig names the ElastalertClusterConfig providing defaults, the one named "default" is used if it exists when empty.
+	// +optional
+	ClusterConfig string `json:"clusterConfig,omitempty"`
 }
 
 // +k8s:openapi-gen=true
 // ElastalertStatus defines the observed state of Elastalert
 type ElastalertStatus struct {
-	Version     string             `json:"version,omitempty"`
+	// Version is the tag of the image the pods run, or of the image to run until pods report one.
+	Version string `json:"version,omitempty"`
+	// Image is the image the pods run.
+	Image string `json:"image,omitempty"`
+	// ImageID is the image ID the pods report, which holds the digest of Image.
+	ImageID     string             `json:"imageID,omitempty"`
 	Phase       string             `json:"phase,omitempty"`
 	Condictions []metav1.Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the generation of the Elastalert whose resources were last applied.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// ConfigSources records where each config key comes from when an ElastalertClusterConfig is used.
+	ConfigSources map[string]string `json:"configSources,omitempty"`
+	// ClusterConfigGeneration is the generation of the ElastalertClusterConfig last applied.
+	ClusterConfigGeneration int64 `json:"clusterConfigGeneration,omitempty"`
+	// SecretsHash is the hash of the values of the Secrets referenced by the Elastalert when its resources were last
+	// applied.
+	SecretsHash string `json:"secretsHash,omitempty"`
+	// AlertProfileGenerations are the generations of the alert profiles last applied, by kind and name.
+	AlertProfileGenerations map[string]int64 `json:"alertProfileGenerations,omitempty"`
+	// Maintenance reports the active and the next maintenance window.
+	Maintenance *MaintenanceStatus `json:"maintenance,omitempty"`
+	// Health reports the failed health checks of the pods.
+	Health *HealthStatus `json:"health,omitempty"`
+	// LastFailure tells why the pods failed when the instance was last marked FAILED by a health check.
+	LastFailure *PodFailure `json:"lastFailure,omitempty"`
+	// WritebackIndices report the size of the writeback indices managed by the operator.
+	WritebackIndices []WritebackIndexStatus `json:"writebackIndices,omitempty"`
+	// WritebackIndexSyncTime is when the writeback indices were last synced.
+	WritebackIndexSyncTime *metav1.Time `json:"writebackIndexSyncTime,omitempty"`
+	// Rules report the validation of the rules against the mappings of their index.
+	Rules []RuleStatus `json:"rules,omitempty"`
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 }
@@ -101,7 +235,8 @@ type ElastalertStatus struct {
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase",description="Elastalert instance's status"
-// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.version",description="Elastalert Version"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Whether the resources are applied and every replica is available"
+// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.version",description="Version of the running Elastalert image"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // Elastalert is the Schema for the elastalerts API
 type Elastalert struct {
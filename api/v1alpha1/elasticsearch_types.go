@@ -0,0 +1,52 @@
+/*
+
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ClusterRuleKey is the rule key referencing an elasticsearch cluster of spec.clusters by name.
+	ClusterRuleKey = "es_cluster"
+)
+
+// ElasticsearchCluster defines an elasticsearch cluster which rules query instead of the one of config.yaml.
+// +k8s:openapi-gen=true
+type ElasticsearchCluster struct {
+	// Name is referenced by rules with es_cluster.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=49
+	Name string `json:"name"`
+	Host string `json:"host"`
+	Port int    `json:"port"`
+	// +optional
+	UseSSL bool `json:"useSsl,omitempty"`
+	// CA selects the secret key holding the CA certificate of the cluster, it is mounted and set as ca_certs.
+	// +optional
+	CA *v1.SecretKeySelector `json:"ca,omitempty"`
+	// Credentials are delivered to rules as es_username and es_password in the file they import.
+	// +optional
+	Credentials *ElasticsearchCredentials `json:"credentials,omitempty"`
+}
+
+// ElasticsearchCredentials selects the secret keys holding the credentials of an elasticsearch cluster.
+type ElasticsearchCredentials struct {
+	Username v1.SecretKeySelector `json:"username"`
+	Password v1.SecretKeySelector `json:"password"`
+}
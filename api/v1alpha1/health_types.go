@@ -0,0 +1,88 @@
+/*
+
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultHealthFailureThreshold is the number of consecutive failed health checks marking an Elastalert FAILED
+	// when the health policy sets none.
+	DefaultHealthFailureThreshold = 1
+)
+
+// HealthPolicy tunes how the health of the pods of an Elastalert is evaluated.
+// +k8s:openapi-gen=true
+type HealthPolicy struct {
+	// Interval is how often the health is checked besides the changes of the deployment and its pods, 1m by default.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// GracePeriod is how long after a rollout unavailable replicas are not counted as failures.
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+	// FailureThreshold is the number of consecutive failed checks, one interval apart at least, after which the
+	// Elastalert is FAILED, 1 by default.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+	// FailOnCrashLoop marks the Elastalert FAILED as soon as a pod is in CrashLoopBackOff, regardless of the grace
+	// period and the failure threshold.
+	// +optional
+	FailOnCrashLoop bool `json:"failOnCrashLoop,omitempty"`
+}
+
+// HealthStatus reports the failed health checks since the last healthy one.
+type HealthStatus struct {
+	// RolloutTime is when the resources were last applied, which starts the grace period.
+	RolloutTime *metav1.Time `json:"rolloutTime,omitempty"`
+	// ConsecutiveFailures is the number of failed checks since the last healthy one.
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+	// LastFailureTime is when the last failed check was counted.
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+}
+
+// Reasons of PodFailure.
+const (
+	PodFailureUnschedulable    = "Unschedulable"
+	PodFailureImagePullBackOff = "ImagePullBackOff"
+	PodFailureOOMKilled        = "OOMKilled"
+	PodFailureCrashLoopBackOff = "CrashLoopBackOff"
+)
+
+// PodFailure tells why a pod of an Elastalert failed, as the health check marking it FAILED found it.
+type PodFailure struct {
+	// Reason is Unschedulable, ImagePullBackOff, OOMKilled or CrashLoopBackOff.
+	Reason string `json:"reason"`
+	// Pod is the name of the failed pod.
+	Pod string `json:"pod"`
+	// Container is the name of the failed container, empty for an unschedulable pod.
+	// +optional
+	Container string `json:"container,omitempty"`
+	// Message is the last termination message of the container, or the message of the scheduler or the kubelet.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// RestartCount is the number of restarts of the container.
+	// +optional
+	RestartCount int32 `json:"restartCount,omitempty"`
+	// LogTail is the end of the log of the last run of the container.
+	// +optional
+	LogTail string `json:"logTail,omitempty"`
+	// Time is when the failure was found.
+	Time metav1.Time `json:"time"`
+}
@@ -0,0 +1,49 @@
+/*
+
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MaintenanceWindow defines a recurring period during which alerting is muted.
+// +k8s:openapi-gen=true
+type MaintenanceWindow struct {
+	// Schedule is a cron expression of five fields, or @hourly, @daily, @weekly or @monthly, at which the window starts.
+	Schedule string `json:"schedule"`
+	// Duration is how long the window lasts, e.g. 2h.
+	Duration metav1.Duration `json:"duration"`
+	// TimeZone is the IANA time zone the schedule is evaluated in, UTC when empty.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+	// Rules are the names of the rules muted during the window, the whole instance is suspended when empty.
+	// +optional
+	Rules []string `json:"rules,omitempty"`
+}
+
+// MaintenanceStatus reports the effect of the maintenance windows.
+type MaintenanceStatus struct {
+	// Suspended tells whether an active window suspends the whole instance.
+	Suspended bool `json:"suspended,omitempty"`
+	// MutedRules are the names of the rules muted by active windows.
+	MutedRules []string `json:"mutedRules,omitempty"`
+	// ActiveUntil is when the last active window ends.
+	ActiveUntil *metav1.Time `json:"activeUntil,omitempty"`
+	// NextWindow is when the next window starts.
+	NextWindow *metav1.Time `json:"nextWindow,omitempty"`
+}
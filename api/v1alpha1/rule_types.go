@@ -0,0 +1,28 @@
+/*
+
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// RuleStatus reports the lint findings of a rule and its validation against the mappings of its index.
+type RuleStatus struct {
+	// Name is the name of the rule.
+	Name string `json:"name"`
+	// Warnings are the lint findings, and the fields the rule references which are missing from the mappings or have
+	// an unexpected type.
+	// +optional
+	Warnings []string `json:"warnings,omitempty"`
+}
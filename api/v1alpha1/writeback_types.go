@@ -0,0 +1,53 @@
+/*
+
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultWritebackIndex is the writeback index ElastAlert uses when config.yaml sets no writeback_index.
+	DefaultWritebackIndex = "elastalert_status"
+)
+
+// WritebackCleanup removes the state of an Elastalert from its writeback index when the Elastalert is deleted.
+// +k8s:openapi-gen=true
+type WritebackCleanup struct {
+	// Timeout after which the cleanup is given up and the Elastalert is deleted anyway, 5m by default.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// WritebackIndexLifecycle lets the operator create the writeback indices of an Elastalert and apply a retention.
+// +k8s:openapi-gen=true
+type WritebackIndexLifecycle struct {
+	// Retention is how long documents are kept, forever when empty.
+	// +optional
+	Retention *metav1.Duration `json:"retention,omitempty"`
+}
+
+// WritebackIndexStatus reports the size of a writeback index.
+type WritebackIndexStatus struct {
+	// Name is the name of the index.
+	Name string `json:"name"`
+	// Documents is the number of documents of the primaries.
+	Documents int64 `json:"documents"`
+	// SizeBytes is the store size of the primaries.
+	SizeBytes int64 `json:"sizeBytes"`
+}
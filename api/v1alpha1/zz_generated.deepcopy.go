@@ -21,10 +21,130 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertProfileSpec) DeepCopyInto(out *AlertProfileSpec) {
+	*out = *in
+	in.Alert.DeepCopyInto(&out.Alert)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertProfileSpec.
+func (in *AlertProfileSpec) DeepCopy() *AlertProfileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertProfileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Alerters) DeepCopyInto(out *Alerters) {
+	*out = *in
+	if in.Slack != nil {
+		in, out := &in.Slack, &out.Slack
+		*out = new(SlackAlerter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Email != nil {
+		in, out := &in.Email, &out.Email
+		*out = new(EmailAlerter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PagerDuty != nil {
+		in, out := &in.PagerDuty, &out.PagerDuty
+		*out = new(PagerDutyAlerter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Opsgenie != nil {
+		in, out := &in.Opsgenie, &out.Opsgenie
+		*out = new(OpsgenieAlerter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MsTeams != nil {
+		in, out := &in.MsTeams, &out.MsTeams
+		*out = new(MsTeamsAlerter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTPPost != nil {
+		in, out := &in.HTTPPost, &out.HTTPPost
+		*out = new(HTTPPostAlerter)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Alerters.
+func (in *Alerters) DeepCopy() *Alerters {
+	if in == nil {
+		return nil
+	}
+	out := new(Alerters)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterElastalertAlertProfile) DeepCopyInto(out *ClusterElastalertAlertProfile) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterElastalertAlertProfile.
+func (in *ClusterElastalertAlertProfile) DeepCopy() *ClusterElastalertAlertProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterElastalertAlertProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterElastalertAlertProfile) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterElastalertAlertProfileList) DeepCopyInto(out *ClusterElastalertAlertProfileList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterElastalertAlertProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterElastalertAlertProfileList.
+func (in *ClusterElastalertAlertProfileList) DeepCopy() *ClusterElastalertAlertProfileList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterElastalertAlertProfileList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterElastalertAlertProfileList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Elastalert) DeepCopyInto(out *Elastalert) {
 	*out = *in
@@ -52,6 +172,138 @@ func (in *Elastalert) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElastalertAlertProfile) DeepCopyInto(out *ElastalertAlertProfile) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElastalertAlertProfile.
+func (in *ElastalertAlertProfile) DeepCopy() *ElastalertAlertProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(ElastalertAlertProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElastalertAlertProfile) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElastalertAlertProfileList) DeepCopyInto(out *ElastalertAlertProfileList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElastalertAlertProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElastalertAlertProfileList.
+func (in *ElastalertAlertProfileList) DeepCopy() *ElastalertAlertProfileList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElastalertAlertProfileList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElastalertAlertProfileList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElastalertClusterConfig) DeepCopyInto(out *ElastalertClusterConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElastalertClusterConfig.
+func (in *ElastalertClusterConfig) DeepCopy() *ElastalertClusterConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ElastalertClusterConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElastalertClusterConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElastalertClusterConfigList) DeepCopyInto(out *ElastalertClusterConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElastalertClusterConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElastalertClusterConfigList.
+func (in *ElastalertClusterConfigList) DeepCopy() *ElastalertClusterConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElastalertClusterConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElastalertClusterConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElastalertClusterConfigSpec) DeepCopyInto(out *ElastalertClusterConfigSpec) {
+	*out = *in
+	in.Config.DeepCopyInto(&out.Config)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElastalertClusterConfigSpec.
+func (in *ElastalertClusterConfigSpec) DeepCopy() *ElastalertClusterConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElastalertClusterConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ElastalertList) DeepCopyInto(out *ElastalertList) {
 	*out = *in
@@ -88,6 +340,28 @@ func (in *ElastalertList) DeepCopyObject() runtime.Object {
 func (in *ElastalertSpec) DeepCopyInto(out *ElastalertSpec) {
 	*out = *in
 	in.PodTemplateSpec.DeepCopyInto(&out.PodTemplateSpec)
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.WritebackCleanup != nil {
+		in, out := &in.WritebackCleanup, &out.WritebackCleanup
+		*out = new(WritebackCleanup)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WritebackIndex != nil {
+		in, out := &in.WritebackIndex, &out.WritebackIndex
+		*out = new(WritebackIndexLifecycle)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HealthPolicy != nil {
+		in, out := &in.HealthPolicy, &out.HealthPolicy
+		*out = new(HealthPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	in.ConfigSetting.DeepCopyInto(&out.ConfigSetting)
 	if in.Rule != nil {
 		in, out := &in.Rule, &out.Rule
@@ -97,6 +371,18 @@ func (in *ElastalertSpec) DeepCopyInto(out *ElastalertSpec) {
 		}
 	}
 	in.Alert.DeepCopyInto(&out.Alert)
+	if in.Alerters != nil {
+		in, out := &in.Alerters, &out.Alerters
+		*out = new(Alerters)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]ElasticsearchCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElastalertSpec.
@@ -119,6 +405,52 @@ func (in *ElastalertStatus) DeepCopyInto(out *ElastalertStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ConfigSources != nil {
+		in, out := &in.ConfigSources, &out.ConfigSources
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.AlertProfileGenerations != nil {
+		in, out := &in.AlertProfileGenerations, &out.AlertProfileGenerations
+		*out = make(map[string]int64, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(MaintenanceStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Health != nil {
+		in, out := &in.Health, &out.Health
+		*out = new(HealthStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastFailure != nil {
+		in, out := &in.LastFailure, &out.LastFailure
+		*out = new(PodFailure)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WritebackIndices != nil {
+		in, out := &in.WritebackIndices, &out.WritebackIndices
+		*out = make([]WritebackIndexStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.WritebackIndexSyncTime != nil {
+		in, out := &in.WritebackIndexSyncTime, &out.WritebackIndexSyncTime
+		*out = new(v1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]RuleStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElastalertStatus.
@@ -131,6 +463,73 @@ func (in *ElastalertStatus) DeepCopy() *ElastalertStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchCluster) DeepCopyInto(out *ElasticsearchCluster) {
+	*out = *in
+	if in.CA != nil {
+		in, out := &in.CA, &out.CA
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(ElasticsearchCredentials)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchCluster.
+func (in *ElasticsearchCluster) DeepCopy() *ElasticsearchCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchCredentials) DeepCopyInto(out *ElasticsearchCredentials) {
+	*out = *in
+	in.Username.DeepCopyInto(&out.Username)
+	in.Password.DeepCopyInto(&out.Password)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchCredentials.
+func (in *ElasticsearchCredentials) DeepCopy() *ElasticsearchCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EmailAlerter) DeepCopyInto(out *EmailAlerter) {
+	*out = *in
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = new(SMTPAuth)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EmailAlerter.
+func (in *EmailAlerter) DeepCopy() *EmailAlerter {
+	if in == nil {
+		return nil
+	}
+	out := new(EmailAlerter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FreeForm) DeepCopyInto(out *FreeForm) {
 	*out = *in
@@ -154,3 +553,316 @@ func (in *FreeForm) DeepCopy() *FreeForm {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPPostAlerter) DeepCopyInto(out *HTTPPostAlerter) {
+	*out = *in
+	in.URL.DeepCopyInto(&out.URL)
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StaticPayload != nil {
+		in, out := &in.StaticPayload, &out.StaticPayload
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPPostAlerter.
+func (in *HTTPPostAlerter) DeepCopy() *HTTPPostAlerter {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPPostAlerter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthPolicy) DeepCopyInto(out *HealthPolicy) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthPolicy.
+func (in *HealthPolicy) DeepCopy() *HealthPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthStatus) DeepCopyInto(out *HealthStatus) {
+	*out = *in
+	if in.RolloutTime != nil {
+		in, out := &in.RolloutTime, &out.RolloutTime
+		*out = new(v1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastFailureTime != nil {
+		in, out := &in.LastFailureTime, &out.LastFailureTime
+		*out = new(v1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthStatus.
+func (in *HealthStatus) DeepCopy() *HealthStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceStatus) DeepCopyInto(out *MaintenanceStatus) {
+	*out = *in
+	if in.MutedRules != nil {
+		in, out := &in.MutedRules, &out.MutedRules
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ActiveUntil != nil {
+		in, out := &in.ActiveUntil, &out.ActiveUntil
+		*out = new(v1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NextWindow != nil {
+		in, out := &in.NextWindow, &out.NextWindow
+		*out = new(v1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceStatus.
+func (in *MaintenanceStatus) DeepCopy() *MaintenanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MsTeamsAlerter) DeepCopyInto(out *MsTeamsAlerter) {
+	*out = *in
+	in.WebhookURL.DeepCopyInto(&out.WebhookURL)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MsTeamsAlerter.
+func (in *MsTeamsAlerter) DeepCopy() *MsTeamsAlerter {
+	if in == nil {
+		return nil
+	}
+	out := new(MsTeamsAlerter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpsgenieAlerter) DeepCopyInto(out *OpsgenieAlerter) {
+	*out = *in
+	in.Key.DeepCopyInto(&out.Key)
+	if in.Recipients != nil {
+		in, out := &in.Recipients, &out.Recipients
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Teams != nil {
+		in, out := &in.Teams, &out.Teams
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpsgenieAlerter.
+func (in *OpsgenieAlerter) DeepCopy() *OpsgenieAlerter {
+	if in == nil {
+		return nil
+	}
+	out := new(OpsgenieAlerter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PagerDutyAlerter) DeepCopyInto(out *PagerDutyAlerter) {
+	*out = *in
+	in.ServiceKey.DeepCopyInto(&out.ServiceKey)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PagerDutyAlerter.
+func (in *PagerDutyAlerter) DeepCopy() *PagerDutyAlerter {
+	if in == nil {
+		return nil
+	}
+	out := new(PagerDutyAlerter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodFailure) DeepCopyInto(out *PodFailure) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodFailure.
+func (in *PodFailure) DeepCopy() *PodFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(PodFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuleStatus) DeepCopyInto(out *RuleStatus) {
+	*out = *in
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleStatus.
+func (in *RuleStatus) DeepCopy() *RuleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RuleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SMTPAuth) DeepCopyInto(out *SMTPAuth) {
+	*out = *in
+	in.User.DeepCopyInto(&out.User)
+	in.Password.DeepCopyInto(&out.Password)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SMTPAuth.
+func (in *SMTPAuth) DeepCopy() *SMTPAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(SMTPAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SlackAlerter) DeepCopyInto(out *SlackAlerter) {
+	*out = *in
+	in.WebhookURL.DeepCopyInto(&out.WebhookURL)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SlackAlerter.
+func (in *SlackAlerter) DeepCopy() *SlackAlerter {
+	if in == nil {
+		return nil
+	}
+	out := new(SlackAlerter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WritebackCleanup) DeepCopyInto(out *WritebackCleanup) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WritebackCleanup.
+func (in *WritebackCleanup) DeepCopy() *WritebackCleanup {
+	if in == nil {
+		return nil
+	}
+	out := new(WritebackCleanup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WritebackIndexLifecycle) DeepCopyInto(out *WritebackIndexLifecycle) {
+	*out = *in
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WritebackIndexLifecycle.
+func (in *WritebackIndexLifecycle) DeepCopy() *WritebackIndexLifecycle {
+	if in == nil {
+		return nil
+	}
+	out := new(WritebackIndexLifecycle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WritebackIndexStatus) DeepCopyInto(out *WritebackIndexStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WritebackIndexStatus.
+func (in *WritebackIndexStatus) DeepCopy() *WritebackIndexStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WritebackIndexStatus)
+	in.DeepCopyInto(out)
+	return out
+}
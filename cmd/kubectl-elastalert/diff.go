@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	"io"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+	"sort"
+	"strings"
+)
+
+const (
+	// restartedAtAnnotation is set to the time of every rollout of the Deployment, it is left out of the comparison.
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+	// hidden replaces the values of sensitive options.
+	hidden = "(hidden)"
+)
+
+func runDiff(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	var files fileFlags
+	fs.Var(&files, "f", "Manifest holding the changed Elastalerts. Repeatable, - reads stdin.")
+	namespace := fs.String("n", "", "Namespace of the Elastalerts which set none, the one of the kubeconfig context by default.")
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig file.")
+	kubeContext := fs.String("context", "", "The kubeconfig context to use.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	objects, err := readManifests(files, stdin)
+	if err != nil {
+		return err
+	}
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = *kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: *kubeContext}
+	overrides.Context.Namespace = *namespace
+	loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+	config, err := loader.ClientConfig()
+	if err != nil {
+		return err
+	}
+	ns, _, err := loader.Namespace()
+	if err != nil {
+		return err
+	}
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, obj := range objects {
+		e, ok := obj.(*esv1alpha1.Elastalert)
+		if !ok {
+			continue
+		}
+		if e.Namespace == "" {
+			e.Namespace = ns
+		}
+		if found {
+			fmt.Fprintln(stdout)
+		}
+		found = true
+		if err = diff(c, context.Background(), e, stdout); err != nil {
+			return fmt.Errorf("Elastalert %s/%s: %v", e.Namespace, e.Name, err)
+		}
+	}
+	if !found {
+		return fmt.Errorf("no Elastalert found in the manifests")
+	}
+	return nil
+}
+
+// diff writes what applying the Elastalert changes in the live instance: the rules added, removed or changed, the
+// changed keys of config.yaml and of the secrets, and what happens to the pods. The desired resources are rendered
+// with the ElastalertClusterConfig, alert profiles and secrets read with c.
+func diff(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert, w io.Writer) error {
+	live := &esv1alpha1.Elastalert{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(e), live); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+		live = nil
+	}
+	specChanged := live == nil || !sameJSON(live.Spec, e.Spec)
+	desired, err := controllers.Render(c, scheme, ctx, e.DeepCopy())
+	if err != nil {
+		return err
+	}
+	current, err := liveResources(c, ctx, e)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Elastalert %s/%s\n", e.Namespace, e.Name)
+	if live == nil {
+		buf.WriteString("  not found, it is created\n")
+	} else if podspec.IsPaused(live) {
+		fmt.Fprintf(&buf, "  paused, nothing is applied until the %s annotation is removed\n", podspec.PausedAnnotation)
+	}
+	liveRules, err := ruleFiles(current.ConfigMaps, e.Name)
+	if err != nil {
+		return err
+	}
+	desiredRules, err := ruleFiles(desired.ConfigMaps, e.Name)
+	if err != nil {
+		return err
+	}
+	writeSection(&buf, "Rules", diffRules(liveRules, desiredRules))
+	liveConfig, err := configFile(current.ConfigMaps, e.Name)
+	if err != nil {
+		return err
+	}
+	desiredConfig, err := configFile(desired.ConfigMaps, e.Name)
+	if err != nil {
+		return err
+	}
+	writeSection(&buf, "Config", diffOptions(liveConfig, desiredConfig))
+	writeSection(&buf, "Secrets", diffSecrets(current.Secrets, desired.Secrets))
+	fmt.Fprintf(&buf, "Pods: %s\n", podsOutcome(live, e, current.Deployment, desired.Deployment, specChanged))
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// liveResources gets the ConfigMaps, Secrets and Deployment of the instance.
+func liveResources(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert) (*controllers.Resources, error) {
+	resources := &controllers.Resources{}
+	configMaps := &corev1.ConfigMapList{}
+	if err := c.List(ctx, configMaps, client.InNamespace(e.Namespace), client.MatchingLabels(podspec.InstanceLabels(e.Name))); err != nil {
+		return nil, err
+	}
+	resources.ConfigMaps = configMaps.Items
+	for _, name := range []string{e.Name + podspec.DefaultCertSuffix, e.Name + podspec.DefaultAlerterSecretSuffix} {
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: e.Namespace, Name: name}, secret); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		resources.Secrets = append(resources.Secrets, *secret)
+	}
+	deploy := &appsv1.Deployment{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: e.Namespace, Name: e.Name}, deploy); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return nil, err
+		}
+		deploy = nil
+	}
+	resources.Deployment = deploy
+	return resources, nil
+}
+
+// ruleFiles returns the rules of the rule ConfigMaps of the instance by name.
+func ruleFiles(configMaps []corev1.ConfigMap, eaName string) (map[string]map[string]interface{}, error) {
+	rules := map[string]map[string]interface{}{}
+	for _, cm := range configMaps {
+		if _, ok := cm.Labels[podspec.RuleShardLabel]; !ok && cm.Name != eaName+esv1alpha1.RuleSuffx {
+			continue
+		}
+		for file, data := range cm.Data {
+			rule := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(data), &rule); err != nil {
+				return nil, fmt.Errorf("ConfigMap %s: %s: %v", cm.Name, file, err)
+			}
+			rules[strings.TrimSuffix(file, ".yaml")] = rule
+		}
+	}
+	return rules, nil
+}
+
+// configFile returns the options of the config.yaml of the instance.
+func configFile(configMaps []corev1.ConfigMap, eaName string) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	for _, cm := range configMaps {
+		if cm.Name != eaName+esv1alpha1.ConfigSuffx {
+			continue
+		}
+		if err := yaml.Unmarshal([]byte(cm.Data["config.yaml"]), &config); err != nil {
+			return nil, fmt.Errorf("ConfigMap %s: %v", cm.Name, err)
+		}
+	}
+	return config, nil
+}
+
+// diffRules lists the rules added, removed and changed, each changed rule followed by its changed options.
+func diffRules(live, desired map[string]map[string]interface{}) []string {
+	var lines []string
+	for _, name := range sortedKeys(live, desired) {
+		before, inLive := live[name]
+		after, inDesired := desired[name]
+		switch {
+		case !inLive:
+			lines = append(lines, "+ "+name)
+		case !inDesired:
+			lines = append(lines, "- "+name)
+		default:
+			options := diffOptions(before, after)
+			if len(options) == 0 {
+				continue
+			}
+			lines = append(lines, "~ "+name)
+			for _, option := range options {
+				lines = append(lines, "    "+option)
+			}
+		}
+	}
+	return lines
+}
+
+// diffOptions lists the top-level options added, removed and changed, hiding the values of sensitive options.
+func diffOptions(live, desired map[string]interface{}) []string {
+	var lines []string
+	for _, key := range sortedKeys(live, desired) {
+		before, inLive := live[key]
+		after, inDesired := desired[key]
+		switch {
+		case !inLive:
+			lines = append(lines, fmt.Sprintf("+ %s: %s", key, formatValue(key, after)))
+		case !inDesired:
+			lines = append(lines, fmt.Sprintf("- %s: %s", key, formatValue(key, before)))
+		case !reflect.DeepEqual(before, after):
+			lines = append(lines, fmt.Sprintf("~ %s: %s -> %s", key, formatValue(key, before), formatValue(key, after)))
+		}
+	}
+	return lines
+}
+
+// diffSecrets lists the secrets and the keys of them which change, their values are never shown.
+func diffSecrets(live, desired []corev1.Secret) []string {
+	secrets := func(list []corev1.Secret) map[string]map[string][]byte {
+		m := map[string]map[string][]byte{}
+		for _, s := range list {
+			m[s.Name] = s.Data
+		}
+		return m
+	}
+	before, after := secrets(live), secrets(desired)
+	var lines []string
+	for _, name := range sortedKeys(before, after) {
+		liveData, inLive := before[name]
+		desiredData, inDesired := after[name]
+		switch {
+		case !inLive:
+			lines = append(lines, "+ "+name)
+		case !inDesired:
+			lines = append(lines, "- "+name)
+		default:
+			var keys []string
+			for _, key := range sortedKeys(liveData, desiredData) {
+				if !bytes.Equal(liveData[key], desiredData[key]) {
+					keys = append(keys, key)
+				}
+			}
+			if len(keys) != 0 {
+				lines = append(lines, fmt.Sprintf("~ %s: %s", name, strings.Join(keys, ", ")))
+			}
+		}
+	}
+	return lines
+}
+
+// podsOutcome tells what applying the Elastalert does to the pods. Reconcile only applies a changed spec, and every
+// apply rolls the Deployment out again.
+func podsOutcome(live, e *esv1alpha1.Elastalert, current, desired *appsv1.Deployment, specChanged bool) string {
+	switch {
+	case current == nil:
+		return "created"
+	case !specChanged:
+		return "unchanged"
+	case podspec.IsPaused(e):
+		return "unchanged, the instance is paused"
+	case e.Spec.Suspend && (live == nil || !live.Spec.Suspend):
+		return "stopped, the instance is suspended"
+	case live != nil && live.Spec.Suspend && !e.Spec.Suspend:
+		return "started, the instance is resumed"
+	}
+	changes := templateChanges(current.Spec.Template, desired.Spec.Template)
+	if len(changes) == 0 {
+		return "restarted, the Deployment is rolled out again"
+	}
+	return "restarted, the pod template changes " + strings.Join(changes, ", ")
+}
+
+// templateChanges names the parts of the live pod template which differ from the desired one, fields the desired
+// template leaves to their defaults are not compared.
+func templateChanges(live, desired corev1.PodTemplateSpec) []string {
+	live, desired = *live.DeepCopy(), *desired.DeepCopy()
+	delete(live.Annotations, restartedAtAnnotation)
+	delete(desired.Annotations, restartedAtAnnotation)
+	var changes []string
+	if !equality.Semantic.DeepDerivative(desired.ObjectMeta, live.ObjectMeta) {
+		changes = append(changes, "metadata")
+	}
+	containers := map[string]corev1.Container{}
+	for _, c := range live.Spec.Containers {
+		containers[c.Name] = c
+	}
+	for _, c := range desired.Spec.Containers {
+		if l, ok := containers[c.Name]; !ok || !equality.Semantic.DeepDerivative(c, l) {
+			changes = append(changes, "container "+c.Name)
+		}
+	}
+	if !equality.Semantic.DeepDerivative(desired.Spec.InitContainers, live.Spec.InitContainers) {
+		changes = append(changes, "init containers")
+	}
+	if !equality.Semantic.DeepDerivative(desired.Spec.Volumes, live.Spec.Volumes) {
+		changes = append(changes, "volumes")
+	}
+	live.Spec.Containers, desired.Spec.Containers = nil, nil
+	live.Spec.InitContainers, desired.Spec.InitContainers = nil, nil
+	live.Spec.Volumes, desired.Spec.Volumes = nil, nil
+	if !equality.Semantic.DeepDerivative(desired.Spec, live.Spec) {
+		changes = append(changes, "pod spec")
+	}
+	return changes
+}
+
+func writeSection(buf *bytes.Buffer, title string, lines []string) {
+	if len(lines) == 0 {
+		fmt.Fprintf(buf, "%s: unchanged\n", title)
+		return
+	}
+	fmt.Fprintf(buf, "%s:\n", title)
+	for _, line := range lines {
+		fmt.Fprintf(buf, "  %s\n", line)
+	}
+}
+
+// formatValue encodes the value of the option to JSON, the values of sensitive options are hidden, nested ones as well.
+func formatValue(key string, value interface{}) string {
+	if podspec.IsSensitiveName(key) {
+		return hidden
+	}
+	out, err := json.Marshal(hideSensitive(value))
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(out)
+}
+
+// hideSensitive returns a copy of the value where the values of the sensitive keys of the nested maps are hidden.
+func hideSensitive(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			if podspec.IsSensitiveName(k) {
+				out[k] = hidden
+			} else {
+				out[k] = hideSensitive(item)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = hideSensitive(item)
+		}
+		return out
+	}
+	return value
+}
+
+// sameJSON tells whether both values encode to the same JSON document, regardless of key order.
+func sameJSON(a, b interface{}) bool {
+	var decoded [2]interface{}
+	for i, v := range []interface{}{a, b} {
+		out, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		if err = json.Unmarshal(out, &decoded[i]); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(decoded[0], decoded[1])
+}
+
+// sortedKeys returns the keys of both maps, sorted.
+func sortedKeys(a, b interface{}) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range []interface{}{a, b} {
+		for _, k := range reflect.ValueOf(m).MapKeys() {
+			if key := k.String(); !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
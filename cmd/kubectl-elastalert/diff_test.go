@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	rule := func(name string, numEvents int) esv1alpha1.FreeForm {
+		return esv1alpha1.NewFreeForm(map[string]interface{}{"name": name, "type": "frequency", "num_events": numEvents})
+	}
+	newElastalert := func(runEvery int, password string, rules ...esv1alpha1.FreeForm) *esv1alpha1.Elastalert {
+		return &esv1alpha1.Elastalert{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "my-esa"},
+			Spec: esv1alpha1.ElastalertSpec{
+				ConfigSetting: esv1alpha1.NewFreeForm(map[string]interface{}{
+					"run_every":       map[string]interface{}{"minutes": runEvery},
+					"buffer_time":     map[string]interface{}{"minutes": 15},
+					"es_host":         "es.default",
+					"es_password":     password,
+					"writeback_index": "elastalert",
+				}),
+				Rule: rules,
+			},
+		}
+	}
+	live := newElastalert(1, "changeme", rule("a", 5), rule("b", 5))
+	testCases := []struct {
+		desc     string
+		live     bool
+		desired  func() *esv1alpha1.Elastalert
+		want     string
+		contains []string
+	}{
+		{
+			desc:    "test diff without change",
+			live:    true,
+			desired: live.DeepCopy,
+			want: `Elastalert esa1/my-esa
+Rules: unchanged
+Config: unchanged
+Secrets: unchanged
+Pods: unchanged
+`,
+		},
+		{
+			desc: "test diff of changed rules and config",
+			live: true,
+			desired: func() *esv1alpha1.Elastalert {
+				e := newElastalert(2, "s3cret", rule("a", 10), rule("c", 5))
+				e.Spec.Image = "toughnoah/elastalert:v2"
+				return e
+			},
+			want: `Elastalert esa1/my-esa
+Rules:
+  ~ a
+      ~ num_events: 5 -> 10
+  - b
+  + c
+Config:
+  ~ es_password: (hidden) -> (hidden)
+  ~ run_every: {"minutes":1} -> {"minutes":2}
+Secrets: unchanged
+Pods: restarted, the pod template changes container elastalert
+`,
+		},
+		{
+			desc: "test diff of a suspended instance",
+			live: true,
+			desired: func() *esv1alpha1.Elastalert {
+				e := live.DeepCopy()
+				e.Spec.Suspend = true
+				return e
+			},
+			contains: []string{"Rules: unchanged\n", "Pods: stopped, the instance is suspended\n"},
+		},
+		{
+			desc: "test diff of a cert added",
+			live: true,
+			desired: func() *esv1alpha1.Elastalert {
+				e := live.DeepCopy()
+				e.Spec.Cert = "abc"
+				return e
+			},
+			contains: []string{"Secrets:\n  + my-esa-es-cert\n", "Pods: restarted, the Deployment is rolled out again\n"},
+		},
+		{
+			desc:     "test diff of a new instance",
+			desired:  live.DeepCopy,
+			contains: []string{"  not found, it is created\n", "Rules:\n  + a\n  + b\n", "  + es_password: (hidden)\n", "Pods: created\n"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			if tc.live {
+				e := live.DeepCopy()
+				resources, err := controllers.Render(builder.Build(), scheme, context.Background(), e.DeepCopy())
+				require.NoError(t, err)
+				builder = builder.WithObjects(append(resources.Objects(), e)...)
+			}
+			var out bytes.Buffer
+			require.NoError(t, diff(builder.Build(), context.Background(), tc.desired(), &out))
+			if tc.want != "" {
+				require.Equal(t, tc.want, out.String())
+			}
+			for _, s := range tc.contains {
+				assert.Contains(t, out.String(), s)
+			}
+		})
+	}
+}
+
+func TestDiffOptionsHidesSecrets(t *testing.T) {
+	live := map[string]interface{}{
+		"pagerduty_service_key": "pd-old",
+		"opsgenie_key":          "og-old",
+		"victorops_api_key":     "vo-old",
+		"alerta_api_key":        "al-old",
+		"query_key":             "host",
+		"http_post_headers":     map[string]interface{}{"Authorization": "Bearer old", "Accept": "application/json"},
+		"alert": []interface{}{
+			map[string]interface{}{"post": map[string]interface{}{"http_post_url": "http://hook", "http_post_headers": map[string]interface{}{"X-Api-Key": "old"}}},
+		},
+	}
+	desired := map[string]interface{}{
+		"pagerduty_service_key": "pd-new",
+		"opsgenie_key":          "og-new",
+		"victorops_api_key":     "vo-new",
+		"alerta_api_key":        "al-new",
+		"query_key":             "service",
+		"http_post_headers":     map[string]interface{}{"Authorization": "Bearer new", "Accept": "application/json"},
+		"alert": []interface{}{
+			map[string]interface{}{"post": map[string]interface{}{"http_post_url": "http://hook", "http_post_headers": map[string]interface{}{"X-Api-Key": "new"}}},
+		},
+	}
+	require.Equal(t, []string{
+		`~ alert: [{"post":{"http_post_headers":{"X-Api-Key":"(hidden)"},"http_post_url":"http://hook"}}] -> [{"post":{"http_post_headers":{"X-Api-Key":"(hidden)"},"http_post_url":"http://hook"}}]`,
+		`~ alerta_api_key: (hidden) -> (hidden)`,
+		`~ http_post_headers: {"Accept":"application/json","Authorization":"(hidden)"} -> {"Accept":"application/json","Authorization":"(hidden)"}`,
+		`~ opsgenie_key: (hidden) -> (hidden)`,
+		`~ pagerduty_service_key: (hidden) -> (hidden)`,
+		`~ query_key: "host" -> "service"`,
+		`~ victorops_api_key: (hidden) -> (hidden)`,
+	}, diffOptions(live, desired))
+}
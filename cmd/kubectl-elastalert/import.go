@@ -0,0 +1,413 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	"io"
+	"io/ioutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+	"strings"
+)
+
+// maxImportDepth bounds the chain of files a rule imports.
+const maxImportDepth = 10
+
+var (
+	// clusterOptions are the rule options moved into spec.clusters for rules querying another elasticsearch.
+	clusterOptions = []string{"es_host", "es_port", "use_ssl", "verify_certs", "ca_certs", "es_username", "es_password"}
+
+	// invalidClusterNameChars are replaced when a cluster name is derived from es_host.
+	invalidClusterNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// alerterSecret is a secret option of a rule which a typed alerter delivers through the alerters secret.
+type alerterSecret struct {
+	option  string
+	alerter string
+	set     func(a *esv1alpha1.Alerters, ref corev1.SecretKeySelector, rule map[string]interface{})
+}
+
+var alerterSecrets = []alerterSecret{
+	{
+		option:  "slack_webhook_url",
+		alerter: "slack",
+		set: func(a *esv1alpha1.Alerters, ref corev1.SecretKeySelector, rule map[string]interface{}) {
+			a.Slack = &esv1alpha1.SlackAlerter{WebhookURL: ref}
+		},
+	},
+	{
+		option:  "pagerduty_service_key",
+		alerter: "pagerduty",
+		set: func(a *esv1alpha1.Alerters, ref corev1.SecretKeySelector, rule map[string]interface{}) {
+			clientName, _ := rule["pagerduty_client_name"].(string)
+			a.PagerDuty = &esv1alpha1.PagerDutyAlerter{ServiceKey: ref, ClientName: clientName}
+		},
+	},
+	{
+		option:  "opsgenie_key",
+		alerter: "opsgenie",
+		set: func(a *esv1alpha1.Alerters, ref corev1.SecretKeySelector, rule map[string]interface{}) {
+			a.Opsgenie = &esv1alpha1.OpsgenieAlerter{Key: ref}
+		},
+	},
+	{
+		option:  "ms_teams_webhook_url",
+		alerter: "ms_teams",
+		set: func(a *esv1alpha1.Alerters, ref corev1.SecretKeySelector, rule map[string]interface{}) {
+			summary, _ := rule["ms_teams_alert_summary"].(string)
+			a.MsTeams = &esv1alpha1.MsTeamsAlerter{WebhookURL: ref, AlertSummary: summary}
+		},
+	},
+	{
+		option:  "http_post_url",
+		alerter: "post",
+		set: func(a *esv1alpha1.Alerters, ref corev1.SecretKeySelector, rule map[string]interface{}) {
+			a.HTTPPost = &esv1alpha1.HTTPPostAlerter{URL: ref}
+		},
+	},
+}
+
+func runImport(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	config := fs.String("config", "", "The config.yaml of ElastAlert.")
+	rules := fs.String("rules", "", "The rules directory, rules_folder of the config by default.")
+	name := fs.String("name", "elastalert", "Name of the Elastalert.")
+	namespace := fs.String("n", "default", "Namespace of the Elastalert.")
+	secret := fs.String("secret", "", "Name of the Secret holding the extracted credentials, <name>-imported by default.")
+	plaintext := fs.Bool("allow-plaintext-password", false, "Keep es_password of the config in spec.config, which has no secret reference.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *config == "" {
+		return fmt.Errorf("no config given, use --config")
+	}
+	im := &importer{
+		e: &esv1alpha1.Elastalert{
+			ObjectMeta: metav1.ObjectMeta{Namespace: *namespace, Name: *name},
+		},
+		secret: &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: *namespace, Name: *secret},
+			Data:       map[string][]byte{},
+		},
+		plaintext: *plaintext,
+	}
+	if im.secret.Name == "" {
+		im.secret.Name = *name + "-imported"
+	}
+	rulesFolder, err := im.importConfig(*config)
+	if err != nil {
+		return err
+	}
+	if *rules == "" {
+		if rulesFolder == "" {
+			return fmt.Errorf("%s sets no rules_folder, use --rules", *config)
+		}
+		*rules = rulesFolder
+	}
+	if err = im.importRules(*rules); err != nil {
+		return err
+	}
+	objects := []client.Object{im.e}
+	if len(im.secret.Data) != 0 {
+		objects = []client.Object{im.secret, im.e}
+	}
+	for _, note := range im.notes {
+		if _, err = fmt.Fprintf(stdout, "# %s\n", note); err != nil {
+			return err
+		}
+	}
+	return printObjects(stdout, objects)
+}
+
+// importer converts the config and rules of an ElastAlert running outside of the operator into an Elastalert, the
+// credentials it finds are moved into secret.
+type importer struct {
+	e      *esv1alpha1.Elastalert
+	secret *corev1.Secret
+	// plaintext allows es_password of the config in spec.config.
+	plaintext bool
+	// notes report what the conversion changed or could not convert.
+	notes []string
+}
+
+func (im *importer) note(format string, args ...interface{}) {
+	im.notes = append(im.notes, fmt.Sprintf(format, args...))
+}
+
+// importConfig sets spec.config and spec.cert from the config file, it returns the rules_folder of the config
+// resolved against the directory of the file.
+func (im *importer) importConfig(path string) (string, error) {
+	config, err := readYAML(path)
+	if err != nil {
+		return "", err
+	}
+	var rulesFolder string
+	if folder, ok := config["rules_folder"].(string); ok {
+		rulesFolder = resolvePath(path, folder)
+		delete(config, "rules_folder")
+		im.note("rules_folder %s is dropped, the operator sets it to %s", folder, podspec.DefaultRulesFolder)
+	}
+	if ca, ok := config["ca_certs"].(string); ok {
+		cert, err := ioutil.ReadFile(resolvePath(path, ca))
+		if err != nil {
+			return "", err
+		}
+		im.e.Spec.Cert = string(cert)
+		delete(config, "ca_certs")
+		im.note("ca_certs %s is set as spec.cert, the operator mounts it and sets ca_certs", ca)
+		if _, ok := config["verify_certs"]; ok {
+			delete(config, "verify_certs")
+			im.note("verify_certs is dropped, the operator sets it from use_ssl and spec.cert")
+		}
+	}
+	if _, ok := config["es_password"]; ok {
+		if !im.plaintext {
+			return "", fmt.Errorf("es_password of %s would be stored in plaintext in spec.config, remove it or pass --allow-plaintext-password", path)
+		}
+		im.note("es_password of config.yaml is kept in plaintext in spec.config, the operator connects to elasticsearch with it")
+	}
+	im.e.Spec.ConfigSetting = esv1alpha1.NewFreeForm(config)
+	return rulesFolder, nil
+}
+
+// importRules sets spec.rule from the rule files of the directory and its subdirectories, inlining the files they
+// import. Rules querying another elasticsearch become spec.clusters, and the alerter secrets shared by every rule
+// become typed alerters.
+func (im *importer) importRules(dir string) error {
+	var rules []map[string]interface{}
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || (filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml") {
+			return nil
+		}
+		rule, err := loadRule(path, 0)
+		if err != nil {
+			return err
+		}
+		if _, ok := rule["name"]; !ok {
+			im.note("%s is skipped, it has no name", path)
+			return nil
+		}
+		rules = append(rules, rule)
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("no rule found in %s", dir)
+	}
+	for i, rule := range rules {
+		if err = im.importCluster(paths[i], rule); err != nil {
+			return err
+		}
+	}
+	im.importAlerterSecrets(rules)
+	for _, rule := range rules {
+		if file, ok := rule["smtp_auth_file"]; ok {
+			im.note("smtp_auth_file %v of rule %v is not mounted by the operator, use spec.alerters.email.auth", file, rule["name"])
+		}
+		im.e.Spec.Rule = append(im.e.Spec.Rule, esv1alpha1.NewFreeForm(rule))
+	}
+	return nil
+}
+
+// loadRule reads a rule file, the files it imports are merged underneath it the way ElastAlert does.
+func loadRule(path string, depth int) (map[string]interface{}, error) {
+	rule, err := readYAML(path)
+	if err != nil {
+		return nil, err
+	}
+	imported, ok := rule["import"].(string)
+	if !ok {
+		return rule, nil
+	}
+	if depth == maxImportDepth {
+		return nil, fmt.Errorf("%s: too many nested imports", path)
+	}
+	base, err := loadRule(resolvePath(path, imported), depth+1)
+	if err != nil {
+		return nil, err
+	}
+	delete(rule, "import")
+	for k, v := range rule {
+		base[k] = v
+	}
+	return base, nil
+}
+
+// importCluster moves the elasticsearch options of the rule read from path into the cluster of spec.clusters connecting
+// to the same elasticsearch with the same credentials, and references it with es_cluster.
+func (im *importer) importCluster(path string, rule map[string]interface{}) error {
+	host, ok := rule["es_host"].(string)
+	if !ok {
+		return nil
+	}
+	cluster := esv1alpha1.ElasticsearchCluster{Host: host, Port: 9200}
+	if port, ok := rule["es_port"].(float64); ok {
+		cluster.Port = int(port)
+	}
+	cluster.UseSSL, _ = rule["use_ssl"].(bool)
+	username, _ := rule["es_username"].(string)
+	password, _ := rule["es_password"].(string)
+	ca, _ := rule["ca_certs"].(string)
+	var cert []byte
+	if ca != "" {
+		var err error
+		if cert, err = ioutil.ReadFile(resolvePath(path, ca)); err != nil {
+			return fmt.Errorf("ca_certs of rule %v: %v", rule["name"], err)
+		}
+	}
+	for _, option := range clusterOptions {
+		delete(rule, option)
+	}
+	for _, c := range im.e.Spec.Clusters {
+		if c.Host == cluster.Host && c.Port == cluster.Port && c.UseSSL == cluster.UseSSL &&
+			im.secretValue(c.CA) == string(cert) && im.credentials(c) == [2]string{username, password} {
+			rule[esv1alpha1.ClusterRuleKey] = c.Name
+			return nil
+		}
+	}
+	cluster.Name = im.clusterName(host)
+	if ca != "" {
+		cluster.CA = im.addSecret(cluster.Name+"-ca.crt", cert)
+	}
+	if username != "" || password != "" {
+		cluster.Credentials = &esv1alpha1.ElasticsearchCredentials{
+			Username: *im.addSecret(cluster.Name+"-es-username", []byte(username)),
+			Password: *im.addSecret(cluster.Name+"-es-password", []byte(password)),
+		}
+	}
+	im.e.Spec.Clusters = append(im.e.Spec.Clusters, cluster)
+	im.note("rules querying %s:%d reference the elasticsearch cluster %s", cluster.Host, cluster.Port, cluster.Name)
+	rule[esv1alpha1.ClusterRuleKey] = cluster.Name
+	return nil
+}
+
+// clusterName derives a cluster name from es_host which no cluster of spec.clusters uses yet.
+func (im *importer) clusterName(host string) string {
+	base := strings.Trim(invalidClusterNameChars.ReplaceAllString(strings.ToLower(host), "-"), "-")
+	if len(base) > 40 {
+		base = strings.TrimRight(base[:40], "-")
+	}
+	if base == "" {
+		base = "cluster"
+	}
+	name := base
+	for i := 2; ; i++ {
+		used := false
+		for _, c := range im.e.Spec.Clusters {
+			used = used || c.Name == name
+		}
+		if !used {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func (im *importer) credentials(c esv1alpha1.ElasticsearchCluster) [2]string {
+	if c.Credentials == nil {
+		return [2]string{}
+	}
+	return [2]string{im.secretValue(&c.Credentials.Username), im.secretValue(&c.Credentials.Password)}
+}
+
+// importAlerterSecrets turns a secret option set to the same value by every rule alerting with its alerter into a
+// typed alerter, the other secret options are left in the rules.
+func (im *importer) importAlerterSecrets(rules []map[string]interface{}) {
+	for _, s := range alerterSecrets {
+		var values []string
+		shared := true
+		for _, rule := range rules {
+			value, ok := rule[s.option].(string)
+			if ok {
+				values = append(values, value)
+			}
+			shared = shared && ok && value == values[0] && alerts(rule, s.alerter)
+		}
+		if len(values) == 0 {
+			continue
+		}
+		if !shared {
+			im.note("%s is left in the rules, the typed alerter needs every rule to alert with %s and the same value", s.option, s.alerter)
+			continue
+		}
+		if im.e.Spec.Alerters == nil {
+			im.e.Spec.Alerters = &esv1alpha1.Alerters{}
+		}
+		s.set(im.e.Spec.Alerters, *im.addSecret(s.option, []byte(values[0])), rules[0])
+		for _, rule := range rules {
+			delete(rule, s.option)
+		}
+		im.note("%s of the rules is delivered by spec.alerters", s.option)
+	}
+}
+
+// alerts tells whether the alert option of the rule lists the alerter.
+func alerts(rule map[string]interface{}, alerter string) bool {
+	switch alert := rule["alert"].(type) {
+	case string:
+		return alert == alerter
+	case []interface{}:
+		for _, a := range alert {
+			if a == alerter {
+				return true
+			}
+			if m, ok := a.(map[string]interface{}); ok {
+				if _, ok = m[alerter]; ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// addSecret stores a value in the secret and returns its selector.
+func (im *importer) addSecret(key string, value []byte) *corev1.SecretKeySelector {
+	im.secret.Data[key] = value
+	return &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: im.secret.Name},
+		Key:                  key,
+	}
+}
+
+func (im *importer) secretValue(ref *corev1.SecretKeySelector) string {
+	if ref == nil {
+		return ""
+	}
+	return string(im.secret.Data[ref.Key])
+}
+
+func readYAML(path string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]interface{}{}
+	if err = yaml.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return out, nil
+}
+
+// resolvePath resolves a path found in a file against the directory of the file.
+func resolvePath(file, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(file), path)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"io/ioutil"
+	corev1 "k8s.io/api/core/v1"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunImport(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, runImport([]string{"--config", "testdata/import/config.yaml", "--name", "migrated", "-n", "monitoring"}, nil, &out))
+	for _, note := range []string{
+		"# rules_folder rules is dropped, the operator sets it to /etc/elastalert/rules/..data/",
+		"# ca_certs ca.crt is set as spec.cert, the operator mounts it and sets ca_certs",
+		"# verify_certs is dropped, the operator sets it from use_ssl and spec.cert",
+		"# rules querying audit.example.com:9243 reference the elasticsearch cluster audit-example-com",
+		"# slack_webhook_url of the rules is delivered by spec.alerters",
+	} {
+		assert.Contains(t, out.String(), note)
+	}
+
+	objects, err := readManifests([]string{"-"}, bytes.NewReader(out.Bytes()))
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	ca, err := ioutil.ReadFile("testdata/import/ca.crt")
+	require.NoError(t, err)
+	secret := objects[0].(*corev1.Secret)
+	require.Equal(t, "migrated-imported", secret.Name)
+	// ca_certs of the rule is relative to the rule file.
+	require.Equal(t, map[string][]byte{
+		"slack_webhook_url":             []byte("https://hooks.slack.com/services/x"),
+		"audit-example-com-ca.crt":      ca,
+		"audit-example-com-es-username": []byte("elastalert"),
+		"audit-example-com-es-password": []byte("s3cret"),
+	}, secret.Data)
+
+	e := objects[1].(*esv1alpha1.Elastalert)
+	require.Equal(t, "monitoring", e.Namespace)
+	require.Equal(t, "migrated", e.Name)
+	require.Contains(t, e.Spec.Cert, "BEGIN CERTIFICATE")
+	config, err := e.Spec.ConfigSetting.GetMap()
+	require.NoError(t, err)
+	require.NotContains(t, config, "rules_folder")
+	require.NotContains(t, config, "ca_certs")
+	require.Equal(t, "es.logging.svc", config["es_host"])
+	require.Equal(t, "slack_webhook_url", e.Spec.Alerters.Slack.WebhookURL.Key)
+	require.Len(t, e.Spec.Clusters, 1)
+	require.Equal(t, 9243, e.Spec.Clusters[0].Port)
+	require.Equal(t, "audit-example-com-es-password", e.Spec.Clusters[0].Credentials.Password.Key)
+	require.Equal(t, "audit-example-com-ca.crt", e.Spec.Clusters[0].CA.Key)
+
+	require.Len(t, e.Spec.Rule, 2)
+	frequency, err := e.Spec.Rule[0].GetMap()
+	require.NoError(t, err)
+	require.Equal(t, "errors", frequency["name"])
+	require.Equal(t, "#alerts", frequency["slack_channel_override"])
+	require.NotContains(t, frequency, "import")
+	require.NotContains(t, frequency, "slack_webhook_url")
+	audit, err := e.Spec.Rule[1].GetMap()
+	require.NoError(t, err)
+	require.Equal(t, "#audit", audit["slack_channel_override"])
+	require.Equal(t, "audit-example-com", audit[esv1alpha1.ClusterRuleKey])
+	require.NotContains(t, audit, "es_password")
+
+	// the imported manifests render as they are.
+	rendered, err := render(objects, "default", false)
+	require.NoError(t, err)
+	require.Len(t, rendered, 5)
+}
+
+func TestRunImportErrors(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte("rules_folder: rules\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "loop.yaml"), []byte("name: loop\nimport: loop.yaml\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "password.yaml"), []byte("rules_folder: rules\nes_password: s3cret\n"), 0644))
+	testCases := []struct {
+		desc    string
+		args    []string
+		wantErr string
+	}{
+		{
+			desc:    "test import without config",
+			wantErr: "no config given",
+		},
+		{
+			desc:    "test import of a missing rules folder",
+			args:    []string{"--config", filepath.Join(dir, "config.yaml")},
+			wantErr: "no such file or directory",
+		},
+		{
+			desc:    "test import of a rule importing itself",
+			args:    []string{"--config", filepath.Join(dir, "config.yaml"), "--rules", dir},
+			wantErr: "too many nested imports",
+		},
+		{
+			desc:    "test import of a config with a password",
+			args:    []string{"--config", filepath.Join(dir, "password.yaml")},
+			wantErr: "would be stored in plaintext in spec.config",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var out bytes.Buffer
+			err := runImport(tc.args, nil, &out)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestRunImportPlaintextPassword(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte("es_host: es\nes_password: s3cret\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "rule.yaml"), []byte("name: rule\ntype: any\n"), 0644))
+	var out bytes.Buffer
+	require.NoError(t, runImport([]string{"--config", filepath.Join(dir, "config.yaml"), "--rules", dir, "--allow-plaintext-password"}, nil, &out))
+	assert.Contains(t, out.String(), "# es_password of config.yaml is kept in plaintext in spec.config")
+	assert.Contains(t, out.String(), "es_password: s3cret")
+}
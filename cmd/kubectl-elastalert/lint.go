@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/lint"
+	"io"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+)
+
+// manifestFinding is a finding of a rule of an Elastalert read from a manifest.
+type manifestFinding struct {
+	File       string `json:"file"`
+	Elastalert string `json:"elastalert"`
+	lint.Finding
+}
+
+func runLint(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	var files fileFlags
+	fs.Var(&files, "f", "Manifest holding the Elastalerts, with the alert profiles they reference. Repeatable, - reads stdin.")
+	namespace := fs.String("n", "default", "Namespace of the objects which set none.")
+	format := fs.String("o", "text", "Output format, one of text, json or sarif.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" && *format != "sarif" {
+		return fmt.Errorf("unknown output format %s", *format)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no manifest given, use -f")
+	}
+	var objects []client.Object
+	origins := map[client.Object]string{}
+	for _, file := range files {
+		read, err := readManifests([]string{file}, stdin)
+		if err != nil {
+			return err
+		}
+		for _, obj := range read {
+			origins[obj] = file
+		}
+		objects = append(objects, read...)
+	}
+	// rendering patches the rules of the Elastalerts the way the operator does.
+	if _, err := render(objects, *namespace, true); err != nil {
+		return err
+	}
+	findings := []manifestFinding{}
+	for _, obj := range objects {
+		e, ok := obj.(*esv1alpha1.Elastalert)
+		if !ok {
+			continue
+		}
+		var rules []map[string]interface{}
+		for _, v := range e.Spec.Rule {
+			rule, err := v.GetMap()
+			if err != nil {
+				return err
+			}
+			rules = append(rules, rule)
+		}
+		for _, f := range lint.Rules(rules) {
+			findings = append(findings, manifestFinding{File: origins[obj], Elastalert: e.Namespace + "/" + e.Name, Finding: f})
+		}
+	}
+	var err error
+	switch *format {
+	case "json":
+		err = writeJSON(stdout, findings)
+	case "sarif":
+		err = writeJSON(stdout, sarifLog(findings))
+	default:
+		for _, f := range findings {
+			if _, err = fmt.Fprintf(stdout, "%s: %s: rule %s: %s (%s)\n", f.File, f.Elastalert, f.Rule, f, f.Check); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	var all []lint.Finding
+	for _, f := range findings {
+		all = append(all, f.Finding)
+	}
+	if n := lint.Errors(all); n != 0 {
+		return fmt.Errorf("%d errors found", n)
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// sarifLog reports the findings in the SARIF 2.1.0 format read by code scanning tools.
+func sarifLog(findings []manifestFinding) map[string]interface{} {
+	var checks []string
+	for check := range lint.Checks {
+		checks = append(checks, check)
+	}
+	sort.Strings(checks)
+	var rules []map[string]interface{}
+	for _, check := range checks {
+		rules = append(rules, map[string]interface{}{
+			"id":               check,
+			"shortDescription": map[string]interface{}{"text": lint.Checks[check]},
+		})
+	}
+	results := []map[string]interface{}{}
+	for _, f := range findings {
+		level := "warning"
+		if f.Severity == lint.SeverityError {
+			level = "error"
+		}
+		results = append(results, map[string]interface{}{
+			"ruleId":  f.Check,
+			"level":   level,
+			"message": map[string]interface{}{"text": fmt.Sprintf("rule %s: %s", f.Rule, f.Message)},
+			"locations": []interface{}{
+				map[string]interface{}{
+					"physicalLocation": map[string]interface{}{
+						"artifactLocation": map[string]interface{}{"uri": f.File},
+					},
+					"logicalLocations": []interface{}{
+						map[string]interface{}{"fullyQualifiedName": f.Elastalert + "/" + f.Rule},
+					},
+				},
+			},
+		})
+	}
+	return map[string]interface{}{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []interface{}{
+			map[string]interface{}{
+				"tool": map[string]interface{}{
+					"driver": map[string]interface{}{
+						"name":           "kubectl-elastalert",
+						"informationUri": "https://github.com/toughnoah/elastalert-operator",
+						"rules":          rules,
+					},
+				},
+				"results": results,
+			},
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRunLint(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{
+			desc: "test lint text output",
+			args: []string{"-f", "testdata/lint.yaml"},
+			want: `testdata/lint.yaml: monitoring/elastalert: rule errors: error: frequency rule needs num_events (required-option)
+testdata/lint.yaml: monitoring/elastalert: rule errors: error: timeframe has unknown unit minute, use weeks, days, hours, minutes, seconds, milliseconds or microseconds (time-unit)
+testdata/lint.yaml: monitoring/elastalert: rule audit: warning: aggregation_key has no effect without aggregation (aggregation)
+`,
+			wantErr: "2 errors found",
+		},
+		{
+			desc: "test lint without finding",
+			args: []string{"-f", "testdata/elastalert.yaml"},
+		},
+		{
+			desc:    "test lint unknown format",
+			args:    []string{"-f", "testdata/lint.yaml", "-o", "xml"},
+			wantErr: "unknown output format xml",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var out bytes.Buffer
+			err := runLint(tc.args, nil, &out)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.want, out.String())
+		})
+	}
+}
+
+func TestRunLintFormats(t *testing.T) {
+	var out bytes.Buffer
+	require.Error(t, runLint([]string{"-f", "testdata/lint.yaml", "-o", "json"}, nil, &out))
+	var findings []map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &findings))
+	require.Len(t, findings, 3)
+	require.Equal(t, map[string]interface{}{
+		"file":       "testdata/lint.yaml",
+		"elastalert": "monitoring/elastalert",
+		"rule":       "errors",
+		"check":      "required-option",
+		"severity":   "error",
+		"message":    "frequency rule needs num_events",
+	}, findings[0])
+
+	out.Reset()
+	require.Error(t, runLint([]string{"-f", "testdata/lint.yaml", "-o", "sarif"}, nil, &out))
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID  string `json:"ruleId"`
+				Level   string `json:"level"`
+				Message struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []map[string]json.RawMessage `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &sarif))
+	require.Equal(t, "2.1.0", sarif.Version)
+	require.Len(t, sarif.Runs[0].Results, 3)
+	require.Equal(t, "aggregation", sarif.Runs[0].Results[2].RuleID)
+	require.Equal(t, "warning", sarif.Runs[0].Results[2].Level)
+	require.Equal(t, "rule audit: aggregation_key has no effect without aggregation", sarif.Runs[0].Results[2].Message.Text)
+	// the logical locations are next to the physical one on the location, as SARIF 2.1.0 defines them.
+	location := sarif.Runs[0].Results[2].Locations[0]
+	require.Len(t, location, 2)
+	require.JSONEq(t, `{"artifactLocation": {"uri": "testdata/lint.yaml"}}`, string(location["physicalLocation"]))
+	require.JSONEq(t, `[{"fullyQualifiedName": "monitoring/elastalert/audit"}]`, string(location["logicalLocations"]))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"io"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"os"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+	"strings"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(esv1alpha1.AddToScheme(scheme))
+}
+
+// fileFlags collects the repeated -f flags.
+type fileFlags []string
+
+func (f *fileFlags) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *fileFlags) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// readManifests decodes the objects of the YAML or JSON files, "-" reading stdin. Kinds unknown to the operator are
+// skipped, so that whole deployment manifests can be given.
+func readManifests(files []string, stdin io.Reader) ([]client.Object, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no manifest given, use -f")
+	}
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+	var objects []client.Object
+	for _, file := range files {
+		r := stdin
+		if file != "-" {
+			f, err := os.Open(file)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			r = f
+		}
+		reader := k8syaml.NewYAMLReader(bufio.NewReader(r))
+		for {
+			doc, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", file, err)
+			}
+			var fields map[string]interface{}
+			if err = yaml.Unmarshal(doc, &fields); err != nil {
+				return nil, fmt.Errorf("%s: %v", file, err)
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			obj, _, err := decoder.Decode(doc, nil, nil)
+			if runtime.IsNotRegisteredError(err) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", file, err)
+			}
+			if o, ok := obj.(client.Object); ok {
+				objects = append(objects, o)
+			}
+		}
+	}
+	return objects, nil
+}
+
+// printObjects writes the objects as a YAML stream.
+func printObjects(w io.Writer, objects []client.Object) error {
+	var buf bytes.Buffer
+	for _, obj := range objects {
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+		if err != nil {
+			return err
+		}
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		buf.WriteString("---\n")
+		buf.Write(data)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/toughnoah/elastalert-operator/controllers/metrics"
+	"io"
+	"sigs.k8s.io/yaml"
+)
+
+func runMonitoring(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("monitoring", flag.ContinueOnError)
+	opts := metrics.DefaultAlertOptions
+	fs.StringVar(&opts.Namespace, "n", opts.Namespace, "Namespace of the PrometheusRule.")
+	fs.DurationVar(&opts.FailedFor, "failed-for", opts.FailedFor, "How long an Elastalert is FAILED before it alerts.")
+	fs.DurationVar(&opts.RuleStalledAfter, "rule-stalled-after", opts.RuleStalledAfter, "How long a rule may not run before it alerts.")
+	fs.DurationVar(&opts.CertExpiryWarning, "cert-expiry-warning", opts.CertExpiryWarning, "How long before the elasticsearch cert expires it alerts.")
+	output := fs.String("o", "prometheusrule", "What to print, the prometheusrule or the Grafana dashboard.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch *output {
+	case "prometheusrule":
+		data, err := yaml.Marshal(metrics.PrometheusRule(opts))
+		if err != nil {
+			return err
+		}
+		_, err = stdout.Write(data)
+		return err
+	case "dashboard":
+		return writeJSON(stdout, metrics.GrafanaDashboard())
+	}
+	return fmt.Errorf("unknown output %s", *output)
+}
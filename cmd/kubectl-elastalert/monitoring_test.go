@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"sigs.k8s.io/yaml"
+	"testing"
+)
+
+func TestRunMonitoring(t *testing.T) {
+	testCases := []struct {
+		desc string
+		args []string
+		file string
+	}{
+		{
+			desc: "test prometheusrule",
+			file: "../../deploy/monitoring/prometheusrule.yaml",
+		},
+		{
+			desc: "test dashboard",
+			args: []string{"-o", "dashboard"},
+			file: "../../deploy/monitoring/grafana-dashboard.json",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, runMonitoring(tc.args, nil, &out))
+			data, err := ioutil.ReadFile(tc.file)
+			require.NoError(t, err)
+			// the manifests are regenerated with "make monitoring" when the metrics change.
+			var have, want interface{}
+			require.NoError(t, yaml.Unmarshal(out.Bytes(), &have))
+			require.NoError(t, yaml.Unmarshal(data, &want))
+			require.Equal(t, want, have)
+		})
+	}
+}
+
+func TestRunMonitoringOptions(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, runMonitoring([]string{"-n", "monitoring", "--rule-stalled-after", "1h", "--failed-for", "90s"}, nil, &out))
+	require.Contains(t, out.String(), "namespace: monitoring")
+	require.Contains(t, out.String(), "elastalert_operator_rule_last_run_timestamp_seconds > 3600")
+	require.Contains(t, out.String(), "for: 90s")
+
+	out.Reset()
+	require.NoError(t, runMonitoring([]string{"-o", "dashboard"}, nil, &out))
+	require.True(t, json.Valid(out.Bytes()))
+
+	err := runMonitoring([]string{"-o", "alertmanager"}, nil, &out)
+	require.EqualError(t, err, "unknown output alertmanager")
+}
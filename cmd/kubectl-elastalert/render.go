@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func runRender(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	var files fileFlags
+	fs.Var(&files, "f", "Manifest holding the Elastalerts, with the ElastalertClusterConfig, alert profiles and Secrets they reference. Repeatable, - reads stdin.")
+	namespace := fs.String("n", "default", "Namespace of the objects which set none.")
+	placeholders := fs.Bool("secret-placeholders", true, "Render Secrets referenced but not given with placeholder values.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	objects, err := readManifests(files, stdin)
+	if err != nil {
+		return err
+	}
+	rendered, err := render(objects, *namespace, *placeholders)
+	if err != nil {
+		return err
+	}
+	return printObjects(stdout, rendered)
+}
+
+// render returns the objects the operator applies for each Elastalert of the objects, the other objects being what
+// they reference.
+func render(objects []client.Object, namespace string, placeholders bool) ([]client.Object, error) {
+	var elastalerts []*esv1alpha1.Elastalert
+	var references []client.Object
+	for _, obj := range objects {
+		if isNamespaced(obj) && obj.GetNamespace() == "" {
+			obj.SetNamespace(namespace)
+		}
+		if e, ok := obj.(*esv1alpha1.Elastalert); ok {
+			elastalerts = append(elastalerts, e)
+			continue
+		}
+		references = append(references, obj)
+	}
+	if len(elastalerts) == 0 {
+		return nil, fmt.Errorf("no Elastalert found in the manifests")
+	}
+	if placeholders {
+		references = append(references, placeholderSecrets(elastalerts, references)...)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(references...).Build()
+	var rendered []client.Object
+	for _, e := range elastalerts {
+		resources, err := controllers.Render(c, scheme, context.Background(), e)
+		if err != nil {
+			return nil, fmt.Errorf("Elastalert %s/%s: %v", e.Namespace, e.Name, err)
+		}
+		rendered = append(rendered, resources.Objects()...)
+	}
+	return rendered, nil
+}
+
+// placeholderSecrets returns Secrets holding a placeholder for each key the Elastalerts reference in a Secret missing
+// from the objects.
+func placeholderSecrets(elastalerts []*esv1alpha1.Elastalert, objects []client.Object) []client.Object {
+	given := map[types.NamespacedName]bool{}
+	for _, obj := range objects {
+		if _, ok := obj.(*corev1.Secret); ok {
+			given[types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}] = true
+		}
+	}
+	placeholders := map[types.NamespacedName]*corev1.Secret{}
+	var secrets []client.Object
+	for _, e := range elastalerts {
+		for _, ref := range podspec.SecretRefs(e) {
+			key := types.NamespacedName{Namespace: e.Namespace, Name: ref.Name}
+			if given[key] {
+				continue
+			}
+			secret, ok := placeholders[key]
+			if !ok {
+				secret = &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+					Data:       map[string][]byte{},
+				}
+				placeholders[key] = secret
+				secrets = append(secrets, secret)
+			}
+			secret.Data[ref.Key] = []byte(fmt.Sprintf("<%s/%s>", ref.Name, ref.Key))
+		}
+	}
+	return secrets
+}
+
+// isNamespaced tells whether the kind of the object lives in a namespace.
+func isNamespaced(obj client.Object) bool {
+	switch obj.(type) {
+	case *esv1alpha1.ElastalertClusterConfig, *esv1alpha1.ClusterElastalertAlertProfile, *corev1.Namespace:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"testing"
+)
+
+func TestRunRender(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		args    []string
+		stdin   string
+		objects []string
+		secrets map[string]string
+		wantErr string
+	}{
+		{
+			desc: "test render with placeholders",
+			args: []string{"-f", "testdata/elastalert.yaml", "-n", "monitoring"},
+			objects: []string{
+				"Secret monitoring/elastalert-es-cert",
+				"Secret monitoring/elastalert-alerters",
+				"ConfigMap monitoring/elastalert-rule",
+				"ConfigMap monitoring/elastalert-config",
+				"Deployment monitoring/elastalert",
+			},
+			secrets: map[string]string{"slack_webhook_url": "<slack/webhook>"},
+		},
+		{
+			desc: "test render with the secret given",
+			args: []string{"-f", "testdata/elastalert.yaml", "-f", "-"},
+			stdin: `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: slack
+data:
+  webhook: aHR0cHM6Ly9ob29rcy5zbGFjay5jb20vc2VydmljZXMveA==
+`,
+			objects: []string{
+				"Secret default/elastalert-es-cert",
+				"Secret default/elastalert-alerters",
+				"ConfigMap default/elastalert-rule",
+				"ConfigMap default/elastalert-config",
+				"Deployment default/elastalert",
+			},
+			secrets: map[string]string{"slack_webhook_url": "https://hooks.slack.com/services/x"},
+		},
+		{
+			desc:    "test render without placeholders",
+			args:    []string{"-f", "testdata/elastalert.yaml", "--secret-placeholders=false"},
+			wantErr: `secrets "slack" not found`,
+		},
+		{
+			desc:    "test render without elastalert",
+			args:    []string{"-f", "-"},
+			stdin:   "apiVersion: v1\nkind: Secret\nmetadata:\n  name: slack\n",
+			wantErr: "no Elastalert found in the manifests",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var out bytes.Buffer
+			err := runRender(tc.args, strings.NewReader(tc.stdin), &out)
+			if tc.wantErr != "" {
+				assert.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			objects, err := readManifests([]string{"-"}, &out)
+			require.NoError(t, err)
+			var names []string
+			for _, obj := range objects {
+				names = append(names, obj.GetObjectKind().GroupVersionKind().Kind+" "+client.ObjectKeyFromObject(obj).String())
+			}
+			assert.Equal(t, tc.objects, names)
+			alerters := objects[1].(*corev1.Secret)
+			for k, v := range tc.secrets {
+				assert.Contains(t, string(alerters.Data["alerters.yaml"]), k)
+				assert.Contains(t, string(alerters.Data["alerters.yaml"]), v)
+			}
+			config := objects[3].(*corev1.ConfigMap)
+			assert.Contains(t, config.Data["config.yaml"], "es_host: es.logging.svc")
+		})
+	}
+}
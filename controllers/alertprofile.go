@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"context"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// resolvedAlertProfile is an alert profile referenced by the rules and the object it was read from.
+type resolvedAlertProfile struct {
+	alert      esv1alpha1.FreeForm
+	source     string
+	generation int64
+}
+
+// getAlertProfiles fetches the alert profiles referenced by the rules, an ElastalertAlertProfile in the namespace
+// of the Elastalert takes precedence over a ClusterElastalertAlertProfile of the same name.
+// Profiles found in neither place are left out, PatchAlertProfiles reports them.
+func getAlertProfiles(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert) (map[string]resolvedAlertProfile, error) {
+	names, err := podspec.AlertProfileNames(e)
+	if err != nil {
+		return nil, err
+	}
+	profiles := map[string]resolvedAlertProfile{}
+	for _, name := range names {
+		profile := &esv1alpha1.ElastalertAlertProfile{}
+		err = c.Get(ctx, types.NamespacedName{Namespace: e.Namespace, Name: name}, profile)
+		if err == nil {
+			profiles[name] = resolvedAlertProfile{alert: profile.Spec.Alert, source: "ElastalertAlertProfile/" + name, generation: profile.Generation}
+			continue
+		}
+		if !k8serrors.IsNotFound(err) {
+			log.Error(err, "Failed to get alert profile", "Elastalert.Namespace", e.Namespace, "AlertProfile.Name", name)
+			return nil, err
+		}
+		clusterProfile := &esv1alpha1.ClusterElastalertAlertProfile{}
+		err = c.Get(ctx, types.NamespacedName{Name: name}, clusterProfile)
+		if err == nil {
+			profiles[name] = resolvedAlertProfile{alert: clusterProfile.Spec.Alert, source: "ClusterElastalertAlertProfile/" + name, generation: clusterProfile.Generation}
+			continue
+		}
+		if !k8serrors.IsNotFound(err) {
+			log.Error(err, "Failed to get cluster alert profile", "Elastalert.Namespace", e.Namespace, "AlertProfile.Name", name)
+			return nil, err
+		}
+	}
+	return profiles, nil
+}
+
+// resolveAlertProfiles fetches the alerts of the alert profiles referenced by the rules, see getAlertProfiles.
+func resolveAlertProfiles(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert) (map[string]esv1alpha1.FreeForm, error) {
+	profiles, err := getAlertProfiles(c, ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	alerts := map[string]esv1alpha1.FreeForm{}
+	for name, profile := range profiles {
+		alerts[name] = profile.alert
+	}
+	return alerts, nil
+}
+
+// resolveAlertProfileGenerations returns the generations of the alert profiles referenced by the rules, by kind and
+// name, so that a profile edited or shadowed by another one is applied again.
+func resolveAlertProfileGenerations(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert) (map[string]int64, error) {
+	profiles, err := getAlertProfiles(c, ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) == 0 {
+		return nil, nil
+	}
+	generations := map[string]int64{}
+	for _, profile := range profiles {
+		generations[profile.source] = profile.generation
+	}
+	return generations, nil
+}
+
+// alertProfilesChanged determines if the alert profiles differ from the ones last applied to the Elastalert.
+func alertProfilesChanged(e *esv1alpha1.Elastalert, generations map[string]int64, err error) bool {
+	if err != nil {
+		// let applyConfigMaps surface the error.
+		return true
+	}
+	if len(generations) == 0 && len(e.Status.AlertProfileGenerations) == 0 {
+		return false
+	}
+	return !reflect.DeepEqual(generations, e.Status.AlertProfileGenerations)
+}
+
+// elastalertsForAlertProfile maps an ElastalertAlertProfile or a ClusterElastalertAlertProfile to the Elastalerts
+// whose rules reference its name, in its namespace for the former.
+func elastalertsForAlertProfile(c client.Client) func(obj client.Object) []reconcile.Request {
+	return func(obj client.Object) []reconcile.Request {
+		list := &esv1alpha1.ElastalertList{}
+		if err := c.List(context.Background(), list, client.InNamespace(obj.GetNamespace())); err != nil {
+			log.Error(err, "Failed to list Elastalerts for alert profile", "AlertProfile.Namespace", obj.GetNamespace(), "AlertProfile.Name", obj.GetName())
+			return nil
+		}
+		var requests []reconcile.Request
+		for i := range list.Items {
+			// rules with an invalid alert_profile fail to render whatever the profiles are.
+			names, _ := podspec.AlertProfileNames(&list.Items[i])
+			for _, name := range names {
+				if name == obj.GetName() {
+					requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+						Namespace: list.Items[i].Namespace,
+						Name:      list.Items[i].Name,
+					}})
+					break
+				}
+			}
+		}
+		return requests
+	}
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+)
+
+func TestResolveAlertProfiles(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	elastalert := v1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "esa1",
+			Name:      "my-esa",
+		},
+		Spec: v1alpha1.ElastalertSpec{
+			Rule: []v1alpha1.FreeForm{
+				v1alpha1.NewFreeForm(map[string]interface{}{
+					"name": "rule1", "alert_profile": "oncall",
+				}),
+				v1alpha1.NewFreeForm(map[string]interface{}{
+					"name": "rule2", "alert_profile": "shared",
+				}),
+				v1alpha1.NewFreeForm(map[string]interface{}{
+					"name": "rule3", "alert_profile": "missing",
+				}),
+			},
+		},
+	}
+	namespaced := v1alpha1.NewFreeForm(map[string]interface{}{"alert": "post"})
+	cluster := v1alpha1.NewFreeForm(map[string]interface{}{"alert": "slack"})
+	testCases := []struct {
+		desc string
+		c    client.Client
+		want map[string]v1alpha1.FreeForm
+	}{
+		{
+			desc: "test namespaced profile takes precedence",
+			c: fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+				&v1alpha1.ElastalertAlertProfile{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "oncall"},
+					Spec:       v1alpha1.AlertProfileSpec{Alert: namespaced},
+				},
+				&v1alpha1.ClusterElastalertAlertProfile{
+					ObjectMeta: metav1.ObjectMeta{Name: "oncall"},
+					Spec:       v1alpha1.AlertProfileSpec{Alert: cluster},
+				},
+				&v1alpha1.ClusterElastalertAlertProfile{
+					ObjectMeta: metav1.ObjectMeta{Name: "shared"},
+					Spec:       v1alpha1.AlertProfileSpec{Alert: cluster},
+				},
+			).Build(),
+			want: map[string]v1alpha1.FreeForm{
+				"oncall": namespaced,
+				"shared": cluster,
+			},
+		},
+		{
+			desc: "test profile in another namespace is ignored",
+			c: fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+				&v1alpha1.ElastalertAlertProfile{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "esa2", Name: "oncall"},
+					Spec:       v1alpha1.AlertProfileSpec{Alert: namespaced},
+				},
+			).Build(),
+			want: map[string]v1alpha1.FreeForm{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			have, err := resolveAlertProfiles(tc.c, context.Background(), &elastalert)
+			require.NoError(t, err)
+			require.Equal(t, len(tc.want), len(have))
+			for name, want := range tc.want {
+				wantMap, err := want.GetMap()
+				require.NoError(t, err)
+				haveMap, err := have[name].GetMap()
+				require.NoError(t, err)
+				require.Equal(t, wantMap, haveMap)
+			}
+		})
+	}
+}
+
+func TestAlertProfilesChanged(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	elastalert := &v1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "my-esa"},
+		Spec: v1alpha1.ElastalertSpec{
+			Rule: []v1alpha1.FreeForm{
+				v1alpha1.NewFreeForm(map[string]interface{}{"name": "rule1", "alert_profile": "oncall"}),
+			},
+		},
+	}
+	clusterProfile := &v1alpha1.ClusterElastalertAlertProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: "oncall", Generation: 1},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(clusterProfile).Build()
+	generations, err := resolveAlertProfileGenerations(c, context.Background(), elastalert)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"ClusterElastalertAlertProfile/oncall": 1}, generations)
+	require.True(t, alertProfilesChanged(elastalert, generations, err))
+	elastalert.Status.AlertProfileGenerations = generations
+	require.False(t, alertProfilesChanged(elastalert, generations, err))
+
+	// the fake client leaves the generation as is.
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(clusterProfile), clusterProfile))
+	clusterProfile.Generation = 2
+	require.NoError(t, c.Update(context.Background(), clusterProfile))
+	generations, err = resolveAlertProfileGenerations(c, context.Background(), elastalert)
+	require.NoError(t, err)
+	require.True(t, alertProfilesChanged(elastalert, generations, err))
+
+	// a namespaced profile of the same name shadows the cluster one, whatever its generation.
+	elastalert.Status.AlertProfileGenerations = generations
+	require.NoError(t, c.Create(context.Background(), &v1alpha1.ElastalertAlertProfile{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "oncall", Generation: 2},
+	}))
+	generations, err = resolveAlertProfileGenerations(c, context.Background(), elastalert)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"ElastalertAlertProfile/oncall": 2}, generations)
+	require.True(t, alertProfilesChanged(elastalert, generations, err))
+
+	require.False(t, alertProfilesChanged(&v1alpha1.Elastalert{}, nil, nil))
+}
+
+func TestElastalertsForAlertProfile(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	rules := []v1alpha1.FreeForm{
+		v1alpha1.NewFreeForm(map[string]interface{}{"name": "rule1", "alert_profile": "oncall"}),
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		&v1alpha1.Elastalert{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "uses-oncall"},
+			Spec:       v1alpha1.ElastalertSpec{Rule: rules},
+		},
+		&v1alpha1.Elastalert{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "no-profile"},
+		},
+		&v1alpha1.Elastalert{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "esa2", Name: "uses-oncall"},
+			Spec:       v1alpha1.ElastalertSpec{Rule: rules},
+		},
+	).Build()
+	testCases := []struct {
+		desc    string
+		profile client.Object
+		want    []reconcile.Request
+	}{
+		{
+			desc: "test namespaced profile",
+			profile: &v1alpha1.ElastalertAlertProfile{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "oncall"},
+			},
+			want: []reconcile.Request{
+				{NamespacedName: types.NamespacedName{Namespace: "esa1", Name: "uses-oncall"}},
+			},
+		},
+		{
+			desc: "test cluster profile",
+			profile: &v1alpha1.ClusterElastalertAlertProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "oncall"},
+			},
+			want: []reconcile.Request{
+				{NamespacedName: types.NamespacedName{Namespace: "esa1", Name: "uses-oncall"}},
+				{NamespacedName: types.NamespacedName{Namespace: "esa2", Name: "uses-oncall"}},
+			},
+		},
+		{
+			desc: "test unreferenced profile",
+			profile: &v1alpha1.ClusterElastalertAlertProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "other"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.ElementsMatch(t, tc.want, elastalertsForAlertProfile(c)(tc.profile))
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"context"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// resolveClusterConfig gets the ElastalertClusterConfig used by the Elastalert. It returns nil when the Elastalert
+// names none and the default one does not exist, a missing ElastalertClusterConfig named by the Elastalert is an error.
+func resolveClusterConfig(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert) (*esv1alpha1.ElastalertClusterConfig, error) {
+	cc := &esv1alpha1.ElastalertClusterConfig{}
+	err := c.Get(ctx, types.NamespacedName{Name: podspec.ClusterConfigName(e)}, cc)
+	if err == nil {
+		return cc, nil
+	}
+	if e.Spec.ClusterConfig == "" && (k8serrors.IsNotFound(err) || meta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err)) {
+		// the default is optional, and so is its CRD.
+		return nil, nil
+	}
+	log.Error(err, "Failed to get ElastalertClusterConfig", "Elastalert.Namespace", e.Namespace, "ClusterConfig.Name", podspec.ClusterConfigName(e))
+	return nil, err
+}
+
+// clusterConfigChanged determines if the ElastalertClusterConfig differs from the one last applied to the Elastalert.
+func clusterConfigChanged(e *esv1alpha1.Elastalert, cc *esv1alpha1.ElastalertClusterConfig) bool {
+	var generation int64
+	if cc != nil {
+		generation = cc.Generation
+	}
+	if generation != e.Status.ClusterConfigGeneration {
+		return true
+	}
+	sources, err := podspec.MergeClusterConfig(e.DeepCopy(), cc)
+	if err != nil {
+		// let applyClusterConfig surface the error.
+		return true
+	}
+	return !equalSources(sources, e.Status.ConfigSources)
+}
+
+func equalSources(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// applyClusterConfig records the config sources in status, then merges the ElastalertClusterConfig into the Elastalert
+// in memory, so that the following steps render the effective config and cert.
+func applyClusterConfig(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert, cc *esv1alpha1.ElastalertClusterConfig) error {
+	merged := e.DeepCopy()
+	sources, err := podspec.MergeClusterConfig(merged, cc)
+	if err != nil {
+		log.Error(err, "Failed to merge ElastalertClusterConfig", "Elastalert.Namespace", e.Namespace, "ClusterConfig.Name", podspec.ClusterConfigName(e))
+		return err
+	}
+	var generation int64
+	if cc != nil {
+		generation = cc.Generation
+	}
+	if generation != e.Status.ClusterConfigGeneration || !equalSources(sources, e.Status.ConfigSources) {
+		patch := client.MergeFrom(e.DeepCopy())
+		e.Status.ConfigSources = sources
+		e.Status.ClusterConfigGeneration = generation
+		if err = c.Status().Patch(ctx, e, patch); err != nil {
+			log.Error(err, "Failed to update elastalert config sources", "Elastalert.Name", e.Name)
+			return err
+		}
+	}
+	e.Spec = merged.Spec
+	return nil
+}
+
+// elastalertsForClusterConfig maps an ElastalertClusterConfig to the Elastalerts using it.
+func elastalertsForClusterConfig(c client.Client) func(obj client.Object) []reconcile.Request {
+	return func(obj client.Object) []reconcile.Request {
+		list := &esv1alpha1.ElastalertList{}
+		if err := c.List(context.Background(), list); err != nil {
+			log.Error(err, "Failed to list Elastalerts for ElastalertClusterConfig", "ClusterConfig.Name", obj.GetName())
+			return nil
+		}
+		var requests []reconcile.Request
+		for i := range list.Items {
+			if podspec.ClusterConfigName(&list.Items[i]) == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: list.Items[i].Namespace,
+					Name:      list.Items[i].Name,
+				}})
+			}
+		}
+		return requests
+	}
+}
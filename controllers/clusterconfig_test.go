@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+)
+
+func TestResolveClusterConfig(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	cc := &v1alpha1.ElastalertClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+	}
+	testCases := []struct {
+		desc          string
+		clusterConfig string
+		objects       bool
+		wantName      string
+		wantErr       bool
+	}{
+		{
+			desc:     "test default cluster config",
+			objects:  true,
+			wantName: "default",
+		},
+		{
+			desc: "test missing default cluster config is ignored",
+		},
+		{
+			desc:          "test missing named cluster config",
+			clusterConfig: "prod",
+			objects:       true,
+			wantErr:       true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(s)
+			if tc.objects {
+				builder = builder.WithRuntimeObjects(cc.DeepCopy())
+			}
+			e := &v1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "my-esa"},
+				Spec:       v1alpha1.ElastalertSpec{ClusterConfig: tc.clusterConfig},
+			}
+			have, err := resolveClusterConfig(builder.Build(), context.Background(), e)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tc.wantName == "" {
+				require.Nil(t, have)
+				return
+			}
+			require.Equal(t, tc.wantName, have.Name)
+		})
+	}
+}
+
+func TestApplyClusterConfig(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	e := &v1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "my-esa"},
+		Spec: v1alpha1.ElastalertSpec{
+			ConfigSetting: v1alpha1.NewFreeForm(map[string]interface{}{"run_every": "1m"}),
+		},
+	}
+	cc := &v1alpha1.ElastalertClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Generation: 2},
+		Spec: v1alpha1.ElastalertClusterConfigSpec{
+			Config: v1alpha1.NewFreeForm(map[string]interface{}{"es_host": "es.default"}),
+			Cert:   "default-cert",
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(e).Build()
+	require.True(t, clusterConfigChanged(e, cc))
+	require.NoError(t, applyClusterConfig(c, context.Background(), e, cc))
+	require.Equal(t, "default-cert", e.Spec.Cert)
+	config, err := e.Spec.ConfigSetting.GetMap()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"run_every": "1m", "es_host": "es.default"}, config)
+
+	have := &v1alpha1.Elastalert{}
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "esa1", Name: "my-esa"}, have))
+	require.Equal(t, int64(2), have.Status.ClusterConfigGeneration)
+	require.Equal(t, map[string]string{
+		"run_every": "spec",
+		"es_host":   "ElastalertClusterConfig/default",
+		"cert":      "ElastalertClusterConfig/default",
+	}, have.Status.ConfigSources)
+	require.False(t, clusterConfigChanged(have, cc))
+
+	cc.Generation = 3
+	require.True(t, clusterConfigChanged(have, cc))
+	require.True(t, clusterConfigChanged(have, nil))
+}
+
+func TestElastalertsForClusterConfig(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		&v1alpha1.Elastalert{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "uses-default"},
+		},
+		&v1alpha1.Elastalert{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "esa2", Name: "uses-prod"},
+			Spec:       v1alpha1.ElastalertSpec{ClusterConfig: "prod"},
+		},
+	).Build()
+	have := elastalertsForClusterConfig(c)(&v1alpha1.ElastalertClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+	})
+	require.Equal(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "esa2", Name: "uses-prod"}},
+	}, have)
+}
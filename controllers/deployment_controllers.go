@@ -31,6 +31,9 @@ func (r *DeploymentReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 		log.Error(err, "Failed to get deployment from server")
 		return ctrl.Result{}, err
 	}
+	if podspec.IsPaused(elastalert) {
+		return ctrl.Result{}, nil
+	}
 	if _, err = recreateDeployment(r.Client, r.Scheme, ctx, elastalert); err != nil {
 		if statusError := ob.UpdateElastalertStatus(r.Client, ctx, elastalert, esv1alpha1.ActionFailed); statusError != nil {
 			return ctrl.Result{}, statusError
@@ -57,16 +60,31 @@ func recreateDeployment(c client.Client, Scheme *runtime.Scheme, ctx context.Con
 		deploy)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
-			newDeploy, err := podspec.GenerateNewDeployment(Scheme, e)
+			cc, err := resolveClusterConfig(c, ctx, e)
 			if err != nil {
 				return nil, err
 			}
+			if err = applyClusterConfig(c, ctx, e, cc); err != nil {
+				return nil, err
+			}
+			// the windows recorded in status are kept, they are evaluated again by the Elastalert reconciler.
+			if err = podspec.PatchMaintenanceSettings(e); err != nil {
+				return nil, err
+			}
 			if err = applySecret(c, Scheme, ctx, e); err != nil {
 				return nil, err
 			}
+			if err = applyAlerterSecret(c, Scheme, ctx, e); err != nil {
+				return nil, err
+			}
 			if err = applyConfigMaps(c, Scheme, ctx, e); err != nil {
 				return nil, err
 			}
+			// the deployment is generated after the configmaps, so that it projects every rule shard.
+			newDeploy, err := podspec.GenerateNewDeployment(Scheme, e)
+			if err != nil {
+				return nil, err
+			}
 			if err = c.Create(ctx, newDeploy); err != nil {
 				return nil, err
 			}
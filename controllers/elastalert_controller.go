@@ -18,8 +18,11 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/elasticsearch"
 	"github.com/toughnoah/elastalert-operator/controllers/event"
+	"github.com/toughnoah/elastalert-operator/controllers/metrics"
 	ob "github.com/toughnoah/elastalert-operator/controllers/observer"
 	"github.com/toughnoah/elastalert-operator/controllers/podspec"
 	appsv1 "k8s.io/api/apps/v1"
@@ -29,11 +32,16 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sync"
+	"time"
 )
 
 const name = "elastalert-controller"
@@ -45,39 +53,145 @@ type ElastalertReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
-	Observer ob.Manager
+	// Clock tells the time maintenance windows and cleanup timeouts are evaluated at, the real clock when nil.
+	Clock clock.Clock
+	// ESClientFactory connects to the elasticsearch cluster of an Elastalert, elasticsearch.NewClientFor when nil.
+	ESClientFactory elasticsearch.ClientFactory
+	// Preflight checks that elasticsearch is reachable before a new config is rolled out.
+	Preflight bool
+	// PodLogs reads the log tail of failed pods for status.lastFailure, which is recorded without it when nil.
+	PodLogs ob.PodLogs
+	// RuleRuns reads when the rules last ran from the writeback indices, for the rule_last_run metric.
+	RuleRuns bool
+
+	// ruleRunsSyncTimes are when the latest runs of the rules were last read, by Elastalert.
+	ruleRunsSyncTimes sync.Map
 }
 
 //+kubebuilder:rbac:groups=es.noah.domain,resources=elastalerts,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=es.noah.domain,resources=elastalerts/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=es.noah.domain,resources=elastalerts/finalizers,verbs=update
+//+kubebuilder:rbac:groups=es.noah.domain,resources=elastalertalertprofiles,verbs=get;list;watch
+//+kubebuilder:rbac:groups=es.noah.domain,resources=clusterelastalertalertprofiles,verbs=get;list;watch
+//+kubebuilder:rbac:groups=es.noah.domain,resources=elastalertclusterconfigs,verbs=get;list;watch
 func (r *ElastalertReconciler) Reconcile(ctx context.Context, req reconcile.Request) (ctrl.Result, error) {
 	elastalert := &esv1alpha1.Elastalert{}
 	err := r.Get(ctx, req.NamespacedName, elastalert)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
-			r.Observer.StopObserving(req.NamespacedName)
+			metrics.Forget(req.NamespacedName)
+			if recorder, ok := r.Recorder.(*event.Recorder); ok {
+				recorder.Forget(req.NamespacedName)
+			}
+			r.ruleRunsSyncTimes.Delete(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		log.Error(err, "Failed to get Elastalert from server")
 		return ctrl.Result{}, err
 	}
-	cond := r.findSuccessCondition(elastalert)
-	if cond == nil || cond.ObservedGeneration != elastalert.Generation {
+	if !elastalert.DeletionTimestamp.IsZero() {
+		return r.finalizeWriteback(ctx, elastalert)
+	}
+	if err = ensureWritebackFinalizer(r.Client, ctx, elastalert); err != nil {
+		return ctrl.Result{}, err
+	}
+	wasPaused := meta.FindStatusCondition(elastalert.Status.Condictions, esv1alpha1.ElastAlertPausedType) != nil
+	if podspec.IsPaused(elastalert) {
+		// leave every resource as is, so that manual edits are not reverted.
+		if statusError := ob.UpdatePausedCondition(r.Client, ctx, elastalert, true); statusError != nil {
+			return ctrl.Result{}, statusError
+		}
+		if !wasPaused {
+			ob.EmitK8sEvent(r.Recorder, elastalert, corev1.EventTypeNormal, event.EventReasonPaused, "Reconciliation is paused.")
+		}
+		return ctrl.Result{}, nil
+	}
+	if wasPaused {
+		if statusError := ob.UpdatePausedCondition(r.Client, ctx, elastalert, false); statusError != nil {
+			return ctrl.Result{}, statusError
+		}
+		ob.EmitK8sEvent(r.Recorder, elastalert, corev1.EventTypeNormal, event.EventReasonPaused, "Reconciliation is resumed.")
+	}
+	cc, err := resolveClusterConfig(r.Client, ctx, elastalert)
+	if err != nil {
+		ob.EmitFailure(r.Recorder, elastalert, event.EventReasonConfigRenderFailed, "Failed to get ElastalertClusterConfig", err)
+		if statusError := ob.UpdateElastalertStatus(r.Client, ctx, elastalert, esv1alpha1.ActionFailed); statusError != nil {
+			return ctrl.Result{}, statusError
+		}
+		return ctrl.Result{}, err
+	}
+	maintenance, err := podspec.EvaluateMaintenanceWindows(elastalert, r.now())
+	if err != nil {
+		ob.EmitFailure(r.Recorder, elastalert, event.EventReasonConfigRenderFailed, "Failed to evaluate maintenance windows", err)
+		if statusError := ob.UpdateElastalertStatus(r.Client, ctx, elastalert, esv1alpha1.ActionFailed); statusError != nil {
+			return ctrl.Result{}, statusError
+		}
+		return ctrl.Result{}, err
+	}
+	maintenanceChanged, err := updateMaintenanceStatus(r.Client, r.Recorder, ctx, elastalert, maintenance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	result := ctrl.Result{}
+	if !maintenance.NextTransition.IsZero() {
+		result.RequeueAfter = maintenance.NextTransition.Sub(r.now())
+	}
+	// generations start at 1, none is observed before the resources are first applied.
+	applied := elastalert.Status.ObservedGeneration != 0 && elastalert.Status.ObservedGeneration == elastalert.Generation
+	secretsHash, err := hashSecrets(r.Client, ctx, elastalert)
+	changed := secretsChanged(elastalert, secretsHash, err)
+	profileGenerations, err := resolveAlertProfileGenerations(r.Client, ctx, elastalert)
+	changed = alertProfilesChanged(elastalert, profileGenerations, err) || changed
+	// resources edited while paused are applied again on resume.
+	apply := !applied || clusterConfigChanged(elastalert, cc) || changed || wasPaused || maintenanceChanged
+	if apply {
 		if statusError := ob.UpdateElastalertStatus(r.Client, ctx, elastalert, esv1alpha1.ResourcesCreating); statusError != nil {
 			return ctrl.Result{}, statusError
 		}
+		if r.Preflight {
+			if err = r.preflight(ctx, elastalert, cc); err != nil {
+				// keep the running config, a crash-looping pod would tell less. The old pods staying healthy must not
+				// mark the new generation available, so their health is checked again once the preflight passes.
+				ob.EmitK8sEvent(r.Recorder, elastalert, corev1.EventTypeWarning, event.EventReasonElasticsearchUnreachable, fmt.Sprintf("Elasticsearch is not reachable, the config is not rolled out: %v.", err))
+				if statusError := ob.UpdateElastalertStatus(r.Client, ctx, elastalert, esv1alpha1.ActionFailed); statusError != nil {
+					return ctrl.Result{}, statusError
+				}
+				return ctrl.Result{RequeueAfter: preflightRetryInterval}, nil
+			}
+		}
+		if err = applyClusterConfig(r.Client, ctx, elastalert, cc); err != nil {
+			ob.EmitFailure(r.Recorder, elastalert, event.EventReasonConfigRenderFailed, "Failed to apply ElastalertClusterConfig", err)
+			if statusError := ob.UpdateElastalertStatus(r.Client, ctx, elastalert, esv1alpha1.ActionFailed); statusError != nil {
+				return ctrl.Result{}, statusError
+			}
+			return ctrl.Result{}, err
+		}
+		if err = podspec.PatchMaintenanceSettings(elastalert); err != nil {
+			ob.EmitFailure(r.Recorder, elastalert, event.EventReasonConfigRenderFailed, "Failed to apply maintenance windows", err)
+			if statusError := ob.UpdateElastalertStatus(r.Client, ctx, elastalert, esv1alpha1.ActionFailed); statusError != nil {
+				return ctrl.Result{}, statusError
+			}
+			return ctrl.Result{}, err
+		}
+		r.validateRules(ctx, elastalert)
 		if err = applySecret(r.Client, r.Scheme, ctx, elastalert); err != nil {
-			ob.EmitK8sEvent(r.Recorder, elastalert, corev1.EventTypeWarning, event.EventReasonError, "Failed to apply Secret.")
+			ob.EmitFailure(r.Recorder, elastalert, event.EventReasonApplyFailed, "Failed to apply "+event.Object("Secret", elastalert.Namespace, elastalert.Name+podspec.DefaultCertSuffix), err)
 			if statusError := ob.UpdateElastalertStatus(r.Client, ctx, elastalert, esv1alpha1.ActionFailed); statusError != nil {
 				return ctrl.Result{}, statusError
 			}
 			return ctrl.Result{}, err
 		}
 		ob.EmitK8sEvent(r.Recorder, elastalert, corev1.EventTypeNormal, event.EventReasonCreated, "Apply cert secret successfully.")
+		if err = applyAlerterSecret(r.Client, r.Scheme, ctx, elastalert); err != nil {
+			ob.EmitFailure(r.Recorder, elastalert, event.EventReasonApplyFailed, "Failed to apply alerters Secret", err)
+			if statusError := ob.UpdateElastalertStatus(r.Client, ctx, elastalert, esv1alpha1.ActionFailed); statusError != nil {
+				return ctrl.Result{}, statusError
+			}
+			return ctrl.Result{}, err
+		}
 		if err = applyConfigMaps(r.Client, r.Scheme, ctx, elastalert); err != nil {
-			ob.EmitK8sEvent(r.Recorder, elastalert, corev1.EventTypeWarning, event.EventReasonError, "Failed to apply configmaps")
+			ob.EmitFailure(r.Recorder, elastalert, event.EventReasonApplyFailed, "Failed to apply configmaps", err)
 			if statusError := ob.UpdateElastalertStatus(r.Client, ctx, elastalert, esv1alpha1.ActionFailed); statusError != nil {
 				return ctrl.Result{}, statusError
 			}
@@ -85,85 +199,180 @@ func (r *ElastalertReconciler) Reconcile(ctx context.Context, req reconcile.Requ
 		}
 		ob.EmitK8sEvent(r.Recorder, elastalert, corev1.EventTypeNormal, event.EventReasonCreated, "Apply configmaps successfully.")
 		if _, err = applyDeployment(r.Client, r.Scheme, ctx, elastalert); err != nil {
-			ob.EmitK8sEvent(r.Recorder, elastalert, corev1.EventTypeWarning, event.EventReasonError, "Failed to apply deployment.")
+			ob.EmitFailure(r.Recorder, elastalert, event.EventReasonApplyFailed, "Failed to apply "+event.Object("Deployment", elastalert.Namespace, elastalert.Name), err)
 			if statusError := ob.UpdateElastalertStatus(r.Client, ctx, elastalert, esv1alpha1.ActionFailed); statusError != nil {
 				return ctrl.Result{}, statusError
 			}
 			return ctrl.Result{}, err
 		}
 		ob.EmitK8sEvent(r.Recorder, elastalert, corev1.EventTypeNormal, event.EventReasonSuccess, "Apply deployment done, reconcile Elastalert resources successfully.")
+		pruned, err := pruneResources(r.Client, ctx, elastalert)
+		for _, p := range pruned {
+			ob.EmitK8sEvent(r.Recorder, elastalert, corev1.EventTypeNormal, event.EventReasonDeleted, fmt.Sprintf("Pruned %s which is no longer desired.", p))
+		}
+		if err != nil {
+			ob.EmitFailure(r.Recorder, elastalert, event.EventReasonError, "Failed to prune resources", err)
+			return ctrl.Result{}, err
+		}
+		if err = updateRolloutStatus(r.Client, ctx, elastalert, secretsHash, profileGenerations, r.now()); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err = ob.UpdateElastalertStatus(r.Client, ctx, elastalert, esv1alpha1.ActionSuccess); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if next := r.syncWritebackIndex(ctx, elastalert); next > 0 && (result.RequeueAfter == 0 || next < result.RequeueAfter) {
+		result.RequeueAfter = next
+	}
+	if next := r.syncRuleLastRuns(ctx, elastalert); next > 0 && (result.RequeueAfter == 0 || next < result.RequeueAfter) {
+		result.RequeueAfter = next
+	}
+	if !apply {
+		// the health after applying is checked on the changes of the deployment the rollout brings.
+		next, err := ob.CheckHealth(r.Client, r.Recorder, r.PodLogs, ctx, elastalert, r.now())
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if result.RequeueAfter == 0 || next < result.RequeueAfter {
+			result.RequeueAfter = next
+		}
 	}
-	r.startObservingHealth(elastalert)
-	return ctrl.Result{}, nil
+	return result, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ElastalertReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&esv1alpha1.Elastalert{}).
+		Owns(&appsv1.Deployment{}).
+		Watches(&source.Kind{Type: &corev1.Pod{}}, handler.EnqueueRequestsFromMapFunc(elastalertForPod)).
+		Watches(&source.Kind{Type: &esv1alpha1.ElastalertClusterConfig{}}, handler.EnqueueRequestsFromMapFunc(elastalertsForClusterConfig(mgr.GetClient()))).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(elastalertsForSecret(mgr.GetClient()))).
+		Watches(&source.Kind{Type: &esv1alpha1.ElastalertAlertProfile{}}, handler.EnqueueRequestsFromMapFunc(elastalertsForAlertProfile(mgr.GetClient()))).
+		Watches(&source.Kind{Type: &esv1alpha1.ClusterElastalertAlertProfile{}}, handler.EnqueueRequestsFromMapFunc(elastalertsForAlertProfile(mgr.GetClient()))).
 		WithOptions(controller.Options{MaxConcurrentReconciles: 5}).
 		Complete(r)
 }
 
-func (r *ElastalertReconciler) startObservingHealth(e *esv1alpha1.Elastalert) {
-	r.Observer.Observe(e, r.Client, r.Recorder)
+func (r *ElastalertReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+	return r.Clock.Now()
 }
 
-func (r *ElastalertReconciler) findSuccessCondition(e *esv1alpha1.Elastalert) *metav1.Condition {
-	return meta.FindStatusCondition(e.Status.Condictions, esv1alpha1.ElastAlertAvailableType)
+func (r *ElastalertReconciler) esClientFactory() elasticsearch.ClientFactory {
+	if r.ESClientFactory == nil {
+		return elasticsearch.NewClientFor
+	}
+	return r.ESClientFactory
 }
 
-func applyConfigMaps(c client.Client, Scheme *runtime.Scheme, ctx context.Context, e *esv1alpha1.Elastalert) error {
+// elastalertForPod maps a pod to the Elastalert it runs, so that its health is checked again when the pod changes.
+func elastalertForPod(obj client.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()[podspec.ElastalertInstanceLabel]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}}}
+}
+
+// updateRolloutStatus records that the resources of the generation of the Elastalert, the Secrets hashing to
+// secretsHash and the alert profiles of profileGenerations were applied at now, which starts the grace period of its
+// health checks.
+func updateRolloutStatus(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert, secretsHash string, profileGenerations map[string]int64, now time.Time) error {
+	patch := client.MergeFrom(e.DeepCopy())
+	e.Status.ObservedGeneration = e.Generation
+	e.Status.SecretsHash = secretsHash
+	e.Status.AlertProfileGenerations = profileGenerations
+	e.Status.Health = &esv1alpha1.HealthStatus{RolloutTime: &metav1.Time{Time: now}}
+	if err := c.Status().Patch(ctx, e, patch); err != nil {
+		log.Error(err, "Failed to update elastalert rollout status", "Elastalert.Namespace", e.Namespace, "Elastalert.Name", e.Name)
+		return err
+	}
+	return nil
+}
+
+// renderConfigMaps patches the config and the rules of the Elastalert and returns the ConfigMaps holding them, the rule
+// ConfigMaps first.
+func renderConfigMaps(c client.Client, Scheme *runtime.Scheme, ctx context.Context, e *esv1alpha1.Elastalert) ([]corev1.ConfigMap, error) {
 	stringCert := e.Spec.Cert
 	err := podspec.PatchConfigSettings(e, stringCert)
 	if err != nil {
 		log.Error(err, "Failed to patch config.yaml configmaps", "Elastalert.Namespace", e.Namespace, "Configmaps.Namespace", e.Namespace)
-		return err
+		return nil, err
+	}
+	if err = patchRules(c, ctx, e); err != nil {
+		return nil, err
 	}
-	err = podspec.PatchAlertSettings(e)
+	err = podspec.ValidateFlavorSettings(e)
+	if err != nil {
+		log.Error(err, "Failed to validate rules configmaps", "Elastalert.Namespace", e.Namespace, "Configmaps.Namespace", e.Namespace)
+		return nil, err
+	}
+	config, err := podspec.GenerateNewConfigmap(Scheme, e, esv1alpha1.ConfigSuffx)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := podspec.GenerateRuleConfigmaps(Scheme, e)
+	if err != nil {
+		return nil, err
+	}
+	return append(rules, *config), nil
+}
+
+// patchRules renders overall, the alert profiles, the typed alerters and the elasticsearch clusters into the rules.
+func patchRules(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert) error {
+	err := podspec.PatchAlertSettings(e)
 	if err != nil {
 		log.Error(err, "Failed to patch alert for rules configmaps", "Elastalert.Namespace", e.Namespace, "Configmaps.Namespace", e.Namespace)
 		return err
 	}
-	list := &corev1.ConfigMapList{}
-	opts := client.InNamespace(e.Namespace)
-	if err = c.List(ctx, list, opts); err != nil {
+	profiles, err := resolveAlertProfiles(c, ctx, e)
+	if err != nil {
 		return err
 	}
-	config, err := podspec.GenerateNewConfigmap(Scheme, e, esv1alpha1.ConfigSuffx)
+	err = podspec.PatchAlertProfiles(e, profiles)
+	if err != nil {
+		log.Error(err, "Failed to patch alert profiles for rules configmaps", "Elastalert.Namespace", e.Namespace, "Configmaps.Namespace", e.Namespace)
+		return err
+	}
+	err = podspec.PatchAlerterSettings(e)
 	if err != nil {
+		log.Error(err, "Failed to patch alerters for rules configmaps", "Elastalert.Namespace", e.Namespace, "Configmaps.Namespace", e.Namespace)
 		return err
 	}
-	rule, err := podspec.GenerateNewConfigmap(Scheme, e, esv1alpha1.RuleSuffx)
+	err = podspec.PatchClusterSettings(e)
 	if err != nil {
+		log.Error(err, "Failed to patch elasticsearch clusters for rules configmaps", "Elastalert.Namespace", e.Namespace, "Configmaps.Namespace", e.Namespace)
+		return err
+	}
+	return nil
+}
+
+func applyConfigMaps(c client.Client, Scheme *runtime.Scheme, ctx context.Context, e *esv1alpha1.Elastalert) error {
+	configMapsList, err := renderConfigMaps(c, Scheme, ctx, e)
+	if err != nil {
+		return &event.ObjectError{Reason: event.EventReasonConfigRenderFailed, Err: err}
+	}
+	list := &corev1.ConfigMapList{}
+	opts := client.InNamespace(e.Namespace)
+	if err = c.List(ctx, list, opts); err != nil {
 		return err
 	}
 	configMapsMaps := podspec.ConfigMapsToMap(list.Items)
-	var configMapsList []corev1.ConfigMap
-	configMapsList = append(configMapsList, *rule, *config)
-	if len(list.Items) != 0 {
-		for _, cm := range configMapsList {
-			if _, ok := configMapsMaps[cm.Name]; ok {
-				if err = c.Update(ctx, &cm); err != nil {
-					log.Error(err, "Failed to update configmaps", "Elastalert.Namespace", e.Namespace, "Configmaps.Namespace", e.Namespace)
-					return err
-				}
-			} else {
-				if err = c.Create(ctx, &cm); err != nil {
-					log.Error(err, "Failed to create configmaps", "Elastalert.Namespace", e.Namespace, "Configmaps.Namespace", e.Namespace)
-					return err
-				}
+	for _, cm := range configMapsList {
+		if _, ok := configMapsMaps[cm.Name]; ok {
+			if err = c.Update(ctx, &cm); err != nil {
+				log.Error(err, "Failed to update configmaps", "Elastalert.Namespace", e.Namespace, "Configmaps.Namespace", e.Namespace)
+				return err
 			}
-		}
-		return nil
-	} else {
-		for _, cm := range configMapsList {
+		} else {
 			if err = c.Create(ctx, &cm); err != nil {
 				log.Error(err, "Failed to create configmaps", "Elastalert.Namespace", e.Namespace, "Configmaps.Namespace", e.Namespace)
 				return err
 			}
 		}
-
 	}
 	log.V(1).Info(
 		"Apply configmaps successfully",
@@ -175,6 +384,10 @@ func applyConfigMaps(c client.Client, Scheme *runtime.Scheme, ctx context.Contex
 
 func applySecret(c client.Client, Scheme *runtime.Scheme, ctx context.Context, e *esv1alpha1.Elastalert) error {
 	secret := &corev1.Secret{}
+	if e.Spec.Cert == "" {
+		// nothing to store, a leftover cert secret is removed by pruneResources.
+		return nil
+	}
 	newSecret, err := podspec.GenerateCertSecret(Scheme, e)
 	if err != nil {
 		return err
@@ -205,6 +418,78 @@ func applySecret(c client.Client, Scheme *runtime.Scheme, ctx context.Context, e
 	return nil
 }
 
+func applyAlerterSecret(c client.Client, Scheme *runtime.Scheme, ctx context.Context, e *esv1alpha1.Elastalert) error {
+	if !podspec.HasAlerterSecrets(e) {
+		// a leftover alerters secret is removed by pruneResources.
+		return nil
+	}
+	values, err := resolveAlerterSecrets(c, ctx, e)
+	if err != nil {
+		return err
+	}
+	newSecret, err := podspec.GenerateAlerterSecret(Scheme, e, values)
+	if err != nil {
+		return err
+	}
+	secret := &corev1.Secret{}
+	if err = c.Get(ctx, types.NamespacedName{
+		Namespace: e.Namespace,
+		Name:      newSecret.Name,
+	}, secret); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+		if err = c.Create(ctx, newSecret); err != nil {
+			log.Error(err, "Failed to create alerters Secret", "Elastalert.Namespace", e.Namespace, "Secret.Name", newSecret.Name)
+			return err
+		}
+	} else {
+		if err = c.Update(ctx, newSecret); err != nil {
+			log.Error(err, "Failed to update alerters Secret", "Elastalert.Namespace", e.Namespace, "Secret.Name", newSecret.Name)
+			return err
+		}
+	}
+	log.V(1).Info(
+		"Apply alerters secret successfully",
+		"Elastalert.Namespace", e.Namespace,
+		"Secret.Name", newSecret.Name,
+	)
+	return nil
+}
+
+// resolveAlerterSecrets reads the secret values referenced by the typed alerters and the elasticsearch clusters
+// from the instance namespace.
+func resolveAlerterSecrets(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert) (map[string]string, error) {
+	values := map[string]string{}
+	for option, ref := range podspec.SecretRefs(e) {
+		optional := ref.Optional != nil && *ref.Optional
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: e.Namespace, Name: ref.Name}, secret); err != nil {
+			if k8serrors.IsNotFound(err) && optional {
+				continue
+			}
+			log.Error(err, "Failed to get alerter Secret", "Elastalert.Namespace", e.Namespace, "Secret.Name", ref.Name)
+			if k8serrors.IsNotFound(err) {
+				return nil, &event.ObjectError{Reason: event.EventReasonSecretMissing, Object: event.Object("Secret", e.Namespace, ref.Name), Err: err}
+			}
+			return nil, err
+		}
+		value, ok := secret.Data[ref.Key]
+		if !ok {
+			if optional {
+				continue
+			}
+			return nil, &event.ObjectError{
+				Reason: event.EventReasonSecretMissing,
+				Object: event.Object("Secret", e.Namespace, ref.Name),
+				Err:    fmt.Errorf("key %s not found for %s", ref.Key, option),
+			}
+		}
+		values[option] = string(value)
+	}
+	return values, nil
+}
+
 func applyDeployment(c client.Client, Scheme *runtime.Scheme, ctx context.Context, e *esv1alpha1.Elastalert) (*appsv1.Deployment, error) {
 	deploy := &appsv1.Deployment{}
 	err := c.Get(ctx,
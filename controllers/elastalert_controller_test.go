@@ -13,6 +13,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -165,6 +166,137 @@ func TestApplySecret(t *testing.T) {
 	}
 }
 
+func TestApplyAlerterSecret(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &v1alpha1.Elastalert{})
+	webhook := corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "slack"},
+		Key:                  "url",
+	}
+	testCases := []struct {
+		desc       string
+		elastalert v1alpha1.Elastalert
+		c          client.Client
+		wantErr    bool
+		want       map[string][]byte
+	}{
+		{
+			desc: "test to create alerters secret",
+			elastalert: v1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "esa1",
+					Name:      "my-esa",
+				},
+				Spec: v1alpha1.ElastalertSpec{
+					Alerters: &v1alpha1.Alerters{
+						Slack: &v1alpha1.SlackAlerter{WebhookURL: webhook},
+					},
+				},
+			},
+			c: fake.NewClientBuilder().WithRuntimeObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "esa1",
+					Name:      "slack",
+				},
+				Data: map[string][]byte{
+					"url": []byte("https://hooks.slack.com/abc"),
+				},
+			}).Build(),
+			want: map[string][]byte{
+				"alerters.yaml": []byte("slack_webhook_url: https://hooks.slack.com/abc\n"),
+			},
+		},
+		{
+			desc: "test to update alerters secret",
+			elastalert: v1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "esa1",
+					Name:      "my-esa",
+				},
+				Spec: v1alpha1.ElastalertSpec{
+					Alerters: &v1alpha1.Alerters{
+						Slack: &v1alpha1.SlackAlerter{WebhookURL: webhook},
+					},
+				},
+			},
+			c: fake.NewClientBuilder().WithRuntimeObjects(
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "esa1",
+						Name:      "slack",
+					},
+					Data: map[string][]byte{
+						"url": []byte("https://hooks.slack.com/abc"),
+					},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "esa1",
+						Name:      "my-esa" + podspec.DefaultAlerterSecretSuffix,
+					},
+				},
+			).Build(),
+			want: map[string][]byte{
+				"alerters.yaml": []byte("slack_webhook_url: https://hooks.slack.com/abc\n"),
+			},
+		},
+		{
+			desc: "test referenced secret is missing",
+			elastalert: v1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "esa1",
+					Name:      "my-esa",
+				},
+				Spec: v1alpha1.ElastalertSpec{
+					Alerters: &v1alpha1.Alerters{
+						Slack: &v1alpha1.SlackAlerter{WebhookURL: webhook},
+					},
+				},
+			},
+			c:       fake.NewClientBuilder().Build(),
+			wantErr: true,
+		},
+		{
+			desc: "test referenced key is missing",
+			elastalert: v1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "esa1",
+					Name:      "my-esa",
+				},
+				Spec: v1alpha1.ElastalertSpec{
+					Alerters: &v1alpha1.Alerters{
+						Slack: &v1alpha1.SlackAlerter{WebhookURL: webhook},
+					},
+				},
+			},
+			c: fake.NewClientBuilder().WithRuntimeObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "esa1",
+					Name:      "slack",
+				},
+			}).Build(),
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := applyAlerterSecret(tc.c, s, context.Background(), &tc.elastalert)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			se := corev1.Secret{}
+			err = tc.c.Get(context.Background(), types.NamespacedName{
+				Namespace: "esa1",
+				Name:      "my-esa" + podspec.DefaultAlerterSecretSuffix,
+			}, &se)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, se.Data)
+		})
+	}
+}
+
 func TestApplyDeployment(t *testing.T) {
 	testCases := []struct {
 		desc       string
@@ -359,16 +491,17 @@ func TestReconcile(t *testing.T) {
 						Cert: "abc",
 					},
 					Status: v1alpha1.ElastalertStatus{
-						Version: "v1.0",
-						Phase:   "RUNNING",
+						Version:            "v1.0",
+						Phase:              "RUNNING",
+						ObservedGeneration: int64(1),
 						Condictions: []metav1.Condition{
 							{
-								Type:               "Progressing",
+								Type:               "Available",
 								Status:             "True",
 								ObservedGeneration: int64(1),
 								LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-								Reason:             "NewElastAlertAvailable",
-								Message:            "ElastAlert my-esa has successfully progressed.",
+								Reason:             "ReplicasAvailable",
+								Message:            "1 of 1 replicas of Deployment esa1/my-esa are available.",
 							},
 						},
 					},
@@ -397,16 +530,17 @@ func TestReconcile(t *testing.T) {
 						},
 					},
 					Status: v1alpha1.ElastalertStatus{
-						Version: "v1.0",
-						Phase:   "RUNNING",
+						Version:            "v1.0",
+						Phase:              "RUNNING",
+						ObservedGeneration: int64(1),
 						Condictions: []metav1.Condition{
 							{
-								Type:               "Progressing",
+								Type:               "Available",
 								Status:             "True",
 								ObservedGeneration: int64(1),
 								LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-								Reason:             "NewElastAlertAvailable",
-								Message:            "ElastAlert my-esa has successfully progressed.",
+								Reason:             "ReplicasAvailable",
+								Message:            "1 of 1 replicas of Deployment esa1/my-esa are available.",
 							},
 						},
 					},
@@ -452,16 +586,17 @@ func TestReconcile(t *testing.T) {
 						},
 					},
 					Status: v1alpha1.ElastalertStatus{
-						Version: "v1.0",
-						Phase:   "RUNNING",
+						Version:            "v1.0",
+						Phase:              "RUNNING",
+						ObservedGeneration: int64(1),
 						Condictions: []metav1.Condition{
 							{
-								Type:               "Progressing",
+								Type:               "Available",
 								Status:             "True",
 								ObservedGeneration: int64(1),
 								LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-								Reason:             "NewElastAlertAvailable",
-								Message:            "ElastAlert my-esa has successfully progressed.",
+								Reason:             "ReplicasAvailable",
+								Message:            "1 of 1 replicas of Deployment esa1/my-esa are available.",
 							},
 						},
 					},
@@ -580,7 +715,6 @@ func TestReconcile(t *testing.T) {
 				Client:   tc.c,
 				Scheme:   s,
 				Recorder: record.NewBroadcaster().NewRecorder(s, corev1.EventSource{}),
-				Observer: *ob.NewManager(),
 			}
 			nsn := types.NamespacedName{Name: "my-esa", Namespace: "esa1"}
 			req := reconcile.Request{NamespacedName: nsn}
@@ -608,7 +742,6 @@ func TestReconcileError(t *testing.T) {
 		Client:   fake.NewClientBuilder().Build(),
 		Scheme:   s,
 		Recorder: record.NewBroadcaster().NewRecorder(s, corev1.EventSource{}),
-		Observer: *ob.NewManager(),
 	}
 	nsn := types.NamespacedName{Name: "my-esa", Namespace: "esa1"}
 	req := reconcile.Request{NamespacedName: nsn}
@@ -713,361 +846,195 @@ func TestReconcileApplyDeploymentFailed(t *testing.T) {
 func TestUpdateStatus(t *testing.T) {
 	s := scheme.Scheme
 	s.AddKnownTypes(corev1.SchemeGroupVersion, &v1alpha1.Elastalert{})
+	condition := func(conditionType string, status metav1.ConditionStatus, reason string, generation int64) metav1.Condition {
+		return metav1.Condition{
+			Type:               conditionType,
+			Status:             status,
+			ObservedGeneration: generation,
+			LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
+			Reason:             reason,
+			Message:            reason + ".",
+		}
+	}
+	reconciled := condition("Reconciled", metav1.ConditionTrue, "ReconcileSucceeded", 1)
+	available := condition("Available", metav1.ConditionTrue, "ReplicasAvailable", 1)
 	testCases := []struct {
-		desc string
-		c    client.Client
-		cond *metav1.Condition
-		want v1alpha1.Elastalert
+		desc        string
+		status      v1alpha1.ElastalertStatus
+		conditions  []metav1.Condition
+		phase       string
+		ready       metav1.ConditionStatus
+		readyReason string
 	}{
 		{
-			desc: "test to update elasalert success status",
-			c: fake.NewClientBuilder().WithRuntimeObjects(
-				&v1alpha1.Elastalert{
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace: "esa1",
-						Name:      "my-esa",
-					},
-				}).Build(),
-			cond: &metav1.Condition{
-				Type:               v1alpha1.ElastAlertAvailableType,
-				Status:             v1alpha1.ElastAlertAvailableStatus,
-				LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-				ObservedGeneration: 1,
-				Reason:             v1alpha1.ElastAlertAvailableReason,
-				Message:            "ElastAlert my-esa has successfully progressed.",
-			},
-			want: v1alpha1.Elastalert{
-				TypeMeta: metav1.TypeMeta{
-					Kind:       "Elastalert",
-					APIVersion: "v1",
-				},
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "esa1",
-					Name:      "my-esa",
-				},
-				Status: v1alpha1.ElastalertStatus{
-					Version: "v1.0",
-					Phase:   "RUNNING",
-					Condictions: []metav1.Condition{
-						{
-							Type:               "Progressing",
-							Status:             "True",
-							ObservedGeneration: int64(1),
-							LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-							Reason:             "NewElastAlertAvailable",
-							Message:            "ElastAlert my-esa has successfully progressed.",
-						},
-					},
-				},
-			},
+			desc:        "test initializing without conditions",
+			phase:       "INITIALIZING",
+			ready:       metav1.ConditionFalse,
+			readyReason: "Initializing",
 		},
 		{
-			desc: "test to update elasalert failed status",
-			c: fake.NewClientBuilder().WithRuntimeObjects(
-				&v1alpha1.Elastalert{
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace: "esa1",
-						Name:      "my-esa",
-					},
-				}).Build(),
-			cond: &metav1.Condition{
-				Type:               v1alpha1.ElastAlertUnAvailableType,
-				Status:             v1alpha1.ElastAlertUnAvailableStatus,
-				LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-				ObservedGeneration: 1,
-				Reason:             v1alpha1.ElastAlertUnAvailableReason,
-				Message:            "Failed to apply ElastAlert my-esa resources.",
-			},
-			want: v1alpha1.Elastalert{
-				TypeMeta: metav1.TypeMeta{
-					Kind:       "Elastalert",
-					APIVersion: "v1",
-				},
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "esa1",
-					Name:      "my-esa",
-				},
-				Status: v1alpha1.ElastalertStatus{
-					Version: "v1.0",
-					Phase:   "FAILED",
-					Condictions: []metav1.Condition{
-						{
-							Type:               "Stopped",
-							Status:             "False",
-							ObservedGeneration: int64(1),
-							LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-							Reason:             "ElastAlertUnAvailable",
-							Message:            "Failed to apply ElastAlert my-esa resources.",
-						},
-					},
-				},
-			},
+			desc:        "test reconcile failed",
+			conditions:  []metav1.Condition{condition("Reconciled", metav1.ConditionFalse, "ReconcileFailed", 1)},
+			phase:       "FAILED",
+			ready:       metav1.ConditionFalse,
+			readyReason: "ReconcileFailed",
 		},
 		{
-			desc: "test to remove elasalert success status",
-			c: fake.NewClientBuilder().WithRuntimeObjects(
-				&v1alpha1.Elastalert{
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace: "esa1",
-						Name:      "my-esa",
-					},
-					Status: v1alpha1.ElastalertStatus{
-						Condictions: []metav1.Condition{
-							{
-								Type:               "Progressing",
-								Status:             "True",
-								ObservedGeneration: int64(1),
-								LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-								Reason:             "NewElastAlertAvailable",
-								Message:            "ElastAlert my-esa has successfully progressed.",
-							},
-						},
-					},
-				}).Build(),
-			cond: &metav1.Condition{
-				Type:               v1alpha1.ElastAlertUnAvailableType,
-				Status:             v1alpha1.ElastAlertUnAvailableStatus,
-				LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-				ObservedGeneration: 1,
-				Reason:             v1alpha1.ElastAlertUnAvailableReason,
-				Message:            "Failed to apply ElastAlert my-esa resources.",
-			},
-			want: v1alpha1.Elastalert{
-				TypeMeta: metav1.TypeMeta{
-					Kind:       "Elastalert",
-					APIVersion: "v1",
-				},
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "esa1",
-					Name:      "my-esa",
-				},
-				Status: v1alpha1.ElastalertStatus{
-					Version: "v1.0",
-					Phase:   "FAILED",
-					Condictions: []metav1.Condition{
-						{
-							Type:               "Stopped",
-							Status:             "False",
-							ObservedGeneration: int64(1),
-							LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-							Reason:             "ElastAlertUnAvailable",
-							Message:            "Failed to apply ElastAlert my-esa resources.",
-						},
-					},
-				},
-			},
+			desc:        "test reconcile failed with available replicas",
+			status:      v1alpha1.ElastalertStatus{ObservedGeneration: 1, Condictions: []metav1.Condition{available}},
+			conditions:  []metav1.Condition{condition("Reconciled", metav1.ConditionFalse, "ReconcileFailed", 1)},
+			phase:       "FAILED",
+			ready:       metav1.ConditionFalse,
+			readyReason: "ReconcileFailed",
 		},
 		{
-			desc: "test to update initializing status",
-			c: fake.NewClientBuilder().WithRuntimeObjects(
-				&v1alpha1.Elastalert{
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace:  "esa1",
-						Name:       "my-esa",
-						Generation: int64(1),
-					},
-					Status: v1alpha1.ElastalertStatus{
-						Version: "v1.0",
-						Phase:   "RUNNING",
-						Condictions: []metav1.Condition{
-							{
-								Type:               "Progressing",
-								Status:             "True",
-								ObservedGeneration: int64(1),
-								LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-								Reason:             "NewElastAlertAvailable",
-								Message:            "ElastAlert my-esa has successfully progressed.",
-							},
-						},
-					},
-				}).Build(),
-			cond: nil,
-			want: v1alpha1.Elastalert{
-				TypeMeta: metav1.TypeMeta{
-					Kind:       "Elastalert",
-					APIVersion: "v1",
-				},
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "esa1",
-					Name:      "my-esa",
-				},
-				Status: v1alpha1.ElastalertStatus{
-					Version: "v1.0",
-					Phase:   "RUNNING",
-					Condictions: []metav1.Condition{
-						{
-							Type:               "Progressing",
-							Status:             "True",
-							ObservedGeneration: int64(1),
-							LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-							Reason:             "NewElastAlertAvailable",
-							Message:            "ElastAlert my-esa has successfully progressed.",
-						},
-					},
-				},
-			},
+			desc:        "test reconciled waits for the health check",
+			status:      v1alpha1.ElastalertStatus{ObservedGeneration: 1},
+			conditions:  []metav1.Condition{reconciled},
+			phase:       "INITIALIZING",
+			ready:       metav1.ConditionFalse,
+			readyReason: "Initializing",
 		},
 		{
-			desc: "test to update initializing status with higher generation.",
-			c: fake.NewClientBuilder().WithRuntimeObjects(
-				&v1alpha1.Elastalert{
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace:  "esa1",
-						Name:       "my-esa",
-						Generation: int64(2),
-					},
-					Status: v1alpha1.ElastalertStatus{
-						Condictions: []metav1.Condition{
-							{
-								Type:               "Progressing",
-								Status:             "True",
-								ObservedGeneration: int64(1),
-								LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-								Reason:             "NewElastAlertAvailable",
-								Message:            "ElastAlert my-esa has successfully progressed.",
-							},
-						},
-					},
-				}).Build(),
-			cond: nil,
-			want: v1alpha1.Elastalert{
-				TypeMeta: metav1.TypeMeta{
-					Kind:       "Elastalert",
-					APIVersion: "v1",
-				},
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "esa1",
-					Name:      "my-esa",
-				},
-				Status: v1alpha1.ElastalertStatus{
-					Version: "v1.0",
-					Phase:   "INITIALIZING",
-					Condictions: []metav1.Condition{
-						{
-							Type:               "Progressing",
-							Status:             "True",
-							ObservedGeneration: int64(1),
-							LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-							Reason:             "NewElastAlertAvailable",
-							Message:            "ElastAlert my-esa has successfully progressed.",
-						},
-					},
-				},
+			desc:        "test reconciling",
+			status:      v1alpha1.ElastalertStatus{ObservedGeneration: 1, Condictions: []metav1.Condition{available}},
+			conditions:  []metav1.Condition{condition("Reconciled", metav1.ConditionUnknown, "Reconciling", 1)},
+			phase:       "INITIALIZING",
+			ready:       metav1.ConditionFalse,
+			readyReason: "Initializing",
+		},
+		{
+			desc:        "test running",
+			status:      v1alpha1.ElastalertStatus{ObservedGeneration: 1},
+			conditions:  []metav1.Condition{reconciled, available, condition("Degraded", metav1.ConditionFalse, "AsExpected", 1)},
+			phase:       "RUNNING",
+			ready:       metav1.ConditionTrue,
+			readyReason: "Running",
+		},
+		{
+			desc:        "test degraded",
+			status:      v1alpha1.ElastalertStatus{ObservedGeneration: 1},
+			conditions:  []metav1.Condition{reconciled, available, condition("Degraded", metav1.ConditionTrue, "HealthCheckFailing", 1)},
+			phase:       "DEGRADED",
+			ready:       metav1.ConditionTrue,
+			readyReason: "Running",
+		},
+		{
+			desc:        "test unavailable",
+			status:      v1alpha1.ElastalertStatus{ObservedGeneration: 1},
+			conditions:  []metav1.Condition{reconciled, condition("Available", metav1.ConditionFalse, "ReplicasUnavailable", 1)},
+			phase:       "FAILED",
+			ready:       metav1.ConditionFalse,
+			readyReason: "ReplicasUnavailable",
+		},
+		{
+			desc:        "test suspended",
+			status:      v1alpha1.ElastalertStatus{ObservedGeneration: 1},
+			conditions:  []metav1.Condition{reconciled, condition("Available", metav1.ConditionFalse, "SpecSuspended", 1)},
+			phase:       "SUSPENDED",
+			ready:       metav1.ConditionFalse,
+			readyReason: "SpecSuspended",
+		},
+		{
+			desc: "test upgrading",
+			status: v1alpha1.ElastalertStatus{
+				ObservedGeneration: 1,
+				Condictions:        []metav1.Condition{condition("UpgradeInProgress", metav1.ConditionTrue, "ImagesRollingOut", 1)},
 			},
+			conditions:  []metav1.Condition{reconciled, available},
+			phase:       "UPGRADING",
+			ready:       metav1.ConditionFalse,
+			readyReason: "ImagesRollingOut",
 		},
 		{
-			desc: "test to update initializing status with higher generation.",
-			c: fake.NewClientBuilder().WithRuntimeObjects(
-				&v1alpha1.Elastalert{
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace:  "esa1",
-						Name:       "my-esa",
-						Generation: int64(2),
-					},
-					Status: v1alpha1.ElastalertStatus{},
-				}).Build(),
-			cond: nil,
-			want: v1alpha1.Elastalert{
-				TypeMeta: metav1.TypeMeta{
-					Kind:       "Elastalert",
-					APIVersion: "v1",
-				},
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "esa1",
-					Name:      "my-esa",
-				},
-				Status: v1alpha1.ElastalertStatus{
-					Version: "v1.0",
-					Phase:   "INITIALIZING",
-				},
+			desc:        "test availability of a former generation",
+			status:      v1alpha1.ElastalertStatus{ObservedGeneration: 2},
+			conditions:  []metav1.Condition{condition("Reconciled", metav1.ConditionTrue, "ReconcileSucceeded", 2), condition("Available", metav1.ConditionFalse, "ReplicasUnavailable", 1)},
+			phase:       "INITIALIZING",
+			ready:       metav1.ConditionFalse,
+			readyReason: "Initializing",
+		},
+		{
+			desc: "test legacy conditions are removed",
+			status: v1alpha1.ElastalertStatus{
+				ObservedGeneration: 1,
+				Condictions:        []metav1.Condition{condition("Progressing", metav1.ConditionTrue, "NewElastAlertAvailable", 1)},
 			},
+			conditions:  []metav1.Condition{reconciled, available},
+			phase:       "RUNNING",
+			ready:       metav1.ConditionTrue,
+			readyReason: "Running",
 		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.desc, func(t *testing.T) {
-			// prepare
-			r := &ElastalertReconciler{
-				Client: tc.c,
-				Scheme: s,
+			defer monkey.Unpatch(podspec.GetUtcTime)
+			monkey.Patch(podspec.GetUtcTime, func() time.Time {
+				return time.Unix(0, 1233810057000000000)
+			})
+			generation := int64(1)
+			if tc.status.ObservedGeneration > generation {
+				generation = tc.status.ObservedGeneration
 			}
+			c := fake.NewClientBuilder().WithRuntimeObjects(
+				&v1alpha1.Elastalert{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace:  "esa1",
+						Name:       "my-esa",
+						Generation: generation,
+					},
+					Status: tc.status,
+				}).Build()
 			esa := v1alpha1.Elastalert{}
-			err := r.Client.Get(context.Background(), types.NamespacedName{
-				Namespace: "esa1",
-				Name:      "my-esa",
-			}, &esa)
+			require.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "esa1", Name: "my-esa"}, &esa))
 
-			err = ob.UpdateStatus(r.Client, context.Background(), &esa, tc.cond)
-			require.NoError(t, err)
-			assert.Equal(t, tc.want.Status, esa.Status)
+			require.NoError(t, ob.UpdateStatus(c, context.Background(), &esa, tc.conditions...))
+			have := v1alpha1.Elastalert{}
+			require.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "esa1", Name: "my-esa"}, &have))
+			assert.Equal(t, tc.phase, have.Status.Phase)
+			assert.Equal(t, "v1.0", have.Status.Version)
+			ready := meta.FindStatusCondition(have.Status.Condictions, "Ready")
+			require.NotNil(t, ready)
+			assert.Equal(t, tc.ready, ready.Status)
+			assert.Equal(t, tc.readyReason, ready.Reason)
+			assert.Nil(t, meta.FindStatusCondition(have.Status.Condictions, "Progressing"))
 		})
 	}
 }
 
 func TestNewCondition(t *testing.T) {
+	defer monkey.Unpatch(podspec.GetUtcTime)
+	monkey.Patch(podspec.GetUtcTime, func() time.Time {
+		return time.Unix(0, 1233810057000000000)
+	})
+	elastalert := &v1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  "esa1",
+			Name:       "my-esa",
+			Generation: int64(3),
+		},
+	}
 	testCases := []struct {
-		name       string
-		flag       string
-		elastalert v1alpha1.Elastalert
-		want       *metav1.Condition
+		name          string
+		conditionType string
+		status        metav1.ConditionStatus
+		reason        string
 	}{
-		{
-			name: "test failed condition",
-			flag: "failed",
-			elastalert: v1alpha1.Elastalert{
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace:  "esa1",
-					Name:       "my-esa",
-					Generation: int64(1),
-				},
-			},
-			want: &metav1.Condition{
-				Type:               "Stopped",
-				Status:             "False",
-				ObservedGeneration: int64(1),
-				LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-				Reason:             "ElastAlertUnAvailable",
-				Message:            "Failed to apply ElastAlert my-esa resources.",
-			},
-		},
-		{
-			name: "test success condition",
-			flag: "success",
-			elastalert: v1alpha1.Elastalert{
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace:  "esa1",
-					Name:       "my-esa",
-					Generation: int64(1),
-				},
-			},
-			want: &metav1.Condition{
-				Type:               "Progressing",
-				Status:             "True",
-				ObservedGeneration: int64(1),
-				LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-				Reason:             "NewElastAlertAvailable",
-				Message:            "ElastAlert my-esa has successfully progressed.",
-			},
-		},
-		{
-			name: "test starting condition",
-			flag: "starting",
-			elastalert: v1alpha1.Elastalert{
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace:  "esa1",
-					Name:       "my-esa",
-					Generation: int64(1),
-				},
-			},
-			want: nil,
-		},
+		{name: "test reconciled condition", conditionType: "Reconciled", status: metav1.ConditionTrue, reason: "ReconcileSucceeded"},
+		{name: "test available condition", conditionType: "Available", status: metav1.ConditionFalse, reason: "ReplicasUnavailable"},
+		{name: "test degraded condition", conditionType: "Degraded", status: metav1.ConditionTrue, reason: "HealthCheckFailing"},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			monkey.Patch(podspec.GetUtcTime, func() time.Time {
-				return time.Unix(0, 1233810057000000000)
-			})
-			have := ob.NewCondition(&tc.elastalert, tc.flag)
-			require.Equal(t, tc.want, have)
+			have := ob.NewCondition(elastalert, tc.conditionType, tc.status, tc.reason, "message.")
+			require.Equal(t, metav1.Condition{
+				Type:               tc.conditionType,
+				Status:             tc.status,
+				ObservedGeneration: int64(3),
+				LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
+				Reason:             tc.reason,
+				Message:            "message.",
+			}, have)
 		})
 	}
 }
@@ -1076,129 +1043,76 @@ func TestUpdateElastalertStatus(t *testing.T) {
 	s := scheme.Scheme
 	s.AddKnownTypes(corev1.SchemeGroupVersion, &v1alpha1.Elastalert{})
 	testCases := []struct {
-		desc string
-		flag string
-		c    client.Client
-		want v1alpha1.Elastalert
+		desc    string
+		flag    string
+		status  metav1.ConditionStatus
+		reason  string
+		message string
+		phase   string
+		err     bool
 	}{
 		{
-			desc: "test to update elasalert success status",
-			flag: "success",
-			c: fake.NewClientBuilder().WithRuntimeObjects(
-				&v1alpha1.Elastalert{
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace:  "esa1",
-						Name:       "my-esa",
-						Generation: int64(1),
-					},
-				}).Build(),
-			want: v1alpha1.Elastalert{
-				TypeMeta: metav1.TypeMeta{
-					Kind:       "Elastalert",
-					APIVersion: "v1",
-				},
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "esa1",
-					Name:      "my-esa",
-				},
-				Status: v1alpha1.ElastalertStatus{
-					Version: "v1.0",
-					Phase:   "RUNNING",
-					Condictions: []metav1.Condition{
-						{
-							Type:               "Progressing",
-							Status:             "True",
-							ObservedGeneration: int64(1),
-							LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-							Reason:             "NewElastAlertAvailable",
-							Message:            "ElastAlert my-esa has successfully progressed.",
-						},
-					},
-				},
-			},
+			desc:    "test to update elasalert success status",
+			flag:    "success",
+			status:  metav1.ConditionTrue,
+			reason:  "ReconcileSucceeded",
+			message: "ElastAlert my-esa resources are applied.",
+			phase:   "INITIALIZING",
 		},
 		{
-			desc: "test to update elasalert failed status",
-			flag: "failed",
-			c: fake.NewClientBuilder().WithRuntimeObjects(
-				&v1alpha1.Elastalert{
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace:  "esa1",
-						Name:       "my-esa",
-						Generation: int64(1),
-					},
-				}).Build(),
-			want: v1alpha1.Elastalert{
-				TypeMeta: metav1.TypeMeta{
-					Kind:       "Elastalert",
-					APIVersion: "v1",
-				},
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "esa1",
-					Name:      "my-esa",
-				},
-				Status: v1alpha1.ElastalertStatus{
-					Version: "v1.0",
-					Phase:   "FAILED",
-					Condictions: []metav1.Condition{
-						{
-							Type:               "Stopped",
-							Status:             "False",
-							ObservedGeneration: int64(1),
-							LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-							Reason:             "ElastAlertUnAvailable",
-							Message:            "Failed to apply ElastAlert my-esa resources.",
-						},
-					},
-				},
-			},
+			desc:    "test to update elasalert failed status",
+			flag:    "failed",
+			status:  metav1.ConditionFalse,
+			reason:  "ReconcileFailed",
+			message: "Failed to apply ElastAlert my-esa resources.",
+			phase:   "FAILED",
 		},
 		{
-			desc: "test to update elasalert initializing status",
-			flag: "starting",
-			c: fake.NewClientBuilder().WithRuntimeObjects(
+			desc:    "test to update elasalert initializing status",
+			flag:    "starting",
+			status:  metav1.ConditionUnknown,
+			reason:  "Reconciling",
+			message: "Applying ElastAlert my-esa resources.",
+			phase:   "INITIALIZING",
+		},
+		{
+			desc: "test to update elasalert unknown status",
+			flag: "unknown",
+			err:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer monkey.Unpatch(podspec.GetUtcTime)
+			monkey.Patch(podspec.GetUtcTime, func() time.Time {
+				return time.Unix(0, 1233810057000000000)
+			})
+			c := fake.NewClientBuilder().WithRuntimeObjects(
 				&v1alpha1.Elastalert{
 					ObjectMeta: metav1.ObjectMeta{
 						Namespace:  "esa1",
 						Name:       "my-esa",
 						Generation: int64(1),
 					},
-				}).Build(),
-			want: v1alpha1.Elastalert{
-				TypeMeta: metav1.TypeMeta{
-					Kind:       "Elastalert",
-					APIVersion: "v1",
-				},
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "esa1",
-					Name:      "my-esa",
-				},
-				Status: v1alpha1.ElastalertStatus{
-					Version: "v1.0",
-					Phase:   "INITIALIZING",
-				},
-			},
-		},
-	}
-	for _, tc := range testCases {
-		t.Run(tc.desc, func(t *testing.T) {
-			// prepare
-			r := &ElastalertReconciler{
-				Client: tc.c,
-				Scheme: s,
-			}
+				}).Build()
 			esa := v1alpha1.Elastalert{}
-			err := r.Client.Get(context.Background(), types.NamespacedName{
-				Namespace: "esa1",
-				Name:      "my-esa",
-			}, &esa)
+			require.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "esa1", Name: "my-esa"}, &esa))
 
-			monkey.Patch(podspec.GetUtcTime, func() time.Time {
-				return time.Unix(0, 1233810057000000000)
-			})
-			err = ob.UpdateElastalertStatus(r.Client, context.Background(), &esa, tc.flag)
+			err := ob.UpdateElastalertStatus(c, context.Background(), &esa, tc.flag)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
 			require.NoError(t, err)
-			assert.Equal(t, tc.want.Status, esa.Status)
+			assert.Equal(t, tc.phase, esa.Status.Phase)
+			assert.Equal(t, &metav1.Condition{
+				Type:               "Reconciled",
+				Status:             tc.status,
+				ObservedGeneration: int64(1),
+				LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
+				Reason:             tc.reason,
+				Message:            tc.message,
+			}, meta.FindStatusCondition(esa.Status.Condictions, "Reconciled"))
 		})
 	}
 }
@@ -1258,11 +1172,19 @@ func TestReconcileApplyDeploymentFailedWithUpdateStatus(t *testing.T) {
 					Phase:   "FAILED",
 					Condictions: []metav1.Condition{
 						{
-							Type:               "Stopped",
+							Type:               "Reconciled",
 							Status:             "False",
 							ObservedGeneration: int64(0),
 							LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-							Reason:             "ElastAlertUnAvailable",
+							Reason:             "ReconcileFailed",
+							Message:            "Failed to apply ElastAlert my-esa resources.",
+						},
+						{
+							Type:               "Ready",
+							Status:             "False",
+							ObservedGeneration: int64(0),
+							LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
+							Reason:             "ReconcileFailed",
 							Message:            "Failed to apply ElastAlert my-esa resources.",
 						},
 					},
@@ -1336,11 +1258,19 @@ func TestReconcileApplySecretFailedWithUpdateStatus(t *testing.T) {
 					Phase:   "FAILED",
 					Condictions: []metav1.Condition{
 						{
-							Type:               "Stopped",
+							Type:               "Reconciled",
+							Status:             "False",
+							ObservedGeneration: int64(0),
+							LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
+							Reason:             "ReconcileFailed",
+							Message:            "Failed to apply ElastAlert my-esa resources.",
+						},
+						{
+							Type:               "Ready",
 							Status:             "False",
 							ObservedGeneration: int64(0),
 							LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-							Reason:             "ElastAlertUnAvailable",
+							Reason:             "ReconcileFailed",
 							Message:            "Failed to apply ElastAlert my-esa resources.",
 						},
 					},
@@ -1413,11 +1343,19 @@ func TestReconcileApplyConfigMapsFailedWithUpdateStatus(t *testing.T) {
 					Phase:   "FAILED",
 					Condictions: []metav1.Condition{
 						{
-							Type:               "Stopped",
+							Type:               "Reconciled",
+							Status:             "False",
+							ObservedGeneration: int64(0),
+							LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
+							Reason:             "ReconcileFailed",
+							Message:            "Failed to apply ElastAlert my-esa resources.",
+						},
+						{
+							Type:               "Ready",
 							Status:             "False",
 							ObservedGeneration: int64(0),
 							LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-							Reason:             "ElastAlertUnAvailable",
+							Reason:             "ReconcileFailed",
 							Message:            "Failed to apply ElastAlert my-esa resources.",
 						},
 					},
@@ -1490,11 +1428,19 @@ func TestReconcileApplyConfigMapsPatchFailedWithUpdateStatus(t *testing.T) {
 					Phase:   "FAILED",
 					Condictions: []metav1.Condition{
 						{
-							Type:               "Stopped",
+							Type:               "Reconciled",
+							Status:             "False",
+							ObservedGeneration: int64(0),
+							LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
+							Reason:             "ReconcileFailed",
+							Message:            "Failed to apply ElastAlert my-esa resources.",
+						},
+						{
+							Type:               "Ready",
 							Status:             "False",
 							ObservedGeneration: int64(0),
 							LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
-							Reason:             "ElastAlertUnAvailable",
+							Reason:             "ReconcileFailed",
 							Message:            "Failed to apply ElastAlert my-esa resources.",
 						},
 					},
@@ -1579,7 +1525,7 @@ func TestReconcileRuntimeFailedWithUpdateStatus(t *testing.T) {
 			monkey.Patch(podspec.GenerateCertSecret, func(Scheme *runtime.Scheme, e *v1alpha1.Elastalert) (*corev1.Secret, error) {
 				return nil, errors.New("test update failed")
 			})
-			monkey.Patch(ob.UpdateStatus, func(c client.Client, ctx context.Context, e *v1alpha1.Elastalert, condition *metav1.Condition) error {
+			monkey.Patch(ob.UpdateStatus, func(c client.Client, ctx context.Context, e *v1alpha1.Elastalert, conditions ...metav1.Condition) error {
 				return errors.New("test update failed")
 			})
 			_, err := r.Reconcile(context.Background(), req)
@@ -1653,3 +1599,132 @@ func TestApplyDeploymentError(t *testing.T) {
 	_, err = applyDeployment(r.Client, r.Scheme, context.Background(), &ea)
 	assert.Error(t, err)
 }
+
+func TestReconcilePaused(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &v1alpha1.Elastalert{})
+	nsn := types.NamespacedName{Name: "my-esa", Namespace: "esa1"}
+	c := fake.NewClientBuilder().WithRuntimeObjects(
+		&v1alpha1.Elastalert{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "esa1",
+				Name:        "my-esa",
+				Generation:  int64(1),
+				Annotations: map[string]string{"es.noah.domain/paused": "true"},
+			},
+			Spec: v1alpha1.ElastalertSpec{
+				ConfigSetting: v1alpha1.NewFreeForm(map[string]interface{}{
+					"config": "test",
+				}),
+				Rule: []v1alpha1.FreeForm{
+					v1alpha1.NewFreeForm(map[string]interface{}{
+						"name": "test-elastalert", "type": "any",
+					}),
+				},
+			},
+			Status: v1alpha1.ElastalertStatus{
+				Phase:              "RUNNING",
+				ObservedGeneration: int64(1),
+				Condictions: []metav1.Condition{
+					{
+						Type:               "Available",
+						Status:             "True",
+						ObservedGeneration: int64(1),
+						LastTransitionTime: metav1.NewTime(time.Unix(0, 1233810057000000000)),
+						Reason:             "ReplicasAvailable",
+						Message:            "1 of 1 replicas of Deployment esa1/my-esa are available.",
+					},
+				},
+			},
+		},
+	).Build()
+	r := &ElastalertReconciler{
+		Client:   c,
+		Scheme:   s,
+		Recorder: record.NewBroadcaster().NewRecorder(s, corev1.EventSource{}),
+	}
+
+	_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: nsn})
+	require.NoError(t, err)
+	have := &v1alpha1.Elastalert{}
+	require.NoError(t, c.Get(context.Background(), nsn, have))
+	require.Equal(t, "RUNNING", have.Status.Phase)
+	require.Equal(t, "True", string(meta.FindStatusCondition(have.Status.Condictions, "Paused").Status))
+	require.True(t, k8serrors.IsNotFound(c.Get(context.Background(), nsn, &appsv1.Deployment{})))
+
+	// resuming applies the resources again although the generation is unchanged.
+	have.Annotations = nil
+	require.NoError(t, c.Update(context.Background(), have))
+	_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: nsn})
+	require.NoError(t, err)
+	require.NoError(t, c.Get(context.Background(), nsn, have))
+	require.Nil(t, meta.FindStatusCondition(have.Status.Condictions, "Paused"))
+	require.NoError(t, c.Get(context.Background(), nsn, &appsv1.Deployment{}))
+}
+
+func TestReconcileChecksHealth(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &v1alpha1.Elastalert{})
+	nsn := types.NamespacedName{Name: "my-esa", Namespace: "esa1"}
+	c := fake.NewClientBuilder().WithRuntimeObjects(
+		&v1alpha1.Elastalert{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:  "esa1",
+				Name:       "my-esa",
+				Generation: int64(1),
+			},
+			Spec: v1alpha1.ElastalertSpec{
+				ConfigSetting: v1alpha1.NewFreeForm(map[string]interface{}{
+					"config": "test",
+				}),
+				Rule: []v1alpha1.FreeForm{
+					v1alpha1.NewFreeForm(map[string]interface{}{
+						"name": "test-elastalert", "type": "any",
+					}),
+				},
+			},
+		},
+	).Build()
+	r := &ElastalertReconciler{
+		Client:   c,
+		Scheme:   s,
+		Recorder: record.NewBroadcaster().NewRecorder(s, corev1.EventSource{}),
+	}
+
+	// the pass applying the resources leaves the health to the rollout.
+	result, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: nsn})
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), result.RequeueAfter)
+	have := &v1alpha1.Elastalert{}
+	require.NoError(t, c.Get(context.Background(), nsn, have))
+	require.Equal(t, "INITIALIZING", have.Status.Phase)
+	require.Equal(t, int64(1), have.Status.ObservedGeneration)
+
+	deploy := &appsv1.Deployment{}
+	require.NoError(t, c.Get(context.Background(), nsn, deploy))
+	deploy.Status.AvailableReplicas = *deploy.Spec.Replicas
+	require.NoError(t, c.Status().Update(context.Background(), deploy))
+	require.NoError(t, c.Get(context.Background(), nsn, deploy))
+	restartedAt := deploy.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"]
+
+	// the change of the deployment brings the health check, without applying the resources again.
+	result, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: nsn})
+	require.NoError(t, err)
+	require.Equal(t, v1alpha1.ElastAlertObserveInterval, result.RequeueAfter)
+	require.NoError(t, c.Get(context.Background(), nsn, have))
+	require.Equal(t, "RUNNING", have.Status.Phase)
+	require.NoError(t, c.Get(context.Background(), nsn, deploy))
+	require.Equal(t, restartedAt, deploy.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"])
+}
+
+func TestElastalertForPod(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "esa1",
+			Name:      "my-esa-5d7b9c-x2k4p",
+			Labels:    map[string]string{podspec.ElastalertInstanceLabel: "my-esa"},
+		},
+	}
+	require.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "esa1", Name: "my-esa"}}}, elastalertForPod(pod))
+	require.Nil(t, elastalertForPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "other"}}))
+}
@@ -0,0 +1,469 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRequestTimeout bounds a single request to elasticsearch.
+	DefaultRequestTimeout = 30 * time.Second
+	// idleConnTimeout closes the connections left idle between two syncs.
+	idleConnTimeout = 90 * time.Second
+)
+
+// Reasons of a PreflightError, telling which step of the preflight failed.
+const (
+	PreflightInvalidConfig        = "InvalidConfig"
+	PreflightConnectionFailed     = "ConnectionFailed"
+	PreflightTLSHandshakeFailed   = "TLSHandshakeFailed"
+	PreflightAuthenticationFailed = "AuthenticationFailed"
+	PreflightClusterHealthFailed  = "ClusterHealthFailed"
+)
+
+// Client is the part of the elasticsearch API the operator uses.
+type Client interface {
+	// DeleteByQuery deletes the documents of the indices matching the query, missing indices are ignored.
+	// It returns the number of deleted documents.
+	DeleteByQuery(ctx context.Context, indices []string, query map[string]interface{}) (int64, error)
+	// DeleteIndices deletes the indices, missing indices are ignored.
+	DeleteIndices(ctx context.Context, indices []string) error
+	// IndexMeta returns the _meta of the mapping of the index, and false if the index does not exist.
+	IndexMeta(ctx context.Context, index string) (map[string]interface{}, bool, error)
+	// CreateIndex creates the index with the given body, an index created meanwhile is not an error.
+	CreateIndex(ctx context.Context, index string, body map[string]interface{}) error
+	// IndexStats returns the document count and the size of the indices, missing indices are left out.
+	IndexStats(ctx context.Context, indices []string) (map[string]IndexStats, error)
+	// Preflight connects over TCP, completes the TLS handshake with the CA of the config and requests the cluster
+	// health with the credentials. A failure is a *PreflightError.
+	Preflight(ctx context.Context) (*ClusterHealth, error)
+	// FieldMappings returns the types of the fields of the indices matching the pattern, by dotted field name,
+	// multi-fields included. A field mapped differently across the indices has several types.
+	FieldMappings(ctx context.Context, index string) (map[string][]string, error)
+	// LastRuns returns the time of the latest run of each of the rules recorded in the status index, rules which
+	// never ran are left out.
+	LastRuns(ctx context.Context, index string, rules []string) (map[string]time.Time, error)
+}
+
+// ClusterHealth is the health of an elasticsearch cluster.
+type ClusterHealth struct {
+	ClusterName string `json:"cluster_name"`
+	Status      string `json:"status"`
+}
+
+// PreflightError is a failed preflight, its Reason tells which step failed.
+type PreflightError struct {
+	Reason string
+	Err    error
+}
+
+func (e *PreflightError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PreflightError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError is an error response of elasticsearch.
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %s: %s", e.Method, e.Path, e.Status, e.Body)
+}
+
+// IndexStats is the size of an index.
+type IndexStats struct {
+	Documents int64
+	SizeBytes int64
+}
+
+// ClientFactory builds the Client of the elasticsearch cluster an Elastalert writes back to.
+type ClientFactory func(e *esv1alpha1.Elastalert) (Client, error)
+
+// Config is how to connect to elasticsearch.
+type Config struct {
+	URL      string
+	Username string
+	Password string
+	// CACert is the PEM encoded CA certificate of the cluster, the system roots are used when empty.
+	CACert             string
+	InsecureSkipVerify bool
+}
+
+// ConfigFor returns how to connect to the elasticsearch cluster of the config.yaml of the Elastalert.
+func ConfigFor(e *esv1alpha1.Elastalert) (*Config, error) {
+	config, err := e.Spec.ConfigSetting.GetMap()
+	if err != nil {
+		return nil, err
+	}
+	host, ok := config["es_host"].(string)
+	if !ok || host == "" {
+		return nil, errors.New("es_host is not set in config")
+	}
+	port := fmt.Sprint(config["es_port"])
+	if config["es_port"] == nil {
+		port = "9200"
+	}
+	scheme := "http"
+	if useSSL, _ := config["use_ssl"].(bool); useSSL {
+		scheme = "https"
+	}
+	prefix, _ := config["es_url_prefix"].(string)
+	u := url.URL{Scheme: scheme, Host: net.JoinHostPort(strings.Trim(host, "[]"), port), Path: "/" + strings.Trim(prefix, "/")}
+	c := &Config{
+		URL:    strings.TrimSuffix(u.String(), "/"),
+		CACert: e.Spec.Cert,
+	}
+	c.Username, _ = config["es_username"].(string)
+	c.Password, _ = config["es_password"].(string)
+	if verify, ok := config["verify_certs"].(bool); ok && !verify {
+		c.InsecureSkipVerify = true
+	}
+	return c, nil
+}
+
+// NewClientFor is the default ClientFactory, it connects to the elasticsearch cluster of the config.yaml.
+func NewClientFor(e *esv1alpha1.Elastalert) (Client, error) {
+	config, err := ConfigFor(e)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(*config)
+}
+
+// transports are shared by the clients with the same TLS settings, so that a client built per reconcile reuses the
+// idle connections rather than leaving a transport behind.
+var transports sync.Map
+
+// transportKey is the TLS settings of a transport.
+type transportKey struct {
+	caCert             string
+	insecureSkipVerify bool
+}
+
+// NewClient returns a Client talking to elasticsearch over its REST API.
+func NewClient(config Config) (Client, error) {
+	transport, err := transportFor(config)
+	if err != nil {
+		return nil, err
+	}
+	return &restClient{
+		config:    config,
+		tlsConfig: transport.TLSClientConfig,
+		http: &http.Client{
+			Timeout:   DefaultRequestTimeout,
+			Transport: transport,
+		},
+	}, nil
+}
+
+// transportFor returns the transport of the TLS settings of the config, created on first use.
+func transportFor(config Config) (*http.Transport, error) {
+	key := transportKey{caCert: config.CACert, insecureSkipVerify: config.InsecureSkipVerify}
+	if transport, ok := transports.Load(key); ok {
+		return transport.(*http.Transport), nil
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify}
+	if config.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(config.CACert)) {
+			return nil, errors.New("failed to parse elasticsearch CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport, _ := transports.LoadOrStore(key, &http.Transport{
+		TLSClientConfig: tlsConfig,
+		IdleConnTimeout: idleConnTimeout,
+	})
+	return transport.(*http.Transport), nil
+}
+
+type restClient struct {
+	config    Config
+	tlsConfig *tls.Config
+	http      *http.Client
+}
+
+func (c *restClient) DeleteByQuery(ctx context.Context, indices []string, query map[string]interface{}) (int64, error) {
+	body := map[string]interface{}{"query": query}
+	result := struct {
+		Deleted  int64         `json:"deleted"`
+		Failures []interface{} `json:"failures"`
+	}{}
+	path := "/" + strings.Join(indices, ",") + "/_delete_by_query?ignore_unavailable=true&conflicts=proceed&refresh=true"
+	if _, err := c.do(ctx, http.MethodPost, path, body, &result); err != nil {
+		return 0, err
+	}
+	if len(result.Failures) != 0 {
+		return result.Deleted, fmt.Errorf("delete by query failed for %d documents: %v", len(result.Failures), result.Failures[0])
+	}
+	return result.Deleted, nil
+}
+
+func (c *restClient) DeleteIndices(ctx context.Context, indices []string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/"+strings.Join(indices, ",")+"?ignore_unavailable=true", nil, nil)
+	return err
+}
+
+func (c *restClient) IndexMeta(ctx context.Context, index string) (map[string]interface{}, bool, error) {
+	result := map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}{}
+	found, err := c.do(ctx, http.MethodGet, "/"+index+"/_mapping", nil, &result)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	for _, mapping := range result {
+		if meta, ok := mapping.Mappings["_meta"].(map[string]interface{}); ok {
+			return meta, true, nil
+		}
+		// mappings of elasticsearch 6 are nested under the document type.
+		for _, typed := range mapping.Mappings {
+			if t, ok := typed.(map[string]interface{}); ok {
+				if meta, ok := t["_meta"].(map[string]interface{}); ok {
+					return meta, true, nil
+				}
+			}
+		}
+	}
+	return nil, true, nil
+}
+
+func (c *restClient) CreateIndex(ctx context.Context, index string, body map[string]interface{}) error {
+	_, err := c.do(ctx, http.MethodPut, "/"+index, body, nil)
+	if err != nil && strings.Contains(err.Error(), "resource_already_exists_exception") {
+		return nil
+	}
+	return err
+}
+
+func (c *restClient) IndexStats(ctx context.Context, indices []string) (map[string]IndexStats, error) {
+	result := struct {
+		Indices map[string]struct {
+			Primaries struct {
+				Docs struct {
+					Count int64 `json:"count"`
+				} `json:"docs"`
+				Store struct {
+					SizeInBytes int64 `json:"size_in_bytes"`
+				} `json:"store"`
+			} `json:"primaries"`
+		} `json:"indices"`
+	}{}
+	stats := map[string]IndexStats{}
+	found, err := c.do(ctx, http.MethodGet, "/"+strings.Join(indices, ",")+"/_stats/docs,store?ignore_unavailable=true", nil, &result)
+	if err != nil || !found {
+		return stats, err
+	}
+	for name, index := range result.Indices {
+		stats[name] = IndexStats{
+			Documents: index.Primaries.Docs.Count,
+			SizeBytes: index.Primaries.Store.SizeInBytes,
+		}
+	}
+	return stats, nil
+}
+
+func (c *restClient) Preflight(ctx context.Context) (*ClusterHealth, error) {
+	u, err := url.Parse(c.config.URL)
+	if err != nil {
+		return nil, &PreflightError{Reason: PreflightInvalidConfig, Err: err}
+	}
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, &PreflightError{Reason: PreflightConnectionFailed, Err: err}
+	}
+	defer conn.Close()
+	if u.Scheme == "https" {
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+		tlsConfig := c.tlsConfig.Clone()
+		tlsConfig.ServerName = u.Hostname()
+		if err = tls.Client(conn, tlsConfig).Handshake(); err != nil {
+			return nil, &PreflightError{Reason: PreflightTLSHandshakeFailed, Err: err}
+		}
+	}
+	health := &ClusterHealth{}
+	found, err := c.do(ctx, http.MethodGet, "/_cluster/health", nil, health)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden) {
+		return nil, &PreflightError{Reason: PreflightAuthenticationFailed, Err: err}
+	}
+	if err == nil && !found {
+		// a wrong es_url_prefix, or not elasticsearch at all.
+		err = fmt.Errorf("GET %s/_cluster/health: 404 Not Found", c.config.URL)
+	}
+	if err != nil {
+		return nil, &PreflightError{Reason: PreflightClusterHealthFailed, Err: err}
+	}
+	return health, nil
+}
+
+func (c *restClient) FieldMappings(ctx context.Context, index string) (map[string][]string, error) {
+	result := map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}{}
+	fields := map[string][]string{}
+	found, err := c.do(ctx, http.MethodGet, "/"+index+"/_mapping?ignore_unavailable=true&allow_no_indices=true", nil, &result)
+	if err != nil || !found {
+		return fields, err
+	}
+	for _, mapping := range result {
+		if _, ok := mapping.Mappings["properties"]; ok {
+			collectFields(fields, "", mapping.Mappings)
+			continue
+		}
+		// mappings of elasticsearch 6 are nested under the document type.
+		for _, typed := range mapping.Mappings {
+			if t, ok := typed.(map[string]interface{}); ok {
+				collectFields(fields, "", t)
+			}
+		}
+	}
+	for _, types := range fields {
+		sort.Strings(types)
+	}
+	return fields, nil
+}
+
+func (c *restClient) LastRuns(ctx context.Context, index string, rules []string) (map[string]time.Time, error) {
+	if len(rules) == 0 {
+		// a terms aggregation needs a size of 1 at least.
+		return map[string]time.Time{}, nil
+	}
+	body := map[string]interface{}{
+		"size":  0,
+		"query": map[string]interface{}{"terms": map[string]interface{}{"rule_name": rules}},
+		"aggs": map[string]interface{}{
+			"rules": map[string]interface{}{
+				"terms": map[string]interface{}{"field": "rule_name", "size": len(rules)},
+				"aggs": map[string]interface{}{
+					"last_run": map[string]interface{}{"max": map[string]interface{}{"field": "@timestamp"}},
+				},
+			},
+		},
+	}
+	result := struct {
+		Aggregations struct {
+			Rules struct {
+				Buckets []struct {
+					Key     string `json:"key"`
+					LastRun struct {
+						Value *float64 `json:"value"`
+					} `json:"last_run"`
+				} `json:"buckets"`
+			} `json:"rules"`
+		} `json:"aggregations"`
+	}{}
+	runs := map[string]time.Time{}
+	found, err := c.do(ctx, http.MethodPost, "/"+index+"/_search?ignore_unavailable=true&allow_no_indices=true", body, &result)
+	if err != nil || !found {
+		return runs, err
+	}
+	for _, bucket := range result.Aggregations.Rules.Buckets {
+		if bucket.LastRun.Value != nil {
+			// dates aggregate to epoch milliseconds.
+			runs[bucket.Key] = time.Unix(0, int64(*bucket.LastRun.Value)*int64(time.Millisecond)).UTC()
+		}
+	}
+	return runs, nil
+}
+
+// collectFields adds the types of the properties of a mapping, of their multi-fields and of their sub-properties.
+func collectFields(fields map[string][]string, prefix string, mapping map[string]interface{}) {
+	properties, _ := mapping["properties"].(map[string]interface{})
+	for name, v := range properties {
+		property, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := prefix + name
+		t, _ := property["type"].(string)
+		if t == "" {
+			t = "object"
+		}
+		addFieldType(fields, path, t)
+		multiFields, _ := property["fields"].(map[string]interface{})
+		for sub, v := range multiFields {
+			if multiField, ok := v.(map[string]interface{}); ok {
+				t, _ := multiField["type"].(string)
+				addFieldType(fields, path+"."+sub, t)
+			}
+		}
+		collectFields(fields, path+".", property)
+	}
+}
+
+func addFieldType(fields map[string][]string, field, t string) {
+	for _, known := range fields[field] {
+		if known == t {
+			return
+		}
+	}
+	fields[field] = append(fields[field], t)
+}
+
+// do sends a request and decodes the response into out, it returns false if elasticsearch answers 404.
+func (c *restClient) do(ctx context.Context, method, path string, in, out interface{}) (bool, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return false, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.config.URL+path, body)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.config.Username != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return false, &StatusError{Method: method, Path: path, StatusCode: resp.StatusCode, Status: resp.Status, Body: data}
+	}
+	if out != nil {
+		if err = json.Unmarshal(data, out); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,350 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/stretchr/testify/require"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConfigFor(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  map[string]interface{}
+		cert    string
+		want    *Config
+		wantErr bool
+	}{
+		{
+			name:   "test plain http",
+			config: map[string]interface{}{"es_host": "es.local", "es_port": 9200},
+			want:   &Config{URL: "http://es.local:9200"},
+		},
+		{
+			name: "test tls with credentials and prefix",
+			config: map[string]interface{}{
+				"es_host":       "es.local",
+				"es_port":       9243,
+				"use_ssl":       true,
+				"verify_certs":  false,
+				"es_username":   "elastic",
+				"es_password":   "changeme",
+				"es_url_prefix": "/es/",
+			},
+			cert: "pem",
+			want: &Config{
+				URL:                "https://es.local:9243/es",
+				Username:           "elastic",
+				Password:           "changeme",
+				CACert:             "pem",
+				InsecureSkipVerify: true,
+			},
+		},
+		{
+			name:   "test ipv6 host",
+			config: map[string]interface{}{"es_host": "fd00::1", "es_port": 9200},
+			want:   &Config{URL: "http://[fd00::1]:9200"},
+		},
+		{
+			name:   "test bracketed ipv6 host",
+			config: map[string]interface{}{"es_host": "[fd00::1]"},
+			want:   &Config{URL: "http://[fd00::1]:9200"},
+		},
+		{
+			name:   "test default port",
+			config: map[string]interface{}{"es_host": "es.local"},
+			want:   &Config{URL: "http://es.local:9200"},
+		},
+		{
+			name:    "test missing host",
+			config:  map[string]interface{}{"es_port": 9200},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &esv1alpha1.Elastalert{
+				Spec: esv1alpha1.ElastalertSpec{
+					ConfigSetting: esv1alpha1.NewFreeForm(tc.config),
+					Cert:          tc.cert,
+				},
+			}
+			have, err := ConfigFor(e)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, have)
+		})
+	}
+}
+
+func TestRestClient(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		user, password, _ := r.BasicAuth()
+		require.Equal(t, "elastic", user)
+		require.Equal(t, "changeme", password)
+		switch r.URL.Path {
+		case "/elastalert_status,elastalert_status_silence/_delete_by_query":
+			body, _ := ioutil.ReadAll(r.Body)
+			query := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(body, &query))
+			require.Equal(t, map[string]interface{}{"query": map[string]interface{}{"match_all": map[string]interface{}{}}}, query)
+			w.Write([]byte(`{"deleted": 3, "failures": []}`))
+		case "/elastalert_status/_mapping":
+			w.Write([]byte(`{"elastalert_status": {"mappings": {"_meta": {"es.noah.domain/elastalert": "esa1/my-esa"}}}}`))
+		case "/elastalert_es6/_mapping":
+			w.Write([]byte(`{"elastalert_es6": {"mappings": {"_doc": {"_meta": {"es.noah.domain/elastalert": "esa1/my-esa"}}}}}`))
+		case "/elastalert_status,elastalert_status_silence":
+			w.Write([]byte(`{"acknowledged": true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c, err := NewClient(Config{URL: server.URL, Username: "elastic", Password: "changeme"})
+	require.NoError(t, err)
+	ctx := context.Background()
+	indices := []string{"elastalert_status", "elastalert_status_silence"}
+
+	deleted, err := c.DeleteByQuery(ctx, indices, map[string]interface{}{"match_all": map[string]interface{}{}})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), deleted)
+
+	meta, found, err := c.IndexMeta(ctx, "elastalert_status")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, map[string]interface{}{"es.noah.domain/elastalert": "esa1/my-esa"}, meta)
+	meta, found, err = c.IndexMeta(ctx, "elastalert_es6")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, map[string]interface{}{"es.noah.domain/elastalert": "esa1/my-esa"}, meta)
+	_, found, err = c.IndexMeta(ctx, "missing")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	require.NoError(t, c.DeleteIndices(ctx, indices))
+	require.Equal(t, []string{
+		"POST /elastalert_status,elastalert_status_silence/_delete_by_query?ignore_unavailable=true&conflicts=proceed&refresh=true",
+		"GET /elastalert_status/_mapping",
+		"GET /elastalert_es6/_mapping",
+		"GET /missing/_mapping",
+		"DELETE /elastalert_status,elastalert_status_silence?ignore_unavailable=true",
+	}, requests)
+}
+
+func TestRestClientIndexLifecycle(t *testing.T) {
+	var requests []string
+	bodies := map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		if data, _ := ioutil.ReadAll(r.Body); len(data) != 0 {
+			body := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(data, &body))
+			bodies[r.URL.Path] = body
+		}
+		switch r.URL.Path {
+		case "/elastalert_status":
+			w.Write([]byte(`{"acknowledged": true}`))
+		case "/elastalert_status_silence":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"type": "resource_already_exists_exception"}}`))
+		case "/elastalert_status,elastalert_status_silence/_stats/docs,store":
+			w.Write([]byte(`{"indices": {"elastalert_status": {"primaries": {"docs": {"count": 3}, "store": {"size_in_bytes": 2048}}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c, err := NewClient(Config{URL: server.URL})
+	require.NoError(t, err)
+	ctx := context.Background()
+	indices := []string{"elastalert_status", "elastalert_status_silence"}
+
+	require.NoError(t, c.CreateIndex(ctx, "elastalert_status", map[string]interface{}{"mappings": map[string]interface{}{}}))
+	require.NoError(t, c.CreateIndex(ctx, "elastalert_status_silence", map[string]interface{}{"mappings": map[string]interface{}{}}))
+	stats, err := c.IndexStats(ctx, indices)
+	require.NoError(t, err)
+	require.Equal(t, map[string]IndexStats{"elastalert_status": {Documents: 3, SizeBytes: 2048}}, stats)
+	require.Equal(t, []string{
+		"PUT /elastalert_status",
+		"PUT /elastalert_status_silence",
+		"GET /elastalert_status,elastalert_status_silence/_stats/docs,store?ignore_unavailable=true",
+	}, requests)
+	require.Equal(t, map[string]interface{}{"mappings": map[string]interface{}{}}, bodies["/elastalert_status"])
+}
+
+func TestRestClientPreflight(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "elastic" || password != "changeme" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/_cluster/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"cluster_name": "es", "status": "yellow"}`))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	tlsServer := httptest.NewTLSServer(handler)
+	defer tlsServer.Close()
+	ca := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw}))
+	closed := httptest.NewServer(handler)
+	closed.Close()
+
+	testCases := []struct {
+		name       string
+		config     Config
+		wantReason string
+	}{
+		{
+			name:   "test reachable",
+			config: Config{URL: server.URL, Username: "elastic", Password: "changeme"},
+		},
+		{
+			name:   "test reachable over tls",
+			config: Config{URL: tlsServer.URL, Username: "elastic", Password: "changeme", CACert: ca},
+		},
+		{
+			name:       "test connection refused",
+			config:     Config{URL: closed.URL, Username: "elastic", Password: "changeme"},
+			wantReason: PreflightConnectionFailed,
+		},
+		{
+			name:       "test unknown certificate authority",
+			config:     Config{URL: tlsServer.URL, Username: "elastic", Password: "changeme"},
+			wantReason: PreflightTLSHandshakeFailed,
+		},
+		{
+			name:       "test bad credentials",
+			config:     Config{URL: server.URL, Username: "elastic", Password: "wrong"},
+			wantReason: PreflightAuthenticationFailed,
+		},
+		{
+			name:       "test wrong url prefix",
+			config:     Config{URL: server.URL + "/es", Username: "elastic", Password: "changeme"},
+			wantReason: PreflightClusterHealthFailed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewClient(tc.config)
+			require.NoError(t, err)
+			health, err := c.Preflight(context.Background())
+			if tc.wantReason != "" {
+				var preflightErr *PreflightError
+				require.True(t, errors.As(err, &preflightErr))
+				require.Equal(t, tc.wantReason, preflightErr.Reason)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, &ClusterHealth{ClusterName: "es", Status: "yellow"}, health)
+		})
+	}
+}
+
+func TestRestClientFieldMappings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "ignore_unavailable=true&allow_no_indices=true", r.URL.RawQuery)
+		switch r.URL.Path {
+		case "/logs-*/_mapping":
+			w.Write([]byte(`{
+				"logs-1": {"mappings": {"properties": {
+					"@timestamp": {"type": "date"},
+					"host": {"properties": {"name": {"type": "text", "fields": {"keyword": {"type": "keyword"}}}}},
+					"latency": {"type": "long"}
+				}}},
+				"logs-2": {"mappings": {"properties": {"latency": {"type": "keyword"}}}}
+			}`))
+		case "/legacy/_mapping":
+			w.Write([]byte(`{"legacy": {"mappings": {"_doc": {"properties": {"status": {"type": "keyword"}}}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c, err := NewClient(Config{URL: server.URL})
+	require.NoError(t, err)
+
+	fields, err := c.FieldMappings(context.Background(), "logs-*")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"@timestamp":        {"date"},
+		"host":              {"object"},
+		"host.name":         {"text"},
+		"host.name.keyword": {"keyword"},
+		"latency":           {"keyword", "long"},
+	}, fields)
+	fields, err = c.FieldMappings(context.Background(), "legacy")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"status": {"keyword"}}, fields)
+	fields, err = c.FieldMappings(context.Background(), "missing")
+	require.NoError(t, err)
+	require.Empty(t, fields)
+}
+
+func TestRestClientLastRuns(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/alerts_status/_search", r.URL.Path)
+		body := map[string]interface{}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, map[string]interface{}{"terms": map[string]interface{}{"rule_name": []interface{}{"errors", "audit", "idle"}}}, body["query"])
+		w.Write([]byte(`{"aggregations": {"rules": {"buckets": [
+			{"key": "errors", "doc_count": 3, "last_run": {"value": 1627783200000, "value_as_string": "2021-08-01T02:00:00.000Z"}},
+			{"key": "audit", "doc_count": 0, "last_run": {"value": null}}
+		]}}}`))
+	}))
+	defer server.Close()
+	c, err := NewClient(Config{URL: server.URL})
+	require.NoError(t, err)
+
+	runs, err := c.LastRuns(context.Background(), "alerts_status", []string{"errors", "audit", "idle"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]time.Time{"errors": time.Date(2021, 8, 1, 2, 0, 0, 0, time.UTC)}, runs)
+	runs, err = c.LastRuns(context.Background(), "alerts_status", nil)
+	require.NoError(t, err)
+	require.Empty(t, runs)
+}
+
+func TestRestClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error": "forbidden"}`))
+	}))
+	defer server.Close()
+	c, err := NewClient(Config{URL: server.URL})
+	require.NoError(t, err)
+	_, err = c.DeleteByQuery(context.Background(), []string{"elastalert_status"}, map[string]interface{}{})
+	require.Error(t, err)
+	require.Error(t, c.DeleteIndices(context.Background(), []string{"elastalert_status"}))
+	_, err = NewClient(Config{CACert: "not a pem"})
+	require.Error(t, err)
+}
+
+func TestNewClientSharesTransport(t *testing.T) {
+	transport := func(config Config) *http.Transport {
+		c, err := NewClient(config)
+		require.NoError(t, err)
+		return c.(*restClient).http.Transport.(*http.Transport)
+	}
+	shared := transport(Config{URL: "http://es.local:9200", Username: "elastic"})
+	require.Same(t, shared, transport(Config{URL: "http://other.local:9200"}))
+	require.NotSame(t, shared, transport(Config{URL: "https://es.local:9200", InsecureSkipVerify: true}))
+	require.Equal(t, idleConnTimeout, shared.IdleConnTimeout)
+
+	_, err := NewClient(Config{URL: "https://es.local:9200", CACert: "not a pem"})
+	require.Error(t, err)
+}
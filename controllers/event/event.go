@@ -1,5 +1,7 @@
 package event
 
+import "fmt"
+
 // Event reasons for the Elastalert
 const (
 	// EventReasonCreated describes events where resources were created.
@@ -10,4 +12,61 @@ const (
 	EventReasonError = "Error"
 	// EventReasonSuccess describes events where resources were successfully reconciled.
 	EventReasonSuccess = "Success"
+	// EventReasonPaused describes events where reconciliation was paused or resumed.
+	EventReasonPaused = "Paused"
+	// EventReasonMaintenance describes events where a maintenance window started or ended.
+	EventReasonMaintenance = "Maintenance"
+	// EventReasonConfigRenderFailed describes events where the config or the rules could not be rendered from the spec
+	// and the objects it references.
+	EventReasonConfigRenderFailed = "ConfigRenderFailed"
+	// EventReasonApplyFailed describes events where a resource could not be created or updated.
+	EventReasonApplyFailed = "ApplyFailed"
+	// EventReasonSecretMissing describes events where a secret, or a key of it, referenced by the spec does not exist.
+	EventReasonSecretMissing = "SecretMissing"
+	// EventReasonRuleInvalid describes events where a rule has lint findings or references fields missing from the
+	// index mappings.
+	EventReasonRuleInvalid = "RuleInvalid"
+	// EventReasonElasticsearchUnreachable describes events where elasticsearch was not reachable before a rollout.
+	EventReasonElasticsearchUnreachable = "ElasticsearchUnreachable"
+	// EventReasonElasticsearchReachable describes events where elasticsearch is reachable again after a failed preflight.
+	EventReasonElasticsearchReachable = "ElasticsearchReachable"
+	// EventReasonDeploymentAvailable describes events where every replica of the deployment became available.
+	EventReasonDeploymentAvailable = "DeploymentAvailable"
+	// EventReasonDeploymentUnavailable describes events where replicas of the deployment are not available.
+	EventReasonDeploymentUnavailable = "DeploymentUnavailable"
+	// EventReasonDeploymentRolloutStuck describes events where the rollout of the deployment exceeded its progress
+	// deadline.
+	EventReasonDeploymentRolloutStuck = "DeploymentRolloutStuck"
+	// EventReasonPodFailed describes events where a pod of the deployment failed, with the reason found in its status.
+	EventReasonPodFailed = "PodFailed"
+	// EventReasonCertExpiring describes events where the elasticsearch cert expires soon or has expired.
+	EventReasonCertExpiring = "CertExpiring"
 )
+
+// ObjectError is an error about an object, which tells the reason of the event reporting it.
+type ObjectError struct {
+	// Reason is the reason of the event reporting the error.
+	Reason string
+	// Object names the object, such as Secret ns/name, if the error is about a single one.
+	Object string
+	Err    error
+}
+
+func (e *ObjectError) Error() string {
+	if e.Object == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Object, e.Err)
+}
+
+func (e *ObjectError) Unwrap() error {
+	return e.Err
+}
+
+// Object names an object of a kind for events, such as Secret ns/name.
+func Object(kind, namespace, name string) string {
+	if namespace == "" {
+		return kind + " " + name
+	}
+	return kind + " " + namespace + "/" + name
+}
@@ -0,0 +1,92 @@
+package event
+
+import (
+	"fmt"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sync"
+)
+
+// series groups the reasons of the events telling the state of the same thing. An event repeating the last event of
+// its series for an object is dropped, so that a state checked periodically is reported when it changes only.
+var series = map[string]string{
+	EventReasonDeploymentAvailable:      "deployment",
+	EventReasonDeploymentUnavailable:    "deployment",
+	EventReasonDeploymentRolloutStuck:   "deployment",
+	EventReasonElasticsearchUnreachable: "elasticsearch",
+	EventReasonElasticsearchReachable:   "elasticsearch",
+	EventReasonCertExpiring:             "cert",
+	EventReasonPodFailed:                "pod",
+}
+
+// Recorder is a record.EventRecorder dropping the events which repeat the last event of their series for an object.
+// Events of the other reasons are always recorded.
+type Recorder struct {
+	recorder record.EventRecorder
+	mutex    sync.Mutex
+	// last holds the last event of a series, by object and by UID and series.
+	last map[types.NamespacedName]map[string]string
+}
+
+var _ record.EventRecorder = &Recorder{}
+
+// NewRecorder returns a Recorder recording the events with recorder.
+func NewRecorder(recorder record.EventRecorder) *Recorder {
+	return &Recorder{
+		recorder: recorder,
+		last:     map[types.NamespacedName]map[string]string{},
+	}
+}
+
+func (r *Recorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if r.repeated(object, eventtype, reason, message) {
+		return
+	}
+	r.recorder.Event(object, eventtype, reason, message)
+}
+
+func (r *Recorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *Recorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	message := fmt.Sprintf(messageFmt, args...)
+	if r.repeated(object, eventtype, reason, message) {
+		return
+	}
+	r.recorder.AnnotatedEventf(object, annotations, eventtype, reason, "%s", message)
+}
+
+// repeated records the event as the last one of its series, and reports whether it was the last one already.
+func (r *Recorder) repeated(object runtime.Object, eventtype, reason, message string) bool {
+	s, ok := series[reason]
+	if !ok {
+		return false
+	}
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return false
+	}
+	nsn := types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}
+	key := string(accessor.GetUID()) + "/" + s
+	event := eventtype + "/" + reason + "/" + message
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.last[nsn][key] == event {
+		return true
+	}
+	if r.last[nsn] == nil {
+		r.last[nsn] = map[string]string{}
+	}
+	r.last[nsn][key] = event
+	return false
+}
+
+// Forget drops the last events of a deleted object. The object is gone by then, so it is forgotten by name.
+func (r *Recorder) Forget(nsn types.NamespacedName) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.last, nsn)
+}
@@ -0,0 +1,120 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"testing"
+)
+
+type testEvent struct {
+	object    *corev1.ConfigMap
+	eventtype string
+	reason    string
+	message   string
+}
+
+func TestRecorder(t *testing.T) {
+	a := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a", UID: "a"}}
+	b := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "b", UID: "b"}}
+	testCases := []struct {
+		desc   string
+		events []testEvent
+		want   []string
+	}{
+		{
+			desc: "test repeated state event is dropped",
+			events: []testEvent{
+				{a, corev1.EventTypeNormal, EventReasonDeploymentAvailable, "Deployment has been stabilized."},
+				{a, corev1.EventTypeNormal, EventReasonDeploymentAvailable, "Deployment has been stabilized."},
+				{a, corev1.EventTypeNormal, EventReasonDeploymentAvailable, "Deployment has been stabilized."},
+			},
+			want: []string{
+				"Normal DeploymentAvailable Deployment has been stabilized.",
+			},
+		},
+		{
+			desc: "test state event is recorded again after a change",
+			events: []testEvent{
+				{a, corev1.EventTypeNormal, EventReasonDeploymentAvailable, "Deployment has been stabilized."},
+				{a, corev1.EventTypeWarning, EventReasonDeploymentUnavailable, "0 of 1 replicas of Deployment ns/a are available."},
+				{a, corev1.EventTypeWarning, EventReasonDeploymentUnavailable, "0 of 1 replicas of Deployment ns/a are available."},
+				{a, corev1.EventTypeNormal, EventReasonDeploymentAvailable, "Deployment has been stabilized."},
+			},
+			want: []string{
+				"Normal DeploymentAvailable Deployment has been stabilized.",
+				"Warning DeploymentUnavailable 0 of 1 replicas of Deployment ns/a are available.",
+				"Normal DeploymentAvailable Deployment has been stabilized.",
+			},
+		},
+		{
+			desc: "test series are kept per object",
+			events: []testEvent{
+				{a, corev1.EventTypeNormal, EventReasonDeploymentAvailable, "Deployment has been stabilized."},
+				{b, corev1.EventTypeNormal, EventReasonDeploymentAvailable, "Deployment has been stabilized."},
+				{a, corev1.EventTypeWarning, EventReasonCertExpiring, "Cert es of Secret ns/a-es-cert expires at 2021-06-01T00:00:00Z."},
+				{a, corev1.EventTypeNormal, EventReasonDeploymentAvailable, "Deployment has been stabilized."},
+			},
+			want: []string{
+				"Normal DeploymentAvailable Deployment has been stabilized.",
+				"Normal DeploymentAvailable Deployment has been stabilized.",
+				"Warning CertExpiring Cert es of Secret ns/a-es-cert expires at 2021-06-01T00:00:00Z.",
+			},
+		},
+		{
+			desc: "test events without series are always recorded",
+			events: []testEvent{
+				{a, corev1.EventTypeNormal, EventReasonCreated, "Apply configmaps successfully."},
+				{a, corev1.EventTypeNormal, EventReasonCreated, "Apply configmaps successfully."},
+			},
+			want: []string{
+				"Normal Created Apply configmaps successfully.",
+				"Normal Created Apply configmaps successfully.",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			fake := record.NewFakeRecorder(len(tc.events))
+			r := NewRecorder(fake)
+			for _, e := range tc.events {
+				r.Event(e.object, e.eventtype, e.reason, e.message)
+			}
+			close(fake.Events)
+			var got []string
+			for e := range fake.Events {
+				got = append(got, e)
+			}
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRecorderForget(t *testing.T) {
+	a := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a", UID: "a"}}
+	fake := record.NewFakeRecorder(3)
+	r := NewRecorder(fake)
+	r.Event(a, corev1.EventTypeNormal, EventReasonDeploymentAvailable, "Deployment has been stabilized.")
+	r.Forget(types.NamespacedName{Namespace: "ns", Name: "a"})
+	require.Empty(t, r.last)
+	// the state of a forgotten object is reported again.
+	r.Event(a, corev1.EventTypeNormal, EventReasonDeploymentAvailable, "Deployment has been stabilized.")
+	require.Len(t, fake.Events, 2)
+}
+
+func TestObjectError(t *testing.T) {
+	err := fmt.Errorf("failed to apply: %w", &ObjectError{
+		Reason: EventReasonSecretMissing,
+		Object: Object("Secret", "ns", "slack"),
+		Err:    errors.New("key webhook not found for slack_webhook_url"),
+	})
+	require.Equal(t, "failed to apply: Secret ns/slack: key webhook not found for slack_webhook_url", err.Error())
+	var objectErr *ObjectError
+	require.True(t, errors.As(err, &objectErr))
+	require.Equal(t, EventReasonSecretMissing, objectErr.Reason)
+	require.Equal(t, "ElastalertClusterConfig default", Object("ElastalertClusterConfig", "", "default"))
+}
@@ -0,0 +1,438 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Severity tells how bad a finding is.
+type Severity string
+
+const (
+	// SeverityError findings make ElastAlert reject the rule, or the rule never alert.
+	SeverityError Severity = "error"
+	// SeverityWarning findings are likely mistakes.
+	SeverityWarning Severity = "warning"
+)
+
+// Check identifiers.
+const (
+	CheckName              = "rule-name"
+	CheckType              = "rule-type"
+	CheckRequiredOption    = "required-option"
+	CheckOptionValue       = "option-value"
+	CheckTimeUnit          = "time-unit"
+	CheckAlerter           = "alerter"
+	CheckUnreachableFilter = "unreachable-filter"
+	CheckRealert           = "realert"
+	CheckAggregation       = "aggregation"
+)
+
+// Checks describes each check by identifier.
+var Checks = map[string]string{
+	CheckName:              "Every rule has a unique name.",
+	CheckType:              "The rule type is one of ElastAlert, or a custom module.",
+	CheckRequiredOption:    "The options the rule type requires are set.",
+	CheckOptionValue:       "Options with a fixed set of values or a range use a valid one.",
+	CheckTimeUnit:          "Time periods are mappings of known units to numbers.",
+	CheckAlerter:           "The rule alerts with known alerters.",
+	CheckUnreachableFilter: "The filter can match a document.",
+	CheckRealert:           "realert and exponential_realert are consistent.",
+	CheckAggregation:       "Options depending on aggregation are used along with it.",
+}
+
+var (
+	// requiredOptions are the options of each rule type, each requirement being met by any of its options.
+	requiredOptions = map[string][][]string{
+		"any":                nil,
+		"blacklist":          {{"compare_key"}, {"blacklist"}},
+		"whitelist":          {{"compare_key"}, {"whitelist"}, {"ignore_null"}},
+		"change":             {{"query_key"}, {"compare_key"}, {"ignore_null"}},
+		"frequency":          {{"num_events"}, {"timeframe"}},
+		"spike":              {{"timeframe"}, {"spike_height"}, {"spike_type"}},
+		"flatline":           {{"threshold"}, {"timeframe"}},
+		"new_term":           {{"fields", "query_key"}},
+		"cardinality":        {{"cardinality_field"}, {"timeframe"}, {"max_cardinality", "min_cardinality"}},
+		"metric_aggregation": {{"metric_agg_key"}, {"metric_agg_type"}, {"max_threshold", "min_threshold"}},
+		"spike_aggregation":  {{"metric_agg_key"}, {"metric_agg_type"}, {"spike_height"}, {"spike_type"}, {"timeframe"}},
+		"percentage_match":   {{"match_bucket_filter"}, {"max_percentage", "min_percentage"}},
+	}
+
+	// optionValues are the values allowed for options taking one of a fixed set.
+	optionValues = map[string][]string{
+		"spike_type":      {"up", "down", "both"},
+		"metric_agg_type": {"min", "max", "avg", "sum", "cardinality", "value_count", "percentiles"},
+	}
+
+	// positiveOptions are the options which must be a number above zero.
+	positiveOptions = []string{"num_events", "spike_height", "threshold"}
+
+	// timeOptions are the rule options holding a period of time.
+	timeOptions = []string{
+		"timeframe", "realert", "exponential_realert", "aggregation", "buffer_time", "query_delay", "run_every",
+		"terms_window_size", "window_step_size", "kibana4_start_timedelta", "kibana4_end_timedelta",
+	}
+	timeUnits = map[string]time.Duration{
+		"weeks":        7 * 24 * time.Hour,
+		"days":         24 * time.Hour,
+		"hours":        time.Hour,
+		"minutes":      time.Minute,
+		"seconds":      time.Second,
+		"milliseconds": time.Millisecond,
+		"microseconds": time.Microsecond,
+	}
+
+	// alerters are the alerters of ElastAlert and ElastAlert 2.
+	alerters = []string{
+		"alerta", "alertmanager", "chatwork", "command", "datadog", "debug", "dingtalk", "discord", "email", "exotel",
+		"gitter", "googlechat", "hipchat", "hivealerter", "iris", "jira", "lark", "linenotify", "mattermost",
+		"ms_teams", "opsgenie", "pagerduty", "pagertree", "post", "post2", "rocketchat", "servicenow", "ses", "slack",
+		"sns", "stomp", "stride", "telegram", "tencent_sms", "twilio", "victorops", "zabbix",
+	}
+
+	// aggregationOptions only take effect along with aggregation.
+	aggregationOptions = []string{"aggregation_key", "aggregate_by_match_time", "summary_table_fields", "summary_prefix", "summary_suffix"}
+)
+
+// Finding is a problem found in a rule.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s", f.Severity, f.Message)
+}
+
+// Rules checks the rules against ElastAlert semantics, as ElastAlert loads them once every option is rendered.
+func Rules(rules []map[string]interface{}) []Finding {
+	var findings []Finding
+	names := map[string]bool{}
+	for i, rule := range rules {
+		name := RuleName(rule, i)
+		if n, _ := rule["name"].(string); n == "" {
+			findings = append(findings, Finding{Rule: name, Check: CheckName, Severity: SeverityError, Message: "name is not set"})
+		} else if names[name] {
+			findings = append(findings, Finding{Rule: name, Check: CheckName, Severity: SeverityError, Message: "name is used by another rule"})
+		}
+		names[name] = true
+		l := &linter{rule: rule, name: name}
+		l.checkType()
+		l.checkValues()
+		l.checkTimes()
+		l.checkAlerters()
+		l.checkFilter()
+		l.checkRealert()
+		l.checkAggregation()
+		findings = append(findings, l.findings...)
+	}
+	return findings
+}
+
+// RuleName returns the name findings report the rule with, its position among the rules if it has no name.
+func RuleName(rule map[string]interface{}, index int) string {
+	if name, _ := rule["name"].(string); name != "" {
+		return name
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
+
+// Errors returns the number of findings with SeverityError.
+func Errors(findings []Finding) int {
+	n := 0
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			n++
+		}
+	}
+	return n
+}
+
+type linter struct {
+	rule     map[string]interface{}
+	name     string
+	findings []Finding
+}
+
+func (l *linter) report(check string, severity Severity, format string, args ...interface{}) {
+	l.findings = append(l.findings, Finding{Rule: l.name, Check: check, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) has(option string) bool {
+	_, ok := l.rule[option]
+	return ok
+}
+
+func (l *linter) checkType() {
+	if !l.has("index") {
+		l.report(CheckRequiredOption, SeverityError, "index is not set")
+	}
+	ruleType, ok := l.rule["type"].(string)
+	if !ok || ruleType == "" {
+		l.report(CheckType, SeverityError, "type is not set")
+		return
+	}
+	required, known := requiredOptions[ruleType]
+	if !known {
+		if !strings.Contains(ruleType, ".") {
+			l.report(CheckType, SeverityError, "type %s is unknown, custom rule types are given as module.Class", ruleType)
+		}
+		return
+	}
+	for _, options := range required {
+		met := false
+		for _, option := range options {
+			met = met || l.has(option)
+		}
+		if !met {
+			l.report(CheckRequiredOption, SeverityError, "%s rule needs %s", ruleType, strings.Join(options, " or "))
+		}
+	}
+}
+
+func (l *linter) checkValues() {
+	for _, option := range sortedKeys(optionValues) {
+		value, ok := l.rule[option]
+		if !ok {
+			continue
+		}
+		if s, _ := value.(string); !contains(optionValues[option], s) {
+			l.report(CheckOptionValue, SeverityError, "%s %v is not one of %s", option, value, strings.Join(optionValues[option], ", "))
+		}
+	}
+	for _, option := range positiveOptions {
+		value, ok := l.rule[option]
+		if !ok {
+			continue
+		}
+		if n, ok := number(value); !ok || n <= 0 {
+			l.report(CheckOptionValue, SeverityError, "%s %v is not a number above zero", option, value)
+		}
+	}
+}
+
+func (l *linter) checkTimes() {
+	for _, option := range timeOptions {
+		value, ok := l.rule[option]
+		if !ok {
+			continue
+		}
+		if m, ok := value.(map[string]interface{}); ok && option == "aggregation" && m["schedule"] != nil {
+			continue
+		}
+		if _, err := duration(value); err != nil {
+			l.report(CheckTimeUnit, SeverityError, "%s %v", option, err)
+		}
+	}
+}
+
+func (l *linter) checkAlerters() {
+	alert, ok := l.rule["alert"]
+	if !ok {
+		l.report(CheckRequiredOption, SeverityError, "alert is not set")
+		return
+	}
+	var names []string
+	switch a := alert.(type) {
+	case string:
+		names = append(names, a)
+	case []interface{}:
+		for _, v := range a {
+			switch entry := v.(type) {
+			case string:
+				names = append(names, entry)
+			case map[string]interface{}:
+				// an alerter with its own options.
+				names = append(names, sortedKeys(entry)...)
+			default:
+				l.report(CheckAlerter, SeverityError, "alert entry %v is not an alerter name", v)
+			}
+		}
+	default:
+		l.report(CheckAlerter, SeverityError, "alert must be an alerter name or a list of them")
+	}
+	if len(names) == 0 && alert != nil {
+		l.report(CheckAlerter, SeverityError, "alert lists no alerter")
+	}
+	for _, name := range names {
+		if !contains(alerters, name) && !strings.Contains(name, ".") {
+			l.report(CheckAlerter, SeverityError, "alerter %s is unknown, custom alerters are given as module.Class", name)
+		}
+	}
+}
+
+// checkFilter looks for queries of the filter which exclude each other, the queries of a filter must all match.
+func (l *linter) checkFilter() {
+	filter, ok := l.rule["filter"]
+	if !ok || filter == nil {
+		return
+	}
+	queries, ok := filter.([]interface{})
+	if !ok {
+		l.report(CheckUnreachableFilter, SeverityError, "filter must be a list of queries")
+		return
+	}
+	terms := map[string]interface{}{}
+	for _, q := range queries {
+		query, ok := q.(map[string]interface{})
+		if !ok || len(query) == 0 {
+			l.report(CheckUnreachableFilter, SeverityError, "filter holds an empty query")
+			continue
+		}
+		if term, ok := query["term"].(map[string]interface{}); ok {
+			for _, field := range sortedKeys(term) {
+				value := term[field]
+				if m, ok := value.(map[string]interface{}); ok {
+					value = m["value"]
+				}
+				if previous, ok := terms[field]; ok && fmt.Sprint(previous) != fmt.Sprint(value) {
+					l.report(CheckUnreachableFilter, SeverityError, "filter never matches, term queries require %s to be both %v and %v", field, previous, value)
+				}
+				terms[field] = value
+			}
+		}
+		if ranges, ok := query["range"].(map[string]interface{}); ok {
+			for _, field := range sortedKeys(ranges) {
+				bounds, _ := ranges[field].(map[string]interface{})
+				if emptyRange(bounds) {
+					l.report(CheckUnreachableFilter, SeverityError, "filter never matches, the range of %s is empty", field)
+				}
+			}
+		}
+	}
+}
+
+func (l *linter) checkRealert() {
+	realert := time.Minute
+	if value, ok := l.rule["realert"]; ok {
+		d, err := duration(value)
+		if err != nil {
+			return
+		}
+		realert = d
+	}
+	if value, ok := l.rule["exponential_realert"]; ok {
+		if d, err := duration(value); err == nil && d < realert {
+			l.report(CheckRealert, SeverityError, "exponential_realert %s is shorter than realert %s", d, realert)
+		}
+	}
+	if realert == 0 && l.has("exponential_realert") {
+		l.report(CheckRealert, SeverityWarning, "exponential_realert has no effect with a realert of zero")
+	}
+}
+
+func (l *linter) checkAggregation() {
+	aggregation, ok := l.rule["aggregation"]
+	if !ok {
+		for _, option := range aggregationOptions {
+			if l.has(option) {
+				l.report(CheckAggregation, SeverityWarning, "%s has no effect without aggregation", option)
+			}
+		}
+		return
+	}
+	if m, ok := aggregation.(map[string]interface{}); ok && m["schedule"] != nil {
+		if _, ok := m["schedule"].(string); !ok {
+			l.report(CheckAggregation, SeverityError, "aggregation schedule must be a cron expression")
+		}
+		if l.has("aggregate_by_match_time") {
+			l.report(CheckAggregation, SeverityWarning, "aggregate_by_match_time has no effect with a scheduled aggregation")
+		}
+		return
+	}
+	if d, err := duration(aggregation); err == nil && d == 0 {
+		l.report(CheckAggregation, SeverityWarning, "aggregation of zero sends every match on its own")
+	}
+}
+
+// duration converts a period of time given as a mapping of units to numbers, the way ElastAlert builds a timedelta.
+func duration(value interface{}) (time.Duration, error) {
+	m, ok := value.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		return 0, fmt.Errorf("is not a mapping of units to numbers, such as minutes: 5")
+	}
+	var d time.Duration
+	for _, unit := range sortedKeys(m) {
+		scale, ok := timeUnits[unit]
+		if !ok {
+			return 0, fmt.Errorf("has unknown unit %s, use weeks, days, hours, minutes, seconds, milliseconds or microseconds", unit)
+		}
+		n, ok := number(m[unit])
+		if !ok || n < 0 {
+			return 0, fmt.Errorf("has %v %s, which is not a positive number", m[unit], unit)
+		}
+		d += time.Duration(n * float64(scale))
+	}
+	return d, nil
+}
+
+// emptyRange tells whether the bounds of a range query exclude every value.
+func emptyRange(bounds map[string]interface{}) bool {
+	for _, lower := range []string{"gt", "gte"} {
+		for _, upper := range []string{"lt", "lte"} {
+			lo, ok := bounds[lower]
+			if !ok {
+				continue
+			}
+			hi, ok := bounds[upper]
+			if !ok {
+				continue
+			}
+			inclusive := lower == "gte" && upper == "lte"
+			if a, ok := number(lo); ok {
+				if b, ok := number(hi); ok && (a > b || (a == b && !inclusive)) {
+					return true
+				}
+				continue
+			}
+			a, aok := lo.(string)
+			b, bok := hi.(string)
+			// dates of the same format compare as strings, date math such as now-1h can not be compared.
+			if aok && bok && !strings.Contains(a+b, "now") && (a > b || (a == b && !inclusive)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func number(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch v := m.(type) {
+	case map[string]interface{}:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	case map[string][]string:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
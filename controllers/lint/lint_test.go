@@ -0,0 +1,146 @@
+package lint
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	frequency := func(options map[string]interface{}) map[string]interface{} {
+		rule := map[string]interface{}{
+			"name":       "errors",
+			"type":       "frequency",
+			"index":      "logs-*",
+			"num_events": float64(5),
+			"timeframe":  map[string]interface{}{"minutes": float64(5)},
+			"alert":      []interface{}{"slack"},
+		}
+		for k, v := range options {
+			if v == nil {
+				delete(rule, k)
+				continue
+			}
+			rule[k] = v
+		}
+		return rule
+	}
+	testCases := []struct {
+		desc  string
+		rules []map[string]interface{}
+		want  []Finding
+	}{
+		{
+			desc:  "test valid rule",
+			rules: []map[string]interface{}{frequency(nil)},
+		},
+		{
+			desc: "test names",
+			rules: []map[string]interface{}{
+				frequency(nil),
+				frequency(nil),
+				frequency(map[string]interface{}{"name": nil}),
+			},
+			want: []Finding{
+				{Rule: "errors", Check: CheckName, Severity: SeverityError, Message: "name is used by another rule"},
+				{Rule: "#3", Check: CheckName, Severity: SeverityError, Message: "name is not set"},
+			},
+		},
+		{
+			desc: "test required options",
+			rules: []map[string]interface{}{
+				frequency(map[string]interface{}{"num_events": nil, "index": nil, "alert": nil}),
+				{"name": "cardinality", "type": "cardinality", "index": "logs-*", "alert": "email", "cardinality_field": "user", "timeframe": map[string]interface{}{"hours": float64(1)}},
+				{"name": "custom", "type": "elastalert_modules.rules.AwesomeRule", "index": "logs-*", "alert": "email"},
+				{"name": "typo", "type": "frequence", "index": "logs-*", "alert": "email"},
+			},
+			want: []Finding{
+				{Rule: "errors", Check: CheckRequiredOption, Severity: SeverityError, Message: "index is not set"},
+				{Rule: "errors", Check: CheckRequiredOption, Severity: SeverityError, Message: "frequency rule needs num_events"},
+				{Rule: "errors", Check: CheckRequiredOption, Severity: SeverityError, Message: "alert is not set"},
+				{Rule: "cardinality", Check: CheckRequiredOption, Severity: SeverityError, Message: "cardinality rule needs max_cardinality or min_cardinality"},
+				{Rule: "typo", Check: CheckType, Severity: SeverityError, Message: "type frequence is unknown, custom rule types are given as module.Class"},
+			},
+		},
+		{
+			desc: "test values and time units",
+			rules: []map[string]interface{}{
+				frequency(map[string]interface{}{
+					"num_events": float64(0),
+					"timeframe":  map[string]interface{}{"minute": float64(5)},
+					"realert":    "5m",
+					"spike_type": "upward",
+				}),
+			},
+			want: []Finding{
+				{Rule: "errors", Check: CheckOptionValue, Severity: SeverityError, Message: "spike_type upward is not one of up, down, both"},
+				{Rule: "errors", Check: CheckOptionValue, Severity: SeverityError, Message: "num_events 0 is not a number above zero"},
+				{Rule: "errors", Check: CheckTimeUnit, Severity: SeverityError, Message: "timeframe has unknown unit minute, use weeks, days, hours, minutes, seconds, milliseconds or microseconds"},
+				{Rule: "errors", Check: CheckTimeUnit, Severity: SeverityError, Message: "realert is not a mapping of units to numbers, such as minutes: 5"},
+			},
+		},
+		{
+			desc: "test alerters",
+			rules: []map[string]interface{}{
+				frequency(map[string]interface{}{"alert": []interface{}{
+					"slack",
+					"slak",
+					"elastalert_modules.alerts.AwesomeAlerter",
+					map[string]interface{}{"email": map[string]interface{}{"email": "ops@example.com"}},
+				}}),
+			},
+			want: []Finding{
+				{Rule: "errors", Check: CheckAlerter, Severity: SeverityError, Message: "alerter slak is unknown, custom alerters are given as module.Class"},
+			},
+		},
+		{
+			desc: "test unreachable filter",
+			rules: []map[string]interface{}{
+				frequency(map[string]interface{}{"filter": []interface{}{
+					map[string]interface{}{"term": map[string]interface{}{"level": "ERROR"}},
+					map[string]interface{}{"term": map[string]interface{}{"level": map[string]interface{}{"value": "WARN"}}},
+					map[string]interface{}{"range": map[string]interface{}{"status": map[string]interface{}{"gte": float64(500), "lt": float64(400)}}},
+					map[string]interface{}{"range": map[string]interface{}{"@timestamp": map[string]interface{}{"gte": "now-1h", "lt": "now"}}},
+					map[string]interface{}{},
+				}}),
+			},
+			want: []Finding{
+				{Rule: "errors", Check: CheckUnreachableFilter, Severity: SeverityError, Message: "filter never matches, term queries require level to be both ERROR and WARN"},
+				{Rule: "errors", Check: CheckUnreachableFilter, Severity: SeverityError, Message: "filter never matches, the range of status is empty"},
+				{Rule: "errors", Check: CheckUnreachableFilter, Severity: SeverityError, Message: "filter holds an empty query"},
+			},
+		},
+		{
+			desc: "test realert and aggregation",
+			rules: []map[string]interface{}{
+				frequency(map[string]interface{}{
+					"realert":             map[string]interface{}{"hours": float64(1)},
+					"exponential_realert": map[string]interface{}{"minutes": float64(30)},
+					"aggregation_key":     "host",
+				}),
+				frequency(map[string]interface{}{
+					"name":                    "scheduled",
+					"aggregation":             map[string]interface{}{"schedule": "0 * * * *"},
+					"aggregate_by_match_time": true,
+				}),
+			},
+			want: []Finding{
+				{Rule: "errors", Check: CheckRealert, Severity: SeverityError, Message: "exponential_realert 30m0s is shorter than realert 1h0m0s"},
+				{Rule: "errors", Check: CheckAggregation, Severity: SeverityWarning, Message: "aggregation_key has no effect without aggregation"},
+				{Rule: "scheduled", Check: CheckAggregation, Severity: SeverityWarning, Message: "aggregate_by_match_time has no effect with a scheduled aggregation"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			have := Rules(tc.rules)
+			require.Equal(t, tc.want, have)
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	require.Equal(t, 1, Errors([]Finding{
+		{Severity: SeverityError},
+		{Severity: SeverityWarning},
+	}))
+}
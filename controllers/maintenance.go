@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/event"
+	ob "github.com/toughnoah/elastalert-operator/controllers/observer"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"time"
+)
+
+// updateMaintenanceStatus records the maintenance windows active at now in status and emits an event when one
+// starts or ends. It tells whether the suspension or the muted rules changed, so that resources are applied again.
+func updateMaintenanceStatus(c client.Client, recorder record.EventRecorder, ctx context.Context, e *esv1alpha1.Elastalert, state *podspec.MaintenanceState) (bool, error) {
+	previous := e.Status.Maintenance
+	if previous == nil {
+		previous = &esv1alpha1.MaintenanceStatus{}
+	}
+	current := state.Status
+	if current == nil {
+		current = &esv1alpha1.MaintenanceStatus{}
+	}
+	if !reflect.DeepEqual(e.Status.Maintenance, state.Status) {
+		original := e.DeepCopy()
+		e.Status.Maintenance = state.Status
+		if err := c.Status().Patch(ctx, e, client.MergeFrom(original)); err != nil {
+			log.Error(err, "Failed to update maintenance status", "Elastalert.Namespace", e.Namespace, "Elastalert.Name", e.Name)
+			return false, err
+		}
+	}
+	changed := false
+	if current.Suspended != previous.Suspended {
+		changed = true
+		if current.Suspended {
+			ob.EmitK8sEvent(recorder, e, corev1.EventTypeNormal, event.EventReasonMaintenance, fmt.Sprintf("Maintenance window started, the instance is suspended until %s.", current.ActiveUntil.Format(time.RFC3339)))
+		} else {
+			ob.EmitK8sEvent(recorder, e, corev1.EventTypeNormal, event.EventReasonMaintenance, "Maintenance window ended, the instance is resumed.")
+		}
+	}
+	if started := subtract(current.MutedRules, previous.MutedRules); len(started) != 0 {
+		changed = true
+		ob.EmitK8sEvent(recorder, e, corev1.EventTypeNormal, event.EventReasonMaintenance, fmt.Sprintf("Maintenance window started, rules %s are muted until %s.", strings.Join(started, ", "), current.ActiveUntil.Format(time.RFC3339)))
+	}
+	if ended := subtract(previous.MutedRules, current.MutedRules); len(ended) != 0 {
+		changed = true
+		ob.EmitK8sEvent(recorder, e, corev1.EventTypeNormal, event.EventReasonMaintenance, fmt.Sprintf("Maintenance window ended, rules %s are unmuted.", strings.Join(ended, ", ")))
+	}
+	return changed, nil
+}
+
+// subtract returns the names of a missing from b.
+func subtract(a, b []string) []string {
+	var names []string
+	for _, name := range a {
+		found := false
+		for _, other := range b {
+			if name == other {
+				found = true
+				break
+			}
+		}
+		if !found {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+	"time"
+)
+
+func TestReconcileMaintenanceWindows(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &v1alpha1.Elastalert{})
+	nsn := types.NamespacedName{Name: "my-esa", Namespace: "esa1"}
+	c := fake.NewClientBuilder().WithRuntimeObjects(
+		&v1alpha1.Elastalert{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:  "esa1",
+				Name:       "my-esa",
+				Generation: int64(1),
+			},
+			Spec: v1alpha1.ElastalertSpec{
+				ConfigSetting: v1alpha1.NewFreeForm(map[string]interface{}{
+					"config": "test",
+				}),
+				Rule: []v1alpha1.FreeForm{
+					v1alpha1.NewFreeForm(map[string]interface{}{
+						"name": "test-elastalert", "type": "any",
+					}),
+				},
+				MaintenanceWindows: []v1alpha1.MaintenanceWindow{
+					{
+						Schedule: "0 2 * * *",
+						Duration: metav1.Duration{Duration: time.Hour},
+					},
+				},
+			},
+		},
+	).Build()
+	fakeClock := clock.NewFakeClock(time.Date(2021, 8, 1, 2, 30, 0, 0, time.UTC))
+	r := &ElastalertReconciler{
+		Client:   c,
+		Scheme:   s,
+		Recorder: record.NewBroadcaster().NewRecorder(s, corev1.EventSource{}),
+		Clock:    fakeClock,
+	}
+
+	result, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: nsn})
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Minute, result.RequeueAfter)
+	have := &v1alpha1.Elastalert{}
+	require.NoError(t, c.Get(context.Background(), nsn, have))
+	require.Equal(t, &v1alpha1.MaintenanceStatus{
+		Suspended:   true,
+		ActiveUntil: &metav1.Time{Time: time.Date(2021, 8, 1, 3, 0, 0, 0, time.UTC)},
+		NextWindow:  &metav1.Time{Time: time.Date(2021, 8, 2, 2, 0, 0, 0, time.UTC)},
+	}, have.Status.Maintenance)
+	deploy := &appsv1.Deployment{}
+	require.NoError(t, c.Get(context.Background(), nsn, deploy))
+	require.Equal(t, int32(0), *deploy.Spec.Replicas)
+
+	// the window ends, the instance is scaled up again.
+	fakeClock.SetTime(time.Date(2021, 8, 1, 3, 0, 0, 0, time.UTC))
+	result, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: nsn})
+	require.NoError(t, err)
+	require.Equal(t, 23*time.Hour, result.RequeueAfter)
+	require.NoError(t, c.Get(context.Background(), nsn, have))
+	require.False(t, have.Status.Maintenance.Suspended)
+	require.NoError(t, c.Get(context.Background(), nsn, deploy))
+	require.Equal(t, int32(1), *deploy.Spec.Replicas)
+}
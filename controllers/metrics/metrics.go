@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sync"
+	"time"
+)
+
+// Labels of the metrics of an Elastalert.
+const (
+	NamespaceLabel  = "namespace"
+	ElastalertLabel = "elastalert"
+	PhaseLabel      = "phase"
+	RuleLabel       = "rule"
+)
+
+// Metric is a gauge the operator registers.
+type Metric struct {
+	Name   string
+	Help   string
+	Labels []string
+}
+
+var (
+	// InstancePhase is 1 for the phase an Elastalert is in and 0 for the others.
+	InstancePhase = Metric{
+		Name:   "elastalert_operator_instance_phase",
+		Help:   "Phase of the Elastalert, 1 for the current one.",
+		Labels: []string{NamespaceLabel, ElastalertLabel, PhaseLabel},
+	}
+	// RuleLastRun is when ElastAlert last ran a rule, read from the writeback index.
+	RuleLastRun = Metric{
+		Name:   "elastalert_operator_rule_last_run_timestamp_seconds",
+		Help:   "Unix time of the latest run of the rule recorded in the writeback index.",
+		Labels: []string{NamespaceLabel, ElastalertLabel, RuleLabel},
+	}
+	// CertExpiry is when the elasticsearch cert the pods of an Elastalert mount expires.
+	CertExpiry = Metric{
+		Name:   "elastalert_operator_cert_expiry_timestamp_seconds",
+		Help:   "Unix time the elasticsearch cert of the Elastalert expires at.",
+		Labels: []string{NamespaceLabel, ElastalertLabel},
+	}
+
+	// Metrics are the metrics the operator registers, which the generated alerts and dashboard query.
+	Metrics = []Metric{InstancePhase, RuleLastRun, CertExpiry}
+
+	// Phases are the phases InstancePhase reports.
+	Phases = []string{
+		esv1alpha1.ElastAlertInitializing,
+		esv1alpha1.ElastAlertPhraseSucceeded,
+		esv1alpha1.ElastAlertPhraseDegraded,
+		esv1alpha1.ElastAlertPhraseUpgrading,
+		esv1alpha1.ElastAlertPhraseSuspended,
+		esv1alpha1.ElastAlertPhraseFailed,
+	}
+)
+
+var (
+	instancePhase = newGaugeVec(InstancePhase)
+	ruleLastRun   = newGaugeVec(RuleLastRun)
+	certExpiry    = newGaugeVec(CertExpiry)
+
+	mu sync.Mutex
+	// rules are the rules ruleLastRun has a series of, by Elastalert.
+	rules = map[types.NamespacedName][]string{}
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(instancePhase, ruleLastRun, certExpiry)
+}
+
+func newGaugeVec(m Metric) *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: m.Name, Help: m.Help}, m.Labels)
+}
+
+// SetPhase reports the phase of the Elastalert.
+func SetPhase(nsn types.NamespacedName, phase string) {
+	for _, p := range Phases {
+		value := 0.0
+		if p == phase {
+			value = 1
+		}
+		instancePhase.WithLabelValues(nsn.Namespace, nsn.Name, p).Set(value)
+	}
+}
+
+// SetRuleLastRuns reports the latest runs of the rules of the Elastalert, and drops the rules left out.
+func SetRuleLastRuns(nsn types.NamespacedName, runs map[string]time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, rule := range rules[nsn] {
+		if _, ok := runs[rule]; !ok {
+			ruleLastRun.DeleteLabelValues(nsn.Namespace, nsn.Name, rule)
+		}
+	}
+	var names []string
+	for rule, run := range runs {
+		ruleLastRun.WithLabelValues(nsn.Namespace, nsn.Name, rule).Set(float64(run.Unix()))
+		names = append(names, rule)
+	}
+	rules[nsn] = names
+}
+
+// SetCertExpiry reports when the elasticsearch cert of the Elastalert expires, nil if it mounts none.
+func SetCertExpiry(nsn types.NamespacedName, notAfter *time.Time) {
+	if notAfter == nil {
+		certExpiry.DeleteLabelValues(nsn.Namespace, nsn.Name)
+		return
+	}
+	certExpiry.WithLabelValues(nsn.Namespace, nsn.Name).Set(float64(notAfter.Unix()))
+}
+
+// Forget drops the series of a deleted Elastalert.
+func Forget(nsn types.NamespacedName) {
+	for _, p := range Phases {
+		instancePhase.DeleteLabelValues(nsn.Namespace, nsn.Name, p)
+	}
+	SetRuleLastRuns(nsn, nil)
+	SetCertExpiry(nsn, nil)
+	mu.Lock()
+	defer mu.Unlock()
+	delete(rules, nsn)
+}
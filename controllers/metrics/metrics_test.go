@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	"regexp"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"testing"
+	"time"
+)
+
+var (
+	metricName = regexp.MustCompile(`elastalert_operator_[a-z_]+`)
+	phaseValue = regexp.MustCompile(PhaseLabel + `="([A-Z]+)"`)
+)
+
+func TestMonitoringQueriesRegisteredMetrics(t *testing.T) {
+	var exprs []string
+	for _, group := range PrometheusRule(DefaultAlertOptions).Spec.Groups {
+		for _, r := range group.Rules {
+			exprs = append(exprs, r.Expr)
+		}
+	}
+	for _, p := range GrafanaDashboard()["panels"].([]map[string]interface{}) {
+		for _, target := range p["targets"].([]map[string]interface{}) {
+			exprs = append(exprs, target["expr"].(string))
+		}
+	}
+	defined := map[string]bool{}
+	for _, m := range Metrics {
+		defined[m.Name] = true
+		// the operator registered it already.
+		err := ctrlmetrics.Registry.Register(newGaugeVec(m))
+		require.True(t, errors.As(err, &prometheus.AlreadyRegisteredError{}), m.Name)
+	}
+	for _, expr := range exprs {
+		names := metricName.FindAllString(expr, -1)
+		require.NotEmpty(t, names, expr)
+		for _, name := range names {
+			require.True(t, defined[name], "%s queries %s, which is not registered", expr, name)
+		}
+		for _, match := range phaseValue.FindAllStringSubmatch(expr, -1) {
+			require.Contains(t, Phases, match[1], expr)
+		}
+	}
+}
+
+func TestSetPhase(t *testing.T) {
+	nsn := types.NamespacedName{Namespace: "esa1", Name: "phase"}
+	SetPhase(nsn, "RUNNING")
+	SetPhase(nsn, "FAILED")
+	require.Equal(t, 0.0, testutil.ToFloat64(instancePhase.WithLabelValues("esa1", "phase", "RUNNING")))
+	require.Equal(t, 1.0, testutil.ToFloat64(instancePhase.WithLabelValues("esa1", "phase", "FAILED")))
+	Forget(nsn)
+	require.Equal(t, 0, testutil.CollectAndCount(instancePhase))
+}
+
+func TestSetRuleLastRuns(t *testing.T) {
+	nsn := types.NamespacedName{Namespace: "esa1", Name: "runs"}
+	now := time.Date(2021, 8, 1, 2, 0, 0, 0, time.UTC)
+	SetRuleLastRuns(nsn, map[string]time.Time{"errors": now, "audit": now.Add(-time.Hour)})
+	require.Equal(t, float64(now.Unix()), testutil.ToFloat64(ruleLastRun.WithLabelValues("esa1", "runs", "errors")))
+	// a rule removed from the Elastalert is dropped.
+	SetRuleLastRuns(nsn, map[string]time.Time{"errors": now.Add(time.Minute)})
+	require.Equal(t, 1, testutil.CollectAndCount(ruleLastRun))
+	require.Equal(t, float64(now.Add(time.Minute).Unix()), testutil.ToFloat64(ruleLastRun.WithLabelValues("esa1", "runs", "errors")))
+	Forget(nsn)
+	require.Equal(t, 0, testutil.CollectAndCount(ruleLastRun))
+}
+
+func TestSetCertExpiry(t *testing.T) {
+	nsn := types.NamespacedName{Namespace: "esa1", Name: "cert"}
+	notAfter := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+	SetCertExpiry(nsn, &notAfter)
+	require.Equal(t, float64(notAfter.Unix()), testutil.ToFloat64(certExpiry.WithLabelValues("esa1", "cert")))
+	SetCertExpiry(nsn, nil)
+	require.Equal(t, 0, testutil.CollectAndCount(certExpiry))
+}
+
+func TestPromDuration(t *testing.T) {
+	testCases := []struct {
+		desc string
+		d    time.Duration
+		want string
+	}{
+		{desc: "test hours", d: 720 * time.Hour, want: "720h"},
+		{desc: "test minutes", d: 90 * time.Minute, want: "90m"},
+		{desc: "test seconds", d: 45 * time.Second, want: "45s"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.want, promDuration(tc.d))
+		})
+	}
+}
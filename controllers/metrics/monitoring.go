@@ -0,0 +1,211 @@
+package metrics
+
+import (
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"time"
+)
+
+// AlertOptions are the thresholds of the alerts of PrometheusRule.
+type AlertOptions struct {
+	// Namespace of the PrometheusRule.
+	Namespace string
+	// FailedFor is how long an Elastalert is FAILED before it alerts.
+	FailedFor time.Duration
+	// RuleStalledAfter is how long a rule may not run before it alerts. The runs are read every 10 minutes.
+	RuleStalledAfter time.Duration
+	// CertExpiryWarning is how long before the elasticsearch cert expires it alerts.
+	CertExpiryWarning time.Duration
+}
+
+// CertExpiryWarning is how long before the elasticsearch cert of an Elastalert expires the operator warns.
+const CertExpiryWarning = 30 * 24 * time.Hour
+
+// DefaultAlertOptions are the thresholds of the alerts, in the namespace of the operator manifests.
+var DefaultAlertOptions = AlertOptions{
+	Namespace:         "alert",
+	FailedFor:         5 * time.Minute,
+	RuleStalledAfter:  30 * time.Minute,
+	CertExpiryWarning: CertExpiryWarning,
+}
+
+// PrometheusRuleObject is a PrometheusRule of the prometheus operator.
+type PrometheusRuleObject struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Metadata   ObjectMeta         `json:"metadata"`
+	Spec       PrometheusRuleSpec `json:"spec"`
+}
+
+// ObjectMeta is the part of the metadata of an object PrometheusRule sets.
+type ObjectMeta struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// PrometheusRuleSpec holds the rule groups of a PrometheusRule.
+type PrometheusRuleSpec struct {
+	Groups []RuleGroup `json:"groups"`
+}
+
+// RuleGroup is a group of alerting rules.
+type RuleGroup struct {
+	Name  string `json:"name"`
+	Rules []Rule `json:"rules"`
+}
+
+// Rule is an alerting rule.
+type Rule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// instance is the Elastalert of an alert in its annotations.
+var instance = fmt.Sprintf("{{ $labels.%s }}/{{ $labels.%s }}", NamespaceLabel, ElastalertLabel)
+
+// PrometheusRule returns the PrometheusRule alerting on FAILED Elastalerts, on rules which stopped running and on
+// expiring elasticsearch certs.
+func PrometheusRule(opts AlertOptions) *PrometheusRuleObject {
+	rules := []Rule{
+		{
+			Alert: "ElastalertFailed",
+			Expr:  fmt.Sprintf(`%s{%s="%s"} == 1`, InstancePhase.Name, PhaseLabel, esv1alpha1.ElastAlertPhraseFailed),
+			For:   promDuration(opts.FailedFor),
+			Labels: map[string]string{
+				"severity": "critical",
+			},
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Elastalert %s failed.", instance),
+				"description": fmt.Sprintf("Elastalert %s has been %s for %s, see its Ready condition and status.lastFailure.", instance, esv1alpha1.ElastAlertPhraseFailed, promDuration(opts.FailedFor)),
+			},
+		},
+		{
+			Alert: "ElastalertRuleStalled",
+			// a suspended Elastalert runs no rule.
+			Expr: fmt.Sprintf(`time() - %s > %d unless on (%s, %s) %s{%s="%s"} == 1`,
+				RuleLastRun.Name, int64(opts.RuleStalledAfter.Seconds()),
+				NamespaceLabel, ElastalertLabel, InstancePhase.Name, PhaseLabel, esv1alpha1.ElastAlertPhraseSuspended),
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Rule {{ $labels.%s }} of Elastalert %s stopped running.", RuleLabel, instance),
+				"description": fmt.Sprintf("Rule {{ $labels.%s }} of Elastalert %s last ran {{ $value | humanizeDuration }} ago.", RuleLabel, instance),
+			},
+		},
+		{
+			Alert: "ElastalertCertExpiring",
+			Expr:  fmt.Sprintf(`%s - time() < %d > 0`, CertExpiry.Name, int64(opts.CertExpiryWarning.Seconds())),
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("The elasticsearch cert of Elastalert %s expires soon.", instance),
+				"description": fmt.Sprintf("The elasticsearch cert of Elastalert %s expires in {{ $value | humanizeDuration }}.", instance),
+			},
+		},
+		{
+			Alert: "ElastalertCertExpired",
+			Expr:  fmt.Sprintf(`%s - time() <= 0`, CertExpiry.Name),
+			Labels: map[string]string{
+				"severity": "critical",
+			},
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("The elasticsearch cert of Elastalert %s expired.", instance),
+				"description": fmt.Sprintf("The elasticsearch cert of Elastalert %s expired, it cannot connect to elasticsearch.", instance),
+			},
+		},
+	}
+	return &PrometheusRuleObject{
+		APIVersion: "monitoring.coreos.com/v1",
+		Kind:       "PrometheusRule",
+		Metadata: ObjectMeta{
+			Name:      "elastalert-operator",
+			Namespace: opts.Namespace,
+			Labels:    map[string]string{"app": "elastalert-operator"},
+		},
+		Spec: PrometheusRuleSpec{
+			Groups: []RuleGroup{{Name: "elastalert-operator", Rules: rules}},
+		},
+	}
+}
+
+// GrafanaDashboard returns a Grafana dashboard of the Elastalerts by phase, of the latest runs of their rules and of
+// the expiry of their elasticsearch certs.
+func GrafanaDashboard() map[string]interface{} {
+	selector := fmt.Sprintf(`%s=~"$namespace"`, NamespaceLabel)
+	legend := fmt.Sprintf("{{%s}}/{{%s}}", NamespaceLabel, ElastalertLabel)
+	panels := []map[string]interface{}{
+		panel(1, "Elastalerts by phase", "stat", 0, 0, 24, 4, "short",
+			target(fmt.Sprintf(`sum by (%s) (%s{%s})`, PhaseLabel, InstancePhase.Name, selector), fmt.Sprintf("{{%s}}", PhaseLabel))),
+		panel(2, "Phase", "timeseries", 0, 4, 24, 8, "short",
+			target(fmt.Sprintf(`max by (%s, %s, %s) (%s{%s}) == 1`, NamespaceLabel, ElastalertLabel, PhaseLabel, InstancePhase.Name, selector),
+				legend+fmt.Sprintf(" {{%s}}", PhaseLabel))),
+		panel(3, "Time since the last run of the rules", "timeseries", 0, 12, 12, 8, "s",
+			target(fmt.Sprintf(`time() - %s{%s}`, RuleLastRun.Name, selector), legend+fmt.Sprintf(" {{%s}}", RuleLabel))),
+		panel(4, "Time until the elasticsearch certs expire", "timeseries", 12, 12, 12, 8, "s",
+			target(fmt.Sprintf(`%s{%s} - time()`, CertExpiry.Name, selector), legend)),
+	}
+	return map[string]interface{}{
+		"title":         "Elastalert Operator",
+		"uid":           "elastalert-operator",
+		"tags":          []string{"elastalert"},
+		"editable":      true,
+		"schemaVersion": 30,
+		"refresh":       "1m",
+		"time":          map[string]interface{}{"from": "now-6h", "to": "now"},
+		"panels":        panels,
+		"templating": map[string]interface{}{
+			"list": []map[string]interface{}{
+				{
+					"name":  "datasource",
+					"label": "Data source",
+					"type":  "datasource",
+					"query": "prometheus",
+				},
+				{
+					"name":       "namespace",
+					"label":      "Namespace",
+					"type":       "query",
+					"datasource": "$datasource",
+					"query":      fmt.Sprintf("label_values(%s, %s)", InstancePhase.Name, NamespaceLabel),
+					"refresh":    2,
+					"multi":      true,
+					"includeAll": true,
+					"allValue":   ".*",
+				},
+			},
+		},
+	}
+}
+
+func panel(id int, title, kind string, x, y, w, h int, unit string, targets ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          id,
+		"title":       title,
+		"type":        kind,
+		"datasource":  "$datasource",
+		"gridPos":     map[string]interface{}{"x": x, "y": y, "w": w, "h": h},
+		"fieldConfig": map[string]interface{}{"defaults": map[string]interface{}{"unit": unit}},
+		"targets":     targets,
+	}
+}
+
+func target(expr, legend string) map[string]interface{} {
+	return map[string]interface{}{"expr": expr, "legendFormat": legend, "refId": "A"}
+}
+
+// promDuration formats d the way prometheus reads durations.
+func promDuration(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return fmt.Sprintf("%ds", d/time.Second)
+}
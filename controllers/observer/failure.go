@@ -0,0 +1,197 @@
+package observer
+
+import (
+	"context"
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/event"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"reflect"
+	"regexp"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"time"
+)
+
+const (
+	// logTailLines and logTailBytes bound the log tail recorded in status.lastFailure.
+	logTailLines int64 = 20
+	logTailBytes int64 = 4096
+	// logTailTimeout bounds reading the log tail, so that a slow kubelet never holds the health check.
+	logTailTimeout = 5 * time.Second
+	// redacted replaces the secrets found in the log tail.
+	redacted = "<redacted>"
+	// oomKilled is the reason of the termination of a container killed for exceeding its memory limit.
+	oomKilled = "OOMKilled"
+)
+
+// podFailureRank orders the reasons of pod failures, the most relevant first. OOMKilled tells why a container crash
+// loops, and a pod which cannot be scheduled or pulled never runs at all.
+var podFailureRank = []string{
+	esv1alpha1.PodFailureUnschedulable,
+	esv1alpha1.PodFailureImagePullBackOff,
+	esv1alpha1.PodFailureOOMKilled,
+	esv1alpha1.PodFailureCrashLoopBackOff,
+}
+
+// logSecrets match the secrets ElastAlert may log, such as the sensitive options of a config dump, an auth header or
+// the user info of a url. The groups around the secret are kept.
+var logSecrets = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)((?:` + strings.Join(podspec.SensitiveNames, "|") + `)[\w.-]*['"]?\s*[:=]\s*['"]?(?:(?:basic|bearer|apikey)\s+)?)[^\s'",}]+`),
+	regexp.MustCompile(`(?i)((?:basic|bearer|apikey)\s+)[A-Za-z0-9+/=._~-]+`),
+	regexp.MustCompile(`(://[^/\s:@]+:)[^/\s@]+(@)`),
+}
+
+// redactLog replaces the secrets found in the log tail with a placeholder.
+func redactLog(tail string) string {
+	for _, re := range logSecrets {
+		tail = re.ReplaceAllString(tail, "${1}"+redacted+"${2}")
+	}
+	return tail
+}
+
+// PodLogs reads the tail of the log of a container of a pod, of its previous run if previous is true.
+type PodLogs func(ctx context.Context, namespace, pod, container string, previous bool) (string, error)
+
+// NewPodLogs returns a PodLogs reading the logs with clientset.
+func NewPodLogs(clientset kubernetes.Interface) PodLogs {
+	return func(ctx context.Context, namespace, pod, container string, previous bool) (string, error) {
+		lines, bytes := logTailLines, logTailBytes
+		raw, err := clientset.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+			Container:  container,
+			Previous:   previous,
+			TailLines:  &lines,
+			LimitBytes: &bytes,
+		}).DoRaw(ctx)
+		if err != nil {
+			return "", err
+		}
+		return string(raw), nil
+	}
+}
+
+// reportPodFailure records the most relevant failure of the pods in status.lastFailure and emits it as a PodFailed
+// event. Nothing is reported when no pod failed for a known reason.
+func reportPodFailure(c client.Client, recorder record.EventRecorder, logs PodLogs, ctx context.Context, ea *esv1alpha1.Elastalert, pods []corev1.Pod, now time.Time) error {
+	failure, previous := diagnosePods(pods, now)
+	if failure == nil {
+		return nil
+	}
+	if logs != nil && failure.Reason != esv1alpha1.PodFailureUnschedulable && failure.Reason != esv1alpha1.PodFailureImagePullBackOff {
+		logsCtx, cancel := context.WithTimeout(ctx, logTailTimeout)
+		tail, err := logs(logsCtx, ea.Namespace, failure.Pod, failure.Container, previous)
+		cancel()
+		if err != nil {
+			log.Error(err, "Failed to read log of failed pod.", "Elastalert.Namespace", ea.Namespace, "Elastalert.Name", ea.Name, "Pod", failure.Pod)
+		}
+		failure.LogTail = redactLog(tail)
+	}
+	EmitK8sEvent(recorder, ea, corev1.EventTypeWarning, event.EventReasonPodFailed, podFailureMessage(failure))
+	return UpdateLastFailure(c, ctx, ea, failure)
+}
+
+// diagnosePods returns the most relevant failure of the pods, nil if none failed for a known reason, and whether the
+// log telling about it is the one of the previous run of the container.
+func diagnosePods(pods []corev1.Pod, now time.Time) (*esv1alpha1.PodFailure, bool) {
+	var found *esv1alpha1.PodFailure
+	var previous bool
+	for i := range pods {
+		failure, p := diagnosePod(&pods[i])
+		if failure == nil {
+			continue
+		}
+		if found == nil || rank(failure.Reason) < rank(found.Reason) || rank(failure.Reason) == rank(found.Reason) && failure.Pod < found.Pod {
+			found, previous = failure, p
+		}
+	}
+	if found != nil {
+		found.Time = metav1.Time{Time: now}
+	}
+	return found, previous
+}
+
+// diagnosePod tells why the pod or its elastalert container failed, nil if it did not fail for a known reason.
+func diagnosePod(pod *corev1.Pod) (*esv1alpha1.PodFailure, bool) {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse && cond.Reason == corev1.PodReasonUnschedulable {
+			return &esv1alpha1.PodFailure{Reason: esv1alpha1.PodFailureUnschedulable, Pod: pod.Name, Message: cond.Message}, false
+		}
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name != podspec.DefaultElastAlertName {
+			continue
+		}
+		failure := &esv1alpha1.PodFailure{Pod: pod.Name, Container: cs.Name, RestartCount: cs.RestartCount}
+		terminated := cs.State.Terminated
+		if terminated == nil {
+			terminated = cs.LastTerminationState.Terminated
+		}
+		waiting := cs.State.Waiting
+		switch {
+		case waiting != nil && (waiting.Reason == "ImagePullBackOff" || waiting.Reason == "ErrImagePull"):
+			failure.Reason = esv1alpha1.PodFailureImagePullBackOff
+			failure.Message = waiting.Message
+		case cs.State.Running == nil && terminated != nil && terminated.Reason == oomKilled:
+			// a container running again after being killed is not the failure.
+			failure.Reason = esv1alpha1.PodFailureOOMKilled
+			failure.Message = terminationMessage(terminated)
+		case waiting != nil && waiting.Reason == crashLoopBackOff:
+			failure.Reason = esv1alpha1.PodFailureCrashLoopBackOff
+			failure.Message = terminationMessage(terminated)
+		default:
+			return nil, false
+		}
+		// a container waiting to run again failed in its previous run.
+		return failure, cs.State.Terminated == nil
+	}
+	return nil, false
+}
+
+// terminationMessage describes how a container terminated, with the message it wrote to its termination log.
+func terminationMessage(terminated *corev1.ContainerStateTerminated) string {
+	if terminated == nil {
+		return ""
+	}
+	if message := strings.TrimSpace(terminated.Message); message != "" {
+		return fmt.Sprintf("exited with code %d: %s", terminated.ExitCode, message)
+	}
+	return fmt.Sprintf("exited with code %d", terminated.ExitCode)
+}
+
+func podFailureMessage(failure *esv1alpha1.PodFailure) string {
+	message := fmt.Sprintf("Pod %s is %s", failure.Pod, failure.Reason)
+	if failure.RestartCount > 0 {
+		message += fmt.Sprintf(", restarted %d times", failure.RestartCount)
+	}
+	if failure.Message != "" {
+		message += ": " + failure.Message
+	}
+	return message + "."
+}
+
+func rank(reason string) int {
+	for i, r := range podFailureRank {
+		if r == reason {
+			return i
+		}
+	}
+	return len(podFailureRank)
+}
+
+// UpdateLastFailure records the failure of the pods of the Elastalert in status.
+func UpdateLastFailure(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert, failure *esv1alpha1.PodFailure) error {
+	original := e.DeepCopy()
+	e.Status.LastFailure = failure
+	if reflect.DeepEqual(original.Status, e.Status) {
+		return nil
+	}
+	if err := c.Status().Patch(ctx, e, client.MergeFrom(original)); err != nil {
+		log.Error(err, "Failed to update elastalert last failure", "Elastalert.Name", e.Name)
+		return err
+	}
+	return nil
+}
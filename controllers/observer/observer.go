@@ -2,9 +2,13 @@ package observer
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
 	"github.com/toughnoah/elastalert-operator/controllers/event"
+	"github.com/toughnoah/elastalert-operator/controllers/metrics"
 	"github.com/toughnoah/elastalert-operator/controllers/podspec"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -13,235 +17,378 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	"reflect"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sync"
+	"sort"
+	"strings"
 	"time"
 )
 
-const name = "observation"
+const (
+	name = "observation"
+	// certExpiryWarning is how long before the elasticsearch cert expires CertExpiring events are emitted.
+	certExpiryWarning = metrics.CertExpiryWarning
+	// progressDeadlineExceeded is the reason of the Progressing condition of a deployment whose rollout is stuck.
+	progressDeadlineExceeded = "ProgressDeadlineExceeded"
+	// newReplicaSetAvailable is the reason of the Progressing condition of a deployment whose rollout is complete.
+	newReplicaSetAvailable = "NewReplicaSetAvailable"
+	// crashLoopBackOff is the reason of the waiting state of a container restarted with a back-off.
+	crashLoopBackOff = "CrashLoopBackOff"
+)
 
 var log = ctrl.Log.WithName(name)
 
-// Observer regularly check the health of elastalert deployment
-// in a thread-safe way
-type Observer struct {
-	elastalert          types.NamespacedName
-	creationTime        time.Time
-	stopChan            chan struct{}
-	stopOnce            sync.Once
-	mutex               sync.RWMutex
-	ObservationInterval time.Duration
-	client              client.Client
-	recorder            record.EventRecorder
-}
-
-// NewObserver creates and starts an Observer
-func NewObserver(c client.Client, elastalert types.NamespacedName, interval time.Duration, recorder record.EventRecorder) *Observer {
-	observer := Observer{
-		elastalert:          elastalert,
-		client:              c,
-		creationTime:        time.Now(),
-		stopChan:            make(chan struct{}),
-		stopOnce:            sync.Once{},
-		ObservationInterval: interval,
-		recorder:            recorder,
-	}
-	return &observer
-}
-
-// Start the observer in a separate goroutine
-func (o *Observer) Start() {
-	log.Info(
-		"Starting observer for elastalert instance.",
-		"namespace", o.elastalert.Namespace,
-		"elastalert", o.elastalert.Name,
-	)
-	go o.runPeriodically()
-}
-
-// Stop the observer loop
-func (o *Observer) Stop() {
-	log.Info(
-		"Stopping observer for deleted elastalert instance.",
-		"namespace", o.elastalert.Namespace,
-		"elastalert", o.elastalert.Name,
-	)
-	o.stopOnce.Do(func() {
-		close(o.stopChan)
-	})
-}
-
-func (o *Observer) runPeriodically() {
-	ticker := time.NewTicker(o.ObservationInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			o.checkDeploymentHeath()
-		case <-o.stopChan:
-			return
-		}
-	}
-}
-func (o *Observer) checkDeploymentHeath() error {
-	ea := &esv1alpha1.Elastalert{}
-	err := o.client.Get(context.Background(), o.elastalert, ea)
-	if err != nil {
-		log.Error(err, "Failed to get elastalert instance while observing.", "namespace", o.elastalert.Namespace, "elastalert", o.elastalert.Name)
-		return err
+// CheckHealth evaluates the health of the deployment of the Elastalert at now, as its health policy tells, and records
+// it in status. It is run by the reconciler, on changes of the deployment and its pods and periodically, so that status
+// has a single writer. When it marks the Elastalert FAILED for its pods, why they failed is recorded in
+// status.lastFailure, with the tail of the log read with logs unless nil. It returns when to check again.
+func CheckHealth(c client.Client, recorder record.EventRecorder, logs PodLogs, ctx context.Context, ea *esv1alpha1.Elastalert, now time.Time) (time.Duration, error) {
+	interval := podspec.HealthInterval(ea)
+	if podspec.IsPaused(ea) {
+		log.V(1).Info("Skipping health check of paused elastalert instance.", "Elastalert.Namespace", ea.Namespace, "Elastalert.Name", ea.Name)
+		return interval, nil
 	}
 	dep := &appsv1.Deployment{}
-	err = o.client.Get(context.Background(), o.elastalert, dep)
+	err := c.Get(ctx, types.NamespacedName{Namespace: ea.Namespace, Name: ea.Name}, dep)
 	if err != nil {
-		log.Error(err, "Failed to get deployment instance while observing.", "namespace", o.elastalert.Namespace, "elastalert", o.elastalert.Name)
-		EmitK8sEvent(o.recorder, ea, corev1.EventTypeWarning, event.EventReasonError, "Get deployment instance failed while observing.")
-		return UpdateElastalertStatus(o.client, context.Background(), ea, esv1alpha1.ActionFailed)
+		log.Error(err, "Failed to get deployment instance while checking health.", "Elastalert.Namespace", ea.Namespace, "Elastalert.Name", ea.Name)
+		EmitFailure(recorder, ea, event.EventReasonError, "Failed to get deployment while checking health", err)
+		return interval, updateUnavailable(c, ctx, ea, esv1alpha1.ElastAlertDeploymentMissingReason, fmt.Sprintf("Failed to get %s: %v.", event.Object("Deployment", ea.Namespace, ea.Name), err))
 	}
-	if dep.Status.AvailableReplicas != *dep.Spec.Replicas {
-		log.Error(err, "AvailableReplicas of deployment instance is 0 .", "namespace", o.elastalert.Namespace, "elastalert", o.elastalert.Name)
-		EmitK8sEvent(o.recorder, ea, corev1.EventTypeWarning, event.EventReasonError, "AvailableReplicas of deployment instance is 0.")
-		return UpdateElastalertStatus(o.client, context.Background(), ea, esv1alpha1.ActionFailed)
+	if err = UpdateRunningImage(c, ctx, ea); err != nil {
+		log.Error(err, "Failed to update running image while checking health.", "Elastalert.Namespace", ea.Namespace, "Elastalert.Name", ea.Name)
 	}
+	checkCertExpiry(c, recorder, ctx, ea, now)
+	if ea.Spec.Suspend || podspec.MaintenanceSuspended(ea) {
+		// no replica is expected to be available.
+		if err = resetHealthFailures(c, ctx, ea); err != nil {
+			return interval, err
+		}
+		message := fmt.Sprintf("ElastAlert %s is suspended, its deployment is scaled to zero.", ea.Name)
+		return interval, UpdateStatus(c, ctx, ea,
+			NewCondition(ea, esv1alpha1.ElastAlertAvailableType, metav1.ConditionFalse, esv1alpha1.ElastAlertSuspendedReason, message),
+			NewCondition(ea, esv1alpha1.ElastAlertDegradedType, metav1.ConditionFalse, esv1alpha1.ElastAlertSuspendedReason, message),
+		)
+	}
+	deployment := event.Object("Deployment", dep.Namespace, dep.Name)
+	cond := progressingCondition(dep)
+	if dep.Status.ObservedGeneration < dep.Generation || cond != nil && cond.Status == corev1.ConditionTrue && cond.Reason != newReplicaSetAvailable {
+		// the status of a deployment being rolled out tells about the old pods, its next change is waited for. The
+		// phase is refreshed only, images may be upgraded meanwhile. Crash looping pods are reported at once though,
+		// the deployment only gives up on them at its progress deadline.
+		log.V(1).Info("Deployment instance is being rolled out.", "Elastalert.Namespace", ea.Namespace, "Elastalert.Name", ea.Name)
+		pods := &corev1.PodList{}
+		if err = c.List(ctx, pods, client.InNamespace(ea.Namespace), client.MatchingLabels(podspec.InstanceLabels(ea.Name))); err != nil {
+			return interval, err
+		}
+		crashLooping := crashLoopingPods(pods.Items)
+		if len(crashLooping) == 0 {
+			return interval, UpdateStatus(c, ctx, ea)
+		}
+		message := fmt.Sprintf("Pods %s of %s are crash looping during its rollout.", strings.Join(crashLooping, ", "), deployment)
+		EmitK8sEvent(recorder, ea, corev1.EventTypeWarning, event.EventReasonDeploymentUnavailable, message)
+		if podspec.FailOnCrashLoop(ea) {
+			return interval, failHealth(c, recorder, logs, ctx, ea, pods.Items, esv1alpha1.ElastAlertCrashLoopBackOffReason, message, now)
+		}
+		if err = reportPodFailure(c, recorder, logs, ctx, ea, pods.Items, now); err != nil {
+			return interval, err
+		}
+		return interval, UpdateStatus(c, ctx, ea,
+			NewCondition(ea, esv1alpha1.ElastAlertDegradedType, metav1.ConditionTrue, esv1alpha1.ElastAlertCrashLoopBackOffReason, message),
+		)
+	}
+	replicas := fmt.Sprintf("%d of %d replicas of %s are available.", dep.Status.AvailableReplicas, *dep.Spec.Replicas, deployment)
 	if dep.Status.AvailableReplicas == *dep.Spec.Replicas {
 		log.V(1).Info(
 			"Updating Elastalert resources phase to SUCCESS.",
-			"Elastalert.Namespace", o.elastalert.Namespace,
-			"elastalert", o.elastalert.Name,
+			"Elastalert.Namespace", ea.Namespace,
+			"Elastalert.Name", ea.Name,
+		)
+		if err = resetHealthFailures(c, ctx, ea); err != nil {
+			return interval, err
+		}
+		EmitK8sEvent(recorder, ea, corev1.EventTypeNormal, event.EventReasonDeploymentAvailable, "Deployment has been stabilized.")
+		return interval, UpdateStatus(c, ctx, ea,
+			NewCondition(ea, esv1alpha1.ElastAlertAvailableType, metav1.ConditionTrue, esv1alpha1.ElastAlertReplicasAvailableReason, replicas),
+			NewCondition(ea, esv1alpha1.ElastAlertDegradedType, metav1.ConditionFalse, esv1alpha1.ElastAlertAsExpectedReason, replicas),
 		)
-		EmitK8sEvent(o.recorder, ea, corev1.EventTypeNormal, event.EventReasonSuccess, "Deployment has been stabilized.")
-		return UpdateElastalertStatus(o.client, context.Background(), ea, esv1alpha1.ActionSuccess)
+	}
 
+	log.V(1).Info("Replicas of deployment instance are unavailable.", "Elastalert.Namespace", ea.Namespace, "Elastalert.Name", ea.Name, "available", dep.Status.AvailableReplicas)
+	pods := &corev1.PodList{}
+	if err = c.List(ctx, pods, client.InNamespace(ea.Namespace), client.MatchingLabels(podspec.InstanceLabels(ea.Name))); err != nil {
+		return interval, err
+	}
+	switch crashLooping := crashLoopingPods(pods.Items); {
+	case len(crashLooping) != 0 && podspec.FailOnCrashLoop(ea):
+		message := fmt.Sprintf("Pods %s of %s are crash looping.", strings.Join(crashLooping, ", "), deployment)
+		EmitK8sEvent(recorder, ea, corev1.EventTypeWarning, event.EventReasonDeploymentUnavailable, message)
+		return interval, failHealth(c, recorder, logs, ctx, ea, pods.Items, esv1alpha1.ElastAlertCrashLoopBackOffReason, message, now)
+	case cond != nil && cond.Status == corev1.ConditionFalse && cond.Reason == progressDeadlineExceeded:
+		// the deployment gave up already, the grace period and the threshold would only delay it.
+		message := fmt.Sprintf("Rollout of %s is stuck: %s", deployment, cond.Message)
+		EmitK8sEvent(recorder, ea, corev1.EventTypeWarning, event.EventReasonDeploymentRolloutStuck, message)
+		return interval, failHealth(c, recorder, logs, ctx, ea, pods.Items, esv1alpha1.ElastAlertRolloutStuckReason, message, now)
 	}
+	tolerated := NewCondition(ea, esv1alpha1.ElastAlertDegradedType, metav1.ConditionTrue, esv1alpha1.ElastAlertHealthCheckFailingReason, replicas)
+	if health := ea.Status.Health; health != nil && health.RolloutTime != nil {
+		if graceEnd := health.RolloutTime.Add(podspec.HealthGracePeriod(ea)); now.Before(graceEnd) {
+			log.V(1).Info("Tolerating unavailable replicas during the grace period.", "Elastalert.Namespace", ea.Namespace, "Elastalert.Name", ea.Name, "until", graceEnd)
+			next := interval
+			if untilEnd := graceEnd.Sub(now); untilEnd < interval {
+				next = untilEnd
+			}
+			return next, UpdateStatus(c, ctx, ea, tolerated)
+		}
+	}
+	failures, err := countHealthFailure(c, ctx, ea, interval, now)
+	if err != nil {
+		return interval, err
+	}
+	if threshold := podspec.HealthFailureThreshold(ea); failures < threshold {
+		log.V(1).Info("Health check failed, below the failure threshold.", "Elastalert.Namespace", ea.Namespace, "Elastalert.Name", ea.Name, "failures", failures, "threshold", threshold)
+		return interval, UpdateStatus(c, ctx, ea, tolerated)
+	}
+	EmitK8sEvent(recorder, ea, corev1.EventTypeWarning, event.EventReasonDeploymentUnavailable, replicas)
+	return interval, failHealth(c, recorder, logs, ctx, ea, pods.Items, esv1alpha1.ElastAlertReplicasUnavailableReason, replicas, now)
+}
 
-	return nil
+// failHealth reports why the pods failed and marks the Elastalert unavailable for reason.
+func failHealth(c client.Client, recorder record.EventRecorder, logs PodLogs, ctx context.Context, ea *esv1alpha1.Elastalert, pods []corev1.Pod, reason, message string, now time.Time) error {
+	if err := reportPodFailure(c, recorder, logs, ctx, ea, pods, now); err != nil {
+		return err
+	}
+	return updateUnavailable(c, ctx, ea, reason, message)
 }
 
-type Manager struct {
-	observerLock sync.RWMutex
-	observers    map[types.NamespacedName]*Observer
+// updateUnavailable records in the Available and Degraded conditions that the replicas are unavailable for reason.
+func updateUnavailable(c client.Client, ctx context.Context, ea *esv1alpha1.Elastalert, reason, message string) error {
+	return UpdateStatus(c, ctx, ea,
+		NewCondition(ea, esv1alpha1.ElastAlertAvailableType, metav1.ConditionFalse, reason, message),
+		NewCondition(ea, esv1alpha1.ElastAlertDegradedType, metav1.ConditionTrue, reason, message),
+	)
 }
 
-func NewManager() *Manager {
-	return &Manager{
-		observers: make(map[types.NamespacedName]*Observer),
+// countHealthFailure counts a failed check, unless one was counted less than an interval ago, so that checks triggered
+// by changes of the pods do not use the threshold up. It returns the consecutive failures.
+func countHealthFailure(c client.Client, ctx context.Context, ea *esv1alpha1.Elastalert, interval time.Duration, now time.Time) (int32, error) {
+	health := &esv1alpha1.HealthStatus{}
+	if ea.Status.Health != nil {
+		health = ea.Status.Health.DeepCopy()
 	}
+	if health.LastFailureTime != nil && now.Before(health.LastFailureTime.Add(interval)) {
+		return health.ConsecutiveFailures, nil
+	}
+	health.ConsecutiveFailures++
+	health.LastFailureTime = &metav1.Time{Time: now}
+	return health.ConsecutiveFailures, UpdateHealthStatus(c, ctx, ea, health)
 }
 
-func (m *Manager) getObserver(key types.NamespacedName) (*Observer, bool) {
-	m.observerLock.RLock()
-	defer m.observerLock.RUnlock()
-
-	observer, ok := m.observers[key]
-	return observer, ok
+// resetHealthFailures forgets the failed checks after a healthy one.
+func resetHealthFailures(c client.Client, ctx context.Context, ea *esv1alpha1.Elastalert) error {
+	if ea.Status.Health == nil || ea.Status.Health.ConsecutiveFailures == 0 && ea.Status.Health.LastFailureTime == nil {
+		return nil
+	}
+	return UpdateHealthStatus(c, ctx, ea, &esv1alpha1.HealthStatus{RolloutTime: ea.Status.Health.RolloutTime})
 }
 
-func (m *Manager) Observe(elastalert *esv1alpha1.Elastalert, c client.Client, recorder record.EventRecorder) *Observer {
-	nsName := types.NamespacedName{
-		Namespace: elastalert.Namespace,
-		Name:      elastalert.Name,
+// crashLoopingPods returns the names of the pods whose elastalert container is in CrashLoopBackOff or was OOMKilled.
+func crashLoopingPods(pods []corev1.Pod) []string {
+	var names []string
+	for i := range pods {
+		if failure, _ := diagnosePod(&pods[i]); failure != nil &&
+			(failure.Reason == esv1alpha1.PodFailureCrashLoopBackOff || failure.Reason == esv1alpha1.PodFailureOOMKilled) {
+			names = append(names, pods[i].Name)
+		}
 	}
+	sort.Strings(names)
+	return names
+}
 
-	observer, exists := m.getObserver(nsName)
-	if !exists {
-		return m.createOrReplaceObserver(nsName, c, recorder)
+// checkCertExpiry reports when the elasticsearch cert the pods mount expires, and emits a CertExpiring event when it
+// expires within certExpiryWarning of now, or has expired.
+func checkCertExpiry(c client.Client, recorder record.EventRecorder, ctx context.Context, ea *esv1alpha1.Elastalert, now time.Time) {
+	nsn := types.NamespacedName{Namespace: ea.Namespace, Name: ea.Name}
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: ea.Namespace, Name: ea.Name + podspec.DefaultCertSuffix}, secret)
+	if err != nil {
+		// no cert is mounted without the secret.
+		metrics.SetCertExpiry(nsn, nil)
+		return
+	}
+	cert := earliestExpiry(secret.Data[podspec.DefaultElasticCertName])
+	if cert == nil {
+		metrics.SetCertExpiry(nsn, nil)
+		return
+	}
+	metrics.SetCertExpiry(nsn, &cert.NotAfter)
+	object := event.Object("Secret", secret.Namespace, secret.Name)
+	switch {
+	case now.After(cert.NotAfter):
+		EmitK8sEvent(recorder, ea, corev1.EventTypeWarning, event.EventReasonCertExpiring, fmt.Sprintf("Cert %s of %s expired at %s.", cert.Subject.CommonName, object, cert.NotAfter.UTC().Format(time.RFC3339)))
+	case now.Add(certExpiryWarning).After(cert.NotAfter):
+		EmitK8sEvent(recorder, ea, corev1.EventTypeWarning, event.EventReasonCertExpiring, fmt.Sprintf("Cert %s of %s expires at %s.", cert.Subject.CommonName, object, cert.NotAfter.UTC().Format(time.RFC3339)))
 	}
-	return observer
 }
 
-// createOrReplaceObserver creates a new observer and adds it to the observers map, replacing existing observers if necessary.
-func (m *Manager) createOrReplaceObserver(elastalert types.NamespacedName, c client.Client, recorder record.EventRecorder) *Observer {
-	m.observerLock.Lock()
-	defer m.observerLock.Unlock()
-
-	observer := NewObserver(c, elastalert, esv1alpha1.ElastAlertObserveInterval, recorder)
-	observer.Start()
-
-	m.observers[elastalert] = observer
-	return observer
+// earliestExpiry returns the certificate of a PEM bundle which expires first, nil if none parses.
+func earliestExpiry(bundle []byte) *x509.Certificate {
+	var earliest *x509.Certificate
+	for {
+		var block *pem.Block
+		block, bundle = pem.Decode(bundle)
+		if block == nil {
+			return earliest
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		if earliest == nil || cert.NotAfter.Before(earliest.NotAfter) {
+			earliest = cert
+		}
+	}
 }
 
-func (m *Manager) StopObserving(key types.NamespacedName) {
-	m.observerLock.Lock()
-	defer m.observerLock.Unlock()
+func progressingCondition(dep *appsv1.Deployment) *appsv1.DeploymentCondition {
+	for i := range dep.Status.Conditions {
+		if dep.Status.Conditions[i].Type == appsv1.DeploymentProgressing {
+			return &dep.Status.Conditions[i]
+		}
+	}
+	return nil
+}
 
-	if observer, ok := m.observers[key]; ok {
-		observer.Stop()
-		delete(m.observers, key)
+// UpdateRunningImage records the image the pods of the Elastalert run, and sets the UpgradeInProgress condition
+// while pods run different images.
+func UpdateRunningImage(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert) error {
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(e.Namespace), client.MatchingLabels(podspec.InstanceLabels(e.Name))); err != nil {
+		return err
 	}
+	// image IDs tell apart images pushed again under the same tag.
+	images := map[string]string{}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name == podspec.DefaultElastAlertName && cs.ImageID != "" {
+				images[cs.ImageID] = cs.Image
+			}
+		}
+	}
+	if len(images) == 0 {
+		return nil
+	}
+	original := e.DeepCopy()
+	if len(images) == 1 {
+		for imageID, image := range images {
+			e.Status.Image = image
+			e.Status.ImageID = imageID
+			e.Status.Version = podspec.ImageVersion(image)
+		}
+		meta.RemoveStatusCondition(&e.Status.Condictions, esv1alpha1.ElastAlertUpgradeInProgressType)
+	} else {
+		var running []string
+		for _, image := range images {
+			running = append(running, image)
+		}
+		sort.Strings(running)
+		meta.SetStatusCondition(&e.Status.Condictions, metav1.Condition{
+			Type:               esv1alpha1.ElastAlertUpgradeInProgressType,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: e.Generation,
+			LastTransitionTime: metav1.NewTime(podspec.GetUtcTime()),
+			Reason:             esv1alpha1.ElastAlertUpgradeInProgressReason,
+			Message:            fmt.Sprintf("Pods of ElastAlert %s run %d images: %s.", e.Name, len(images), strings.Join(running, ", ")),
+		})
+	}
+	if reflect.DeepEqual(original.Status, e.Status) {
+		return nil
+	}
+	if err := c.Status().Patch(ctx, e, client.MergeFrom(original)); err != nil {
+		log.Error(err, "Failed to update elastalert running image", "Elastalert.Name", e.Name)
+		return err
+	}
+	return nil
 }
 
-func UpdateElastalertStatus(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert, flag string) error {
-	condition := NewCondition(e, flag)
-	if err := UpdateStatus(c, ctx, e, condition); err != nil {
+// UpdatePausedCondition sets the Paused condition while the Elastalert is paused with the paused annotation,
+// and removes it otherwise. The phase is left untouched.
+func UpdatePausedCondition(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert, paused bool) error {
+	original := e.DeepCopy()
+	if paused {
+		meta.SetStatusCondition(&e.Status.Condictions, metav1.Condition{
+			Type:               esv1alpha1.ElastAlertPausedType,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: e.Generation,
+			LastTransitionTime: metav1.NewTime(podspec.GetUtcTime()),
+			Reason:             esv1alpha1.ElastAlertPausedReason,
+			Message:            fmt.Sprintf("Reconciliation of ElastAlert %s is paused by annotation %s.", e.Name, podspec.PausedAnnotation),
+		})
+	} else {
+		meta.RemoveStatusCondition(&e.Status.Condictions, esv1alpha1.ElastAlertPausedType)
+	}
+	if reflect.DeepEqual(original.Status, e.Status) {
+		return nil
+	}
+	if err := c.Status().Patch(ctx, e, client.MergeFrom(original)); err != nil {
+		log.Error(err, "Failed to update elastalert paused condition", "Elastalert.Name", e.Name)
 		return err
 	}
 	return nil
 }
 
-func UpdateStatus(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert, condition *metav1.Condition) error {
-	patch := client.MergeFrom(e.DeepCopy())
-	e.Status.Version = esv1alpha1.ElastAlertVersion
-
-	if condition != nil {
-		switch condition.Type {
-		case esv1alpha1.ElastAlertAvailableType:
-			e.Status.Phase = esv1alpha1.ElastAlertPhraseSucceeded
-			meta.SetStatusCondition(&e.Status.Condictions, *condition)
-			meta.RemoveStatusCondition(&e.Status.Condictions, esv1alpha1.ElastAlertUnAvailableType)
-		case esv1alpha1.ElastAlertUnAvailableType:
-			e.Status.Phase = esv1alpha1.ElastAlertPhraseFailed
-			meta.SetStatusCondition(&e.Status.Condictions, *condition)
-			meta.RemoveStatusCondition(&e.Status.Condictions, esv1alpha1.ElastAlertAvailableType)
-		}
-		if err := c.Status().Patch(ctx, e, patch); err != nil {
-			log.Error(err, "Failed to update elastalert failed status", "Elastalert.Name", e.Name, "Status", e.Status.Phase)
-			return err
-		}
-	}
-	if len(e.Status.Condictions) == 0 && condition == nil || e.Status.Condictions[0].ObservedGeneration != e.Generation && condition == nil {
-		e.Status.Phase = esv1alpha1.ElastAlertInitializing
-		if err := c.Status().Patch(ctx, e, patch); err != nil {
-			log.Error(err, "Failed to update elastalert failed status", "Elastalert.Name", e.Name, "Status", e.Status.Phase)
-			return err
-		}
-	}
-	log.V(1).Info(
-		"Update Elastalert resources status success.",
-		"Elastalert.Namespace", e.Name,
-		"Status", e.Status.Phase,
-	)
+// UpdateElasticsearchCondition records the outcome of the elasticsearch preflight in the ElasticsearchReachable condition.
+func UpdateElasticsearchCondition(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert, status metav1.ConditionStatus, reason, message string) error {
+	original := e.DeepCopy()
+	meta.SetStatusCondition(&e.Status.Condictions, metav1.Condition{
+		Type:               esv1alpha1.ElasticsearchReachableType,
+		Status:             status,
+		ObservedGeneration: e.Generation,
+		LastTransitionTime: metav1.NewTime(podspec.GetUtcTime()),
+		Reason:             reason,
+		Message:            message,
+	})
+	if reflect.DeepEqual(original.Status, e.Status) {
+		return nil
+	}
+	if err := c.Status().Patch(ctx, e, client.MergeFrom(original)); err != nil {
+		log.Error(err, "Failed to update elastalert elasticsearch condition", "Elastalert.Name", e.Name)
+		return err
+	}
 	return nil
 }
 
-func NewCondition(e *esv1alpha1.Elastalert, flag string) *metav1.Condition {
-	var condition *metav1.Condition
-	switch flag {
-	case esv1alpha1.ActionSuccess:
-		condition = &metav1.Condition{
-			Type:               esv1alpha1.ElastAlertAvailableType,
-			Status:             esv1alpha1.ElastAlertAvailableStatus,
-			ObservedGeneration: e.Generation,
-			LastTransitionTime: metav1.NewTime(podspec.GetUtcTime()),
-			Reason:             esv1alpha1.ElastAlertAvailableReason,
-			Message:            fmt.Sprintf("ElastAlert %s has successfully progressed.", e.Name),
-		}
-	case esv1alpha1.ActionFailed:
-		condition = &metav1.Condition{
-			Type:               esv1alpha1.ElastAlertUnAvailableType,
-			Status:             esv1alpha1.ElastAlertUnAvailableStatus,
-			ObservedGeneration: e.Generation,
-			LastTransitionTime: metav1.NewTime(podspec.GetUtcTime()),
-			Reason:             esv1alpha1.ElastAlertUnAvailableReason,
-			Message:            fmt.Sprintf("Failed to apply ElastAlert %s resources.", e.Name),
-		}
-	case esv1alpha1.ResourcesCreating:
+// UpdateHealthStatus records the health checks of the Elastalert in status.
+func UpdateHealthStatus(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert, health *esv1alpha1.HealthStatus) error {
+	original := e.DeepCopy()
+	e.Status.Health = health
+	if reflect.DeepEqual(original.Status, e.Status) {
 		return nil
 	}
-	return condition
+	if err := c.Status().Patch(ctx, e, client.MergeFrom(original)); err != nil {
+		log.Error(err, "Failed to update elastalert health status", "Elastalert.Name", e.Name)
+		return err
+	}
+	return nil
 }
 
-func EmitK8sEvent(recorder record.EventRecorder, object runtime.Object, eventtype, reason, messageFmt string) {
-	recorder.Eventf(object, eventtype, reason, messageFmt)
+func EmitK8sEvent(recorder record.EventRecorder, object runtime.Object, eventtype, reason, message string) {
+	recorder.Event(object, eventtype, reason, message)
+}
+
+// EmitFailure emits a warning event telling that action failed with err. The reason is the one of the
+// event.ObjectError in err, reason otherwise.
+func EmitFailure(recorder record.EventRecorder, object runtime.Object, reason, action string, err error) {
+	var objectErr *event.ObjectError
+	if errors.As(err, &objectErr) {
+		reason = objectErr.Reason
+	}
+	recorder.Event(object, corev1.EventTypeWarning, reason, fmt.Sprintf("%s: %v.", action, err))
 }
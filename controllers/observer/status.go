@@ -0,0 +1,132 @@
+package observer
+
+import (
+	"context"
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/metrics"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// legacyConditionTypes are the condition types of former releases, superseded by Reconciled and Available.
+var legacyConditionTypes = []string{"Progressing", "Stopped", "Suspended"}
+
+// UpdateElastalertStatus records the outcome of applying the resources of the Elastalert, which flag tells, in the
+// Reconciled condition.
+func UpdateElastalertStatus(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert, flag string) error {
+	var condition metav1.Condition
+	switch flag {
+	case esv1alpha1.ResourcesCreating:
+		condition = NewCondition(e, esv1alpha1.ElastAlertReconciledType, metav1.ConditionUnknown, esv1alpha1.ElastAlertReconcilingReason,
+			fmt.Sprintf("Applying ElastAlert %s resources.", e.Name))
+	case esv1alpha1.ActionSuccess:
+		condition = NewCondition(e, esv1alpha1.ElastAlertReconciledType, metav1.ConditionTrue, esv1alpha1.ElastAlertReconcileSucceededReason,
+			fmt.Sprintf("ElastAlert %s resources are applied.", e.Name))
+	case esv1alpha1.ActionFailed:
+		condition = NewCondition(e, esv1alpha1.ElastAlertReconciledType, metav1.ConditionFalse, esv1alpha1.ElastAlertReconcileFailedReason,
+			fmt.Sprintf("Failed to apply ElastAlert %s resources.", e.Name))
+	default:
+		return fmt.Errorf("unknown status flag %q", flag)
+	}
+	return UpdateStatus(c, ctx, e, condition)
+}
+
+// UpdateStatus sets the conditions of the Elastalert, then derives the Ready condition and the phase from them.
+func UpdateStatus(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert, conditions ...metav1.Condition) error {
+	original := e.DeepCopy()
+	for _, condition := range conditions {
+		meta.SetStatusCondition(&e.Status.Condictions, condition)
+	}
+	for _, t := range legacyConditionTypes {
+		meta.RemoveStatusCondition(&e.Status.Condictions, t)
+	}
+	if e.Status.Image == "" {
+		// no pod reported its image yet.
+		e.Status.Version = podspec.ElastalertVersion(e)
+	}
+	e.Status.Phase = Phase(e)
+	metrics.SetPhase(types.NamespacedName{Namespace: e.Namespace, Name: e.Name}, e.Status.Phase)
+	meta.SetStatusCondition(&e.Status.Condictions, readyCondition(e))
+	if reflect.DeepEqual(original.Status, e.Status) {
+		return nil
+	}
+	if err := c.Status().Patch(ctx, e, client.MergeFrom(original)); err != nil {
+		log.Error(err, "Failed to update elastalert status", "Elastalert.Name", e.Name, "Status", e.Status.Phase)
+		return err
+	}
+	log.V(1).Info(
+		"Update Elastalert resources status success.",
+		"Elastalert.Namespace", e.Namespace,
+		"Elastalert.Name", e.Name,
+		"Status", e.Status.Phase,
+	)
+	return nil
+}
+
+// NewCondition returns a condition of the generation of the Elastalert.
+func NewCondition(e *esv1alpha1.Elastalert, conditionType string, status metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: e.Generation,
+		LastTransitionTime: metav1.NewTime(podspec.GetUtcTime()),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// Phase derives the phase of the Elastalert from its conditions. A failure to apply the resources comes first, then
+// the health of the current generation. The Available condition of a former generation does not tell about the pods
+// of the current one, which is INITIALIZING until they are checked.
+func Phase(e *esv1alpha1.Elastalert) string {
+	reconciled := meta.FindStatusCondition(e.Status.Condictions, esv1alpha1.ElastAlertReconciledType)
+	available := meta.FindStatusCondition(e.Status.Condictions, esv1alpha1.ElastAlertAvailableType)
+	if available != nil && available.ObservedGeneration != e.Generation {
+		available = nil
+	}
+	switch {
+	case hasStatus(reconciled, metav1.ConditionFalse):
+		return esv1alpha1.ElastAlertPhraseFailed
+	case hasStatus(available, metav1.ConditionFalse) && available.Reason == esv1alpha1.ElastAlertSuspendedReason:
+		return esv1alpha1.ElastAlertPhraseSuspended
+	case hasStatus(available, metav1.ConditionFalse):
+		return esv1alpha1.ElastAlertPhraseFailed
+	case meta.IsStatusConditionTrue(e.Status.Condictions, esv1alpha1.ElastAlertUpgradeInProgressType):
+		return esv1alpha1.ElastAlertPhraseUpgrading
+	case hasStatus(reconciled, metav1.ConditionUnknown) || e.Status.ObservedGeneration != e.Generation || !hasStatus(available, metav1.ConditionTrue):
+		return esv1alpha1.ElastAlertInitializing
+	case meta.IsStatusConditionTrue(e.Status.Condictions, esv1alpha1.ElastAlertDegradedType):
+		return esv1alpha1.ElastAlertPhraseDegraded
+	}
+	return esv1alpha1.ElastAlertPhraseSucceeded
+}
+
+// readyCondition sums the phase of the Elastalert up. It is not Ready with the reason and the message of the
+// condition keeping it from being so.
+func readyCondition(e *esv1alpha1.Elastalert) metav1.Condition {
+	switch e.Status.Phase {
+	case esv1alpha1.ElastAlertPhraseSucceeded, esv1alpha1.ElastAlertPhraseDegraded:
+		return NewCondition(e, esv1alpha1.ElastAlertReadyType, metav1.ConditionTrue, esv1alpha1.ElastAlertRunningReason,
+			fmt.Sprintf("ElastAlert %s is running.", e.Name))
+	case esv1alpha1.ElastAlertPhraseFailed, esv1alpha1.ElastAlertPhraseSuspended:
+		for _, t := range []string{esv1alpha1.ElastAlertReconciledType, esv1alpha1.ElastAlertAvailableType} {
+			if cond := meta.FindStatusCondition(e.Status.Condictions, t); hasStatus(cond, metav1.ConditionFalse) {
+				return NewCondition(e, esv1alpha1.ElastAlertReadyType, metav1.ConditionFalse, cond.Reason, cond.Message)
+			}
+		}
+	case esv1alpha1.ElastAlertPhraseUpgrading:
+		cond := meta.FindStatusCondition(e.Status.Condictions, esv1alpha1.ElastAlertUpgradeInProgressType)
+		return NewCondition(e, esv1alpha1.ElastAlertReadyType, metav1.ConditionFalse, cond.Reason, cond.Message)
+	}
+	return NewCondition(e, esv1alpha1.ElastAlertReadyType, metav1.ConditionFalse, esv1alpha1.ElastAlertInitializingReason,
+		fmt.Sprintf("ElastAlert %s is initializing.", e.Name))
+}
+
+func hasStatus(cond *metav1.Condition, status metav1.ConditionStatus) bool {
+	return cond != nil && cond.Status == status
+}
@@ -0,0 +1,224 @@
+package podspec
+
+import (
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// SMTPAuthUserOption and SMTPAuthPasswordOption index the smtp credentials in AlerterSecretRefs,
+	// they are rendered into the smtp_auth_file instead of the alerters file.
+	SMTPAuthUserOption     = "smtp_auth_user"
+	SMTPAuthPasswordOption = "smtp_auth_password"
+)
+
+// AlerterSecretRefs returns the secret keys referenced by the typed alerters, indexed by the elastalert option they provide.
+func AlerterSecretRefs(a *esv1alpha1.Alerters) map[string]corev1.SecretKeySelector {
+	refs := map[string]corev1.SecretKeySelector{}
+	if a == nil {
+		return refs
+	}
+	if a.Slack != nil {
+		refs["slack_webhook_url"] = a.Slack.WebhookURL
+	}
+	if a.Email != nil && a.Email.Auth != nil {
+		refs[SMTPAuthUserOption] = a.Email.Auth.User
+		refs[SMTPAuthPasswordOption] = a.Email.Auth.Password
+	}
+	if a.PagerDuty != nil {
+		refs["pagerduty_service_key"] = a.PagerDuty.ServiceKey
+	}
+	if a.Opsgenie != nil {
+		refs["opsgenie_key"] = a.Opsgenie.Key
+	}
+	if a.MsTeams != nil {
+		refs["ms_teams_webhook_url"] = a.MsTeams.WebhookURL
+	}
+	if a.HTTPPost != nil {
+		refs["http_post_url"] = a.HTTPPost.URL
+	}
+	return refs
+}
+
+// HasAlerterSecrets determines if the typed alerters or the elasticsearch clusters need the alerters secret.
+func HasAlerterSecrets(e *esv1alpha1.Elastalert) bool {
+	return len(SecretRefs(e)) > 0
+}
+
+// alerterOptions returns the alerter names and the non-secret options of the typed alerters.
+func alerterOptions(a *esv1alpha1.Alerters) ([]string, map[string]interface{}) {
+	var names []string
+	options := map[string]interface{}{}
+	if a.Slack != nil {
+		names = append(names, "slack")
+		setIfNotEmpty(options, "slack_channel_override", a.Slack.ChannelOverride)
+		setIfNotEmpty(options, "slack_username_override", a.Slack.UsernameOverride)
+	}
+	if a.Email != nil {
+		names = append(names, "email")
+		options["email"] = a.Email.To
+		setIfNotEmpty(options, "from_addr", a.Email.FromAddr)
+		setIfNotEmpty(options, "smtp_host", a.Email.SMTPHost)
+		if a.Email.SMTPPort != 0 {
+			options["smtp_port"] = a.Email.SMTPPort
+		}
+		if a.Email.SMTPSSL {
+			options["smtp_ssl"] = true
+		}
+		if a.Email.Auth != nil {
+			options["smtp_auth_file"] = DefaultSMTPAuthFilePath
+		}
+	}
+	if a.PagerDuty != nil {
+		names = append(names, "pagerduty")
+		options["pagerduty_client_name"] = a.PagerDuty.ClientName
+	}
+	if a.Opsgenie != nil {
+		names = append(names, "opsgenie")
+		if len(a.Opsgenie.Recipients) != 0 {
+			options["opsgenie_recipients"] = a.Opsgenie.Recipients
+		}
+		if len(a.Opsgenie.Teams) != 0 {
+			options["opsgenie_teams"] = a.Opsgenie.Teams
+		}
+	}
+	if a.MsTeams != nil {
+		names = append(names, "ms_teams")
+		options["ms_teams_alert_summary"] = a.MsTeams.AlertSummary
+	}
+	if a.HTTPPost != nil {
+		names = append(names, "post")
+		if len(a.HTTPPost.Headers) != 0 {
+			options["http_post_headers"] = a.HTTPPost.Headers
+		}
+		if len(a.HTTPPost.StaticPayload) != 0 {
+			options["http_post_static_payload"] = a.HTTPPost.StaticPayload
+		}
+		if a.HTTPPost.Timeout != 0 {
+			options["http_post_timeout"] = a.HTTPPost.Timeout
+		}
+	}
+	return names, options
+}
+
+func setIfNotEmpty(m map[string]interface{}, key string, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
+
+// PatchAlerterSettings adds the typed alerters to the alert list of every rule and renders their non-secret options,
+// options already defined in a rule take precedence. Rules import the alerters file to pick up the secret options.
+func PatchAlerterSettings(e *esv1alpha1.Elastalert) error {
+	if e.Spec.Alerters == nil {
+		return nil
+	}
+	names, options := alerterOptions(e.Spec.Alerters)
+	hasSecrets := len(AlerterSecretRefs(e.Spec.Alerters)) > 0
+	var ruleArray []esv1alpha1.FreeForm
+	for _, v := range e.Spec.Rule {
+		rule, err := v.GetMap()
+		if err != nil {
+			return err
+		}
+		rule["alert"] = appendAlerterNames(rule["alert"], names)
+		for k, option := range options {
+			if _, ok := rule[k]; !ok {
+				rule[k] = option
+			}
+		}
+		if hasSecrets {
+			if imported, ok := rule["import"]; ok && imported != DefaultAlerterFilePath {
+				return fmt.Errorf("rule %v already imports %v, typed alerters with secrets can not be applied", rule["name"], imported)
+			}
+			rule["import"] = DefaultAlerterFilePath
+		}
+		ruleArray = append(ruleArray, esv1alpha1.NewFreeForm(rule))
+	}
+	e.Spec.Rule = ruleArray
+	return nil
+}
+
+// appendAlerterNames appends the given alerter names to the alert option of a rule, which may be a string or a list.
+func appendAlerterNames(alert interface{}, names []string) []interface{} {
+	alerts := alertList(alert)
+	for _, name := range names {
+		alerts = appendIfMissing(alerts, name)
+	}
+	return alerts
+}
+
+// GenerateAlerterSecret builds the alerters secret from the resolved secret values, indexed as in AlerterSecretRefs.
+func GenerateAlerterSecret(Scheme *runtime.Scheme, e *esv1alpha1.Elastalert, values map[string]string) (*corev1.Secret, error) {
+	se, err := BuildAlerterSecret(e, values)
+	if err != nil {
+		return nil, err
+	}
+	if err = ctrl.SetControllerReference(e, se, Scheme); err != nil {
+		log.Error(
+			err,
+			"Failed to generate alerters Secret",
+			"Elastalert.Namespace", e.Namespace,
+		)
+		return nil, err
+	}
+	return se, nil
+}
+
+func BuildAlerterSecret(e *esv1alpha1.Elastalert, values map[string]string) (*corev1.Secret, error) {
+	var data = map[string][]byte{}
+	alerters := map[string]string{}
+	auth := map[string]string{}
+	clusters := map[string]map[string]string{}
+	for option, value := range values {
+		if cluster, clusterOption, ok := parseClusterCredentialOption(option); ok {
+			if clusters[cluster] == nil {
+				clusters[cluster] = map[string]string{}
+			}
+			clusters[cluster][clusterOption] = value
+			continue
+		}
+		switch option {
+		case SMTPAuthUserOption:
+			auth["user"] = value
+		case SMTPAuthPasswordOption:
+			auth["password"] = value
+		default:
+			alerters[option] = value
+		}
+	}
+	out, err := yaml.Marshal(alerters)
+	if err != nil {
+		return nil, err
+	}
+	data[DefaultAlerterFileName] = out
+	// a rule imports a single file, so the file of a cluster repeats the alerter secrets.
+	for cluster, credentials := range clusters {
+		out, err = yaml.Marshal(Merge(credentials, alerters))
+		if err != nil {
+			return nil, err
+		}
+		data[ClusterFileName(cluster)] = out
+	}
+	if len(auth) != 0 {
+		out, err = yaml.Marshal(auth)
+		if err != nil {
+			return nil, err
+		}
+		data[DefaultSMTPAuthFileName] = out
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      e.Name + DefaultAlerterSecretSuffix,
+			Namespace: e.Namespace,
+			Labels:    InstanceLabels(e.Name),
+		},
+		Data: data,
+	}
+	return secret, nil
+}
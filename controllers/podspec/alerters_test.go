@@ -0,0 +1,210 @@
+package podspec
+
+import (
+	"github.com/stretchr/testify/require"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"testing"
+)
+
+var slackWebhook = corev1.SecretKeySelector{
+	LocalObjectReference: corev1.LocalObjectReference{Name: "slack"},
+	Key:                  "url",
+}
+
+func TestPatchAlerterSettings(t *testing.T) {
+	testCases := []struct {
+		name       string
+		elastalert *esv1alpha1.Elastalert
+		want       []esv1alpha1.FreeForm
+		wantErr    bool
+	}{
+		{
+			name: "test no alerters",
+			elastalert: &esv1alpha1.Elastalert{
+				Spec: esv1alpha1.ElastalertSpec{
+					Rule: []esv1alpha1.FreeForm{
+						esv1alpha1.NewFreeForm(map[string]interface{}{
+							"name": "test-elastalert", "type": "any",
+						}),
+					},
+				},
+			},
+			want: []esv1alpha1.FreeForm{
+				esv1alpha1.NewFreeForm(map[string]interface{}{
+					"name": "test-elastalert", "type": "any",
+				}),
+			},
+		},
+		{
+			name: "test slack and email alerters",
+			elastalert: &esv1alpha1.Elastalert{
+				Spec: esv1alpha1.ElastalertSpec{
+					Rule: []esv1alpha1.FreeForm{
+						esv1alpha1.NewFreeForm(map[string]interface{}{
+							"name": "test-elastalert1", "type": "any",
+						}),
+						esv1alpha1.NewFreeForm(map[string]interface{}{
+							"name": "test-elastalert2", "type": "any", "alert": "post", "slack_channel_override": "#rule",
+						}),
+					},
+					Alerters: &esv1alpha1.Alerters{
+						Slack: &esv1alpha1.SlackAlerter{
+							WebhookURL:      slackWebhook,
+							ChannelOverride: "#alerts",
+						},
+						Email: &esv1alpha1.EmailAlerter{
+							To:       []string{"oncall@test.com"},
+							SMTPHost: "smtp.test.com",
+							Auth: &esv1alpha1.SMTPAuth{
+								User:     corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "smtp"}, Key: "user"},
+								Password: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "smtp"}, Key: "password"},
+							},
+						},
+					},
+				},
+			},
+			want: []esv1alpha1.FreeForm{
+				esv1alpha1.NewFreeForm(map[string]interface{}{
+					"name":                   "test-elastalert1",
+					"type":                   "any",
+					"alert":                  []string{"slack", "email"},
+					"slack_channel_override": "#alerts",
+					"email":                  []string{"oncall@test.com"},
+					"smtp_host":              "smtp.test.com",
+					"smtp_auth_file":         "/etc/elastalert/alerters/smtp_auth.yaml",
+					"import":                 "/etc/elastalert/alerters/alerters.yaml",
+				}),
+				esv1alpha1.NewFreeForm(map[string]interface{}{
+					"name":                   "test-elastalert2",
+					"type":                   "any",
+					"alert":                  []string{"post", "slack", "email"},
+					"slack_channel_override": "#rule",
+					"email":                  []string{"oncall@test.com"},
+					"smtp_host":              "smtp.test.com",
+					"smtp_auth_file":         "/etc/elastalert/alerters/smtp_auth.yaml",
+					"import":                 "/etc/elastalert/alerters/alerters.yaml",
+				}),
+			},
+		},
+		{
+			name: "test rule already imports another file",
+			elastalert: &esv1alpha1.Elastalert{
+				Spec: esv1alpha1.ElastalertSpec{
+					Rule: []esv1alpha1.FreeForm{
+						esv1alpha1.NewFreeForm(map[string]interface{}{
+							"name": "test-elastalert", "type": "any", "import": "base.yaml",
+						}),
+					},
+					Alerters: &esv1alpha1.Alerters{
+						Slack: &esv1alpha1.SlackAlerter{
+							WebhookURL: slackWebhook,
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := PatchAlerterSettings(tc.elastalert)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, len(tc.want), len(tc.elastalert.Spec.Rule))
+			for i := range tc.want {
+				want, err := tc.want[i].GetMap()
+				require.NoError(t, err)
+				have, err := tc.elastalert.Spec.Rule[i].GetMap()
+				require.NoError(t, err)
+				require.Equal(t, want, have)
+			}
+		})
+	}
+}
+
+func TestGenerateAlerterSecret(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &esv1alpha1.Elastalert{})
+	elastalert := esv1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "esa1",
+		},
+	}
+	have, err := GenerateAlerterSecret(s, &elastalert, map[string]string{
+		"slack_webhook_url":    "https://hooks.slack.com/abc",
+		SMTPAuthUserOption:     "user",
+		SMTPAuthPasswordOption: "password",
+	})
+	require.NoError(t, err)
+	want := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-alerters",
+			Namespace: "esa1",
+			Labels: map[string]string{
+				"es.noah.domain/elastalert": "test",
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         "v1",
+					Kind:               "Elastalert",
+					Name:               "test",
+					UID:                "",
+					Controller:         &varTrue,
+					BlockOwnerDeletion: &varTrue,
+				},
+			},
+		},
+		Data: map[string][]byte{
+			"alerters.yaml":  []byte("slack_webhook_url: https://hooks.slack.com/abc\n"),
+			"smtp_auth.yaml": []byte("password: password\nuser: user\n"),
+		},
+	}
+	require.Equal(t, want, *have)
+}
+
+func TestAlerterSecretRefs(t *testing.T) {
+	require.Empty(t, AlerterSecretRefs(nil))
+	refs := AlerterSecretRefs(&esv1alpha1.Alerters{
+		Slack:     &esv1alpha1.SlackAlerter{WebhookURL: slackWebhook},
+		PagerDuty: &esv1alpha1.PagerDutyAlerter{ServiceKey: slackWebhook, ClientName: "elastalert"},
+		Email:     &esv1alpha1.EmailAlerter{To: []string{"oncall@test.com"}},
+	})
+	require.Equal(t, map[string]corev1.SecretKeySelector{
+		"slack_webhook_url":     slackWebhook,
+		"pagerduty_service_key": slackWebhook,
+	}, refs)
+}
+
+func TestBuildPodTemplateSpecWithAlerters(t *testing.T) {
+	elastalert := esv1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-elastalert",
+		},
+		Spec: esv1alpha1.ElastalertSpec{
+			Alerters: &esv1alpha1.Alerters{
+				Slack: &esv1alpha1.SlackAlerter{WebhookURL: slackWebhook},
+			},
+		},
+	}
+	have := BuildPodTemplateSpec(elastalert)
+	require.Contains(t, have.Spec.Volumes, corev1.Volume{
+		Name: "elastalert-alerters",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: "test-elastalert-alerters",
+			},
+		},
+	})
+	require.Contains(t, have.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		Name:      "elastalert-alerters",
+		MountPath: "/etc/elastalert/alerters",
+		ReadOnly:  true,
+	})
+}
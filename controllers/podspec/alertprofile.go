@@ -0,0 +1,73 @@
+package podspec
+
+import (
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"sort"
+)
+
+// AlertProfileNames returns the sorted, distinct alert profile names referenced by the rules.
+func AlertProfileNames(e *esv1alpha1.Elastalert) ([]string, error) {
+	seen := map[string]bool{}
+	var names []string
+	for _, v := range e.Spec.Rule {
+		rule, err := v.GetMap()
+		if err != nil {
+			return nil, err
+		}
+		ref, ok := rule[esv1alpha1.AlertProfileRuleKey]
+		if !ok {
+			continue
+		}
+		name, ok := ref.(string)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("rule %v has an invalid %s %v", rule["name"], esv1alpha1.AlertProfileRuleKey, ref)
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// PatchAlertProfiles renders the alert profiles referenced by the rules. Settings are merged recursively with
+// the precedence profile < overall < rule, the profile reference itself is removed from the rendered rule.
+func PatchAlertProfiles(e *esv1alpha1.Elastalert, profiles map[string]esv1alpha1.FreeForm) error {
+	var ruleArray []esv1alpha1.FreeForm
+	for _, v := range e.Spec.Rule {
+		rule, err := v.GetMap()
+		if err != nil {
+			return err
+		}
+		ref, ok := rule[esv1alpha1.AlertProfileRuleKey]
+		if !ok {
+			ruleArray = append(ruleArray, v)
+			continue
+		}
+		name, _ := ref.(string)
+		profile, ok := profiles[name]
+		if !ok {
+			return fmt.Errorf("alert profile %v referenced by rule %v is not found", name, rule["name"])
+		}
+		// GetMap decodes a fresh copy, so rules sharing a profile or overall never share nested maps.
+		base, err := profile.GetMap()
+		if err != nil {
+			return err
+		}
+		overall, err := e.Spec.Alert.GetMap()
+		if err != nil {
+			return err
+		}
+		if base["alert"] == nil {
+			return fmt.Errorf("alert profile %v does not define alert", name)
+		}
+		delete(rule, esv1alpha1.AlertProfileRuleKey)
+		base = DeepMergeInterfaceMap(base, overall)
+		base = DeepMergeInterfaceMap(base, rule)
+		ruleArray = append(ruleArray, esv1alpha1.NewFreeForm(base))
+	}
+	e.Spec.Rule = ruleArray
+	return nil
+}
@@ -0,0 +1,143 @@
+package podspec
+
+import (
+	"github.com/stretchr/testify/require"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"testing"
+)
+
+func TestAlertProfileNames(t *testing.T) {
+	e := &esv1alpha1.Elastalert{
+		Spec: esv1alpha1.ElastalertSpec{
+			Rule: []esv1alpha1.FreeForm{
+				esv1alpha1.NewFreeForm(map[string]interface{}{"name": "rule1", "alert_profile": "b"}),
+				esv1alpha1.NewFreeForm(map[string]interface{}{"name": "rule2"}),
+				esv1alpha1.NewFreeForm(map[string]interface{}{"name": "rule3", "alert_profile": "a"}),
+				esv1alpha1.NewFreeForm(map[string]interface{}{"name": "rule4", "alert_profile": "b"}),
+			},
+		},
+	}
+	have, err := AlertProfileNames(e)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, have)
+
+	e.Spec.Rule = append(e.Spec.Rule, esv1alpha1.NewFreeForm(map[string]interface{}{"name": "rule5", "alert_profile": 1}))
+	_, err = AlertProfileNames(e)
+	require.Error(t, err)
+}
+
+func TestPatchAlertProfiles(t *testing.T) {
+	profiles := map[string]esv1alpha1.FreeForm{
+		"oncall": esv1alpha1.NewFreeForm(map[string]interface{}{
+			"alert":         []interface{}{"post"},
+			"http_post_url": "profile.com",
+			"http_post_headers": map[string]interface{}{
+				"Content-Type": "application/json",
+				"X-Team":       "profile",
+			},
+		}),
+		"noalert": esv1alpha1.NewFreeForm(map[string]interface{}{
+			"http_post_url": "profile.com",
+		}),
+	}
+	testCases := []struct {
+		name    string
+		overall esv1alpha1.FreeForm
+		rule    esv1alpha1.FreeForm
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "test rule without profile",
+			overall: esv1alpha1.NewFreeForm(map[string]interface{}{
+				"http_post_url": "overall.com",
+			}),
+			rule: esv1alpha1.NewFreeForm(map[string]interface{}{"name": "rule"}),
+			want: map[string]interface{}{"name": "rule"},
+		},
+		{
+			name: "test profile < overall < rule",
+			overall: esv1alpha1.NewFreeForm(map[string]interface{}{
+				"http_post_url": "overall.com",
+				"http_post_headers": map[string]interface{}{
+					"X-Team": "overall",
+				},
+			}),
+			rule: esv1alpha1.NewFreeForm(map[string]interface{}{
+				"name":          "rule",
+				"alert_profile": "oncall",
+				"http_post_headers": map[string]interface{}{
+					"X-Rule": "rule",
+				},
+			}),
+			want: map[string]interface{}{
+				"name":          "rule",
+				"alert":         []interface{}{"post"},
+				"http_post_url": "overall.com",
+				"http_post_headers": map[string]interface{}{
+					"Content-Type": "application/json",
+					"X-Team":       "overall",
+					"X-Rule":       "rule",
+				},
+			},
+		},
+		{
+			name:    "test profile not found",
+			rule:    esv1alpha1.NewFreeForm(map[string]interface{}{"name": "rule", "alert_profile": "missing"}),
+			wantErr: true,
+		},
+		{
+			name:    "test profile without alert",
+			rule:    esv1alpha1.NewFreeForm(map[string]interface{}{"name": "rule", "alert_profile": "noalert"}),
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &esv1alpha1.Elastalert{
+				Spec: esv1alpha1.ElastalertSpec{
+					Alert: tc.overall,
+					Rule:  []esv1alpha1.FreeForm{tc.rule},
+				},
+			}
+			err := PatchAlertProfiles(e, profiles)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			have, err := e.Spec.Rule[0].GetMap()
+			require.NoError(t, err)
+			require.Equal(t, tc.want, have)
+		})
+	}
+}
+
+func TestPatchAlertProfilesSharedOverall(t *testing.T) {
+	profiles := map[string]esv1alpha1.FreeForm{
+		"oncall": esv1alpha1.NewFreeForm(map[string]interface{}{"alert": []interface{}{"post"}}),
+	}
+	e := &esv1alpha1.Elastalert{
+		Spec: esv1alpha1.ElastalertSpec{
+			Alert: esv1alpha1.NewFreeForm(map[string]interface{}{
+				"http_post_headers": map[string]interface{}{"a": "overall"},
+			}),
+			Rule: []esv1alpha1.FreeForm{
+				esv1alpha1.NewFreeForm(map[string]interface{}{
+					"name":              "first",
+					"alert_profile":     "oncall",
+					"http_post_headers": map[string]interface{}{"b": "first"},
+				}),
+				esv1alpha1.NewFreeForm(map[string]interface{}{"name": "second", "alert_profile": "oncall"}),
+			},
+		},
+	}
+	require.NoError(t, PatchAlertProfiles(e, profiles))
+	first, err := e.Spec.Rule[0].GetMap()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"a": "overall", "b": "first"}, first["http_post_headers"])
+	// the nested override of the first rule does not leak into the second one.
+	second, err := e.Spec.Rule[1].GetMap()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"a": "overall"}, second["http_post_headers"])
+}
@@ -0,0 +1,58 @@
+package podspec
+
+import (
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+)
+
+const (
+	// ConfigSourceSpec marks config keys defined by the Elastalert itself.
+	ConfigSourceSpec = "spec"
+	// ConfigSourceCertKey records the source of the cert among the config keys.
+	ConfigSourceCertKey = "cert"
+)
+
+// ClusterConfigName returns the name of the ElastalertClusterConfig used by the Elastalert.
+func ClusterConfigName(e *esv1alpha1.Elastalert) string {
+	if e.Spec.ClusterConfig != "" {
+		return e.Spec.ClusterConfig
+	}
+	return esv1alpha1.DefaultClusterConfigName
+}
+
+// ClusterConfigSource returns the config source recorded for keys provided by the named ElastalertClusterConfig.
+func ClusterConfigSource(name string) string {
+	return "ElastalertClusterConfig/" + name
+}
+
+// MergeClusterConfig merges the defaults of the ElastalertClusterConfig underneath spec.config and the cert of the
+// Elastalert, and returns the source of every top level config key. Nothing is merged and no sources are returned
+// when the ElastalertClusterConfig is nil.
+func MergeClusterConfig(e *esv1alpha1.Elastalert, cc *esv1alpha1.ElastalertClusterConfig) (map[string]string, error) {
+	if cc == nil {
+		return nil, nil
+	}
+	config, err := e.Spec.ConfigSetting.GetMap()
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := cc.Spec.Config.GetMap()
+	if err != nil {
+		return nil, err
+	}
+	source := ClusterConfigSource(cc.Name)
+	sources := map[string]string{}
+	for k := range defaults {
+		sources[k] = source
+	}
+	for k := range config {
+		sources[k] = ConfigSourceSpec
+	}
+	e.Spec.ConfigSetting = esv1alpha1.NewFreeForm(DeepMergeInterfaceMap(defaults, config))
+	if e.Spec.Cert != "" {
+		sources[ConfigSourceCertKey] = ConfigSourceSpec
+	} else if cc.Spec.Cert != "" {
+		e.Spec.Cert = cc.Spec.Cert
+		sources[ConfigSourceCertKey] = source
+	}
+	return sources, nil
+}
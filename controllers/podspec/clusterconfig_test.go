@@ -0,0 +1,112 @@
+package podspec
+
+import (
+	"github.com/stretchr/testify/require"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestMergeClusterConfig(t *testing.T) {
+	cc := &esv1alpha1.ElastalertClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: esv1alpha1.ElastalertClusterConfigSpec{
+			Config: esv1alpha1.NewFreeForm(map[string]interface{}{
+				"es_host":         "es.default",
+				"writeback_index": "elastalert",
+				"run_every": map[string]interface{}{
+					"minutes": 1,
+				},
+			}),
+			Cert: "default-cert",
+		},
+	}
+	testCases := []struct {
+		name        string
+		elastalert  esv1alpha1.Elastalert
+		cc          *esv1alpha1.ElastalertClusterConfig
+		wantConfig  map[string]interface{}
+		wantCert    string
+		wantSources map[string]string
+	}{
+		{
+			name: "test no cluster config",
+			elastalert: esv1alpha1.Elastalert{
+				Spec: esv1alpha1.ElastalertSpec{
+					ConfigSetting: esv1alpha1.NewFreeForm(map[string]interface{}{"es_host": "es.spec"}),
+				},
+			},
+			wantConfig: map[string]interface{}{"es_host": "es.spec"},
+		},
+		{
+			name: "test merge underneath spec",
+			elastalert: esv1alpha1.Elastalert{
+				Spec: esv1alpha1.ElastalertSpec{
+					ConfigSetting: esv1alpha1.NewFreeForm(map[string]interface{}{
+						"es_host": "es.spec",
+						"run_every": map[string]interface{}{
+							"seconds": 30,
+						},
+					}),
+				},
+			},
+			cc: cc,
+			wantConfig: map[string]interface{}{
+				"es_host":         "es.spec",
+				"writeback_index": "elastalert",
+				"run_every": map[string]interface{}{
+					"minutes": float64(1),
+					"seconds": float64(30),
+				},
+			},
+			wantCert: "default-cert",
+			wantSources: map[string]string{
+				"es_host":         "spec",
+				"writeback_index": "ElastalertClusterConfig/default",
+				"run_every":       "spec",
+				"cert":            "ElastalertClusterConfig/default",
+			},
+		},
+		{
+			name: "test cert of spec wins",
+			elastalert: esv1alpha1.Elastalert{
+				Spec: esv1alpha1.ElastalertSpec{
+					Cert: "spec-cert",
+				},
+			},
+			cc: cc,
+			wantConfig: map[string]interface{}{
+				"es_host":         "es.default",
+				"writeback_index": "elastalert",
+				"run_every": map[string]interface{}{
+					"minutes": float64(1),
+				},
+			},
+			wantCert: "spec-cert",
+			wantSources: map[string]string{
+				"es_host":         "ElastalertClusterConfig/default",
+				"writeback_index": "ElastalertClusterConfig/default",
+				"run_every":       "ElastalertClusterConfig/default",
+				"cert":            "spec",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sources, err := MergeClusterConfig(&tc.elastalert, tc.cc)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantSources, sources)
+			have, err := tc.elastalert.Spec.ConfigSetting.GetMap()
+			require.NoError(t, err)
+			require.Equal(t, tc.wantConfig, have)
+			require.Equal(t, tc.wantCert, tc.elastalert.Spec.Cert)
+		})
+	}
+}
+
+func TestClusterConfigName(t *testing.T) {
+	e := &esv1alpha1.Elastalert{}
+	require.Equal(t, "default", ClusterConfigName(e))
+	e.Spec.ClusterConfig = "prod"
+	require.Equal(t, "prod", ClusterConfigName(e))
+}
@@ -0,0 +1,130 @@
+package podspec
+
+import (
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"strings"
+)
+
+// ClusterCredentialOption indexes the credentials of an elasticsearch cluster in SecretRefs,
+// they are rendered into the import file of the cluster instead of the alerters file.
+func ClusterCredentialOption(cluster, option string) string {
+	return cluster + "/" + option
+}
+
+// parseClusterCredentialOption splits an option indexed by ClusterCredentialOption.
+func parseClusterCredentialOption(key string) (string, string, bool) {
+	i := strings.Index(key, "/")
+	if i < 0 {
+		return "", "", false
+	}
+	return key[:i], key[i+1:], true
+}
+
+// ClusterFileName returns the name of the file imported by rules of an elasticsearch cluster with credentials.
+func ClusterFileName(cluster string) string {
+	return "cluster-" + cluster + ".yaml"
+}
+
+// ClusterCAPath returns the path the CA of an elasticsearch cluster is mounted at.
+func ClusterCAPath(cluster string) string {
+	return DefaultClusterCAMountPath + "/" + cluster + "/" + DefaultClusterCAFileName
+}
+
+// SecretRefs returns every secret key delivered through the alerters secret: the ones of the typed alerters,
+// and the credentials of the elasticsearch clusters indexed by ClusterCredentialOption.
+func SecretRefs(e *esv1alpha1.Elastalert) map[string]corev1.SecretKeySelector {
+	refs := AlerterSecretRefs(e.Spec.Alerters)
+	for _, cluster := range e.Spec.Clusters {
+		if cluster.Credentials != nil {
+			refs[ClusterCredentialOption(cluster.Name, "es_username")] = cluster.Credentials.Username
+			refs[ClusterCredentialOption(cluster.Name, "es_password")] = cluster.Credentials.Password
+		}
+	}
+	return refs
+}
+
+// PatchClusterSettings renders the connection of the elasticsearch cluster referenced by es_cluster into the rule,
+// options already defined in a rule take precedence. Rules of a cluster with credentials import its cluster file,
+// which also holds the secret options of the typed alerters.
+func PatchClusterSettings(e *esv1alpha1.Elastalert) error {
+	clusters := map[string]esv1alpha1.ElasticsearchCluster{}
+	for _, cluster := range e.Spec.Clusters {
+		clusters[cluster.Name] = cluster
+	}
+	var ruleArray []esv1alpha1.FreeForm
+	for _, v := range e.Spec.Rule {
+		rule, err := v.GetMap()
+		if err != nil {
+			return err
+		}
+		ref, ok := rule[esv1alpha1.ClusterRuleKey]
+		if !ok {
+			ruleArray = append(ruleArray, v)
+			continue
+		}
+		delete(rule, esv1alpha1.ClusterRuleKey)
+		cluster, ok := clusters[fmt.Sprint(ref)]
+		if !ok {
+			return fmt.Errorf("elasticsearch cluster %v referenced by rule %v is not found", ref, rule["name"])
+		}
+		options := map[string]interface{}{
+			"es_host": cluster.Host,
+			"es_port": cluster.Port,
+			"use_ssl": cluster.UseSSL,
+		}
+		if cluster.CA != nil {
+			options["verify_certs"] = true
+			options["ca_certs"] = ClusterCAPath(cluster.Name)
+		}
+		for k, option := range options {
+			if _, ok := rule[k]; !ok {
+				rule[k] = option
+			}
+		}
+		if cluster.Credentials != nil {
+			path := DefaultAlerterMountPath + "/" + ClusterFileName(cluster.Name)
+			if imported, ok := rule["import"]; ok && imported != DefaultAlerterFilePath && imported != path {
+				return fmt.Errorf("rule %v already imports %v, credentials of elasticsearch cluster %v can not be applied", rule["name"], imported, cluster.Name)
+			}
+			rule["import"] = path
+		}
+		ruleArray = append(ruleArray, esv1alpha1.NewFreeForm(rule))
+	}
+	e.Spec.Rule = ruleArray
+	return nil
+}
+
+// buildClusterCAVolumes mounts the CA of every elasticsearch cluster defining one at ClusterCAPath.
+func buildClusterCAVolumes(clusters []esv1alpha1.ElasticsearchCluster) ([]corev1.Volume, []corev1.VolumeMount) {
+	var volumes []corev1.Volume
+	var volumeMounts []corev1.VolumeMount
+	for _, cluster := range clusters {
+		if cluster.CA == nil {
+			continue
+		}
+		name := DefaultClusterCAVolumePrefix + cluster.Name
+		volumes = append(volumes, corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: cluster.CA.Name,
+					Items: []corev1.KeyToPath{
+						{
+							Key:  cluster.CA.Key,
+							Path: DefaultClusterCAFileName,
+						},
+					},
+					Optional: cluster.CA.Optional,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: DefaultClusterCAMountPath + "/" + cluster.Name,
+			ReadOnly:  true,
+		})
+	}
+	return volumes, volumeMounts
+}
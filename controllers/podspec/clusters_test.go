@@ -0,0 +1,175 @@
+package podspec
+
+import (
+	"github.com/stretchr/testify/require"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+var (
+	logsCA = &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "logs-ca"},
+		Key:                  "ca.pem",
+	}
+	logsCredentials = &esv1alpha1.ElasticsearchCredentials{
+		Username: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "logs"}, Key: "user"},
+		Password: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "logs"}, Key: "password"},
+	}
+)
+
+func TestPatchClusterSettings(t *testing.T) {
+	clusters := []esv1alpha1.ElasticsearchCluster{
+		{
+			Name: "metrics",
+			Host: "metrics.es",
+			Port: 9200,
+		},
+		{
+			Name:        "logs",
+			Host:        "logs.es",
+			Port:        9243,
+			UseSSL:      true,
+			CA:          logsCA,
+			Credentials: logsCredentials,
+		},
+	}
+	testCases := []struct {
+		name    string
+		rule    map[string]interface{}
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "test rule without cluster",
+			rule: map[string]interface{}{"name": "rule"},
+			want: map[string]interface{}{"name": "rule"},
+		},
+		{
+			name: "test cluster without tls",
+			rule: map[string]interface{}{"name": "rule", "es_cluster": "metrics", "es_port": 9201},
+			want: map[string]interface{}{
+				"name":    "rule",
+				"es_host": "metrics.es",
+				"es_port": float64(9201),
+				"use_ssl": false,
+			},
+		},
+		{
+			name: "test cluster with ca and credentials",
+			rule: map[string]interface{}{"name": "rule", "es_cluster": "logs", "import": "/etc/elastalert/alerters/alerters.yaml"},
+			want: map[string]interface{}{
+				"name":         "rule",
+				"es_host":      "logs.es",
+				"es_port":      float64(9243),
+				"use_ssl":      true,
+				"verify_certs": true,
+				"ca_certs":     "/etc/elastalert/clusters/logs/ca.crt",
+				"import":       "/etc/elastalert/alerters/cluster-logs.yaml",
+			},
+		},
+		{
+			name:    "test cluster not found",
+			rule:    map[string]interface{}{"name": "rule", "es_cluster": "missing"},
+			wantErr: true,
+		},
+		{
+			name:    "test rule already imports another file",
+			rule:    map[string]interface{}{"name": "rule", "es_cluster": "logs", "import": "base.yaml"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &esv1alpha1.Elastalert{
+				Spec: esv1alpha1.ElastalertSpec{
+					Rule:     []esv1alpha1.FreeForm{esv1alpha1.NewFreeForm(tc.rule)},
+					Clusters: clusters,
+				},
+			}
+			err := PatchClusterSettings(e)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			have, err := e.Spec.Rule[0].GetMap()
+			require.NoError(t, err)
+			require.Equal(t, tc.want, have)
+		})
+	}
+}
+
+func TestSecretRefs(t *testing.T) {
+	e := &esv1alpha1.Elastalert{
+		Spec: esv1alpha1.ElastalertSpec{
+			Alerters: &esv1alpha1.Alerters{
+				Slack: &esv1alpha1.SlackAlerter{WebhookURL: slackWebhook},
+			},
+			Clusters: []esv1alpha1.ElasticsearchCluster{
+				{Name: "metrics", Host: "metrics.es", Port: 9200},
+				{Name: "logs", Host: "logs.es", Port: 9243, Credentials: logsCredentials},
+			},
+		},
+	}
+	require.Equal(t, map[string]corev1.SecretKeySelector{
+		"slack_webhook_url": slackWebhook,
+		"logs/es_username":  logsCredentials.Username,
+		"logs/es_password":  logsCredentials.Password,
+	}, SecretRefs(e))
+	require.True(t, HasAlerterSecrets(e))
+}
+
+func TestBuildAlerterSecretWithClusters(t *testing.T) {
+	e := &esv1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "esa1",
+		},
+	}
+	have, err := BuildAlerterSecret(e, map[string]string{
+		"slack_webhook_url": "https://hooks.slack.com/abc",
+		"logs/es_username":  "elastic",
+		"logs/es_password":  "changeme",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{
+		"alerters.yaml":     []byte("slack_webhook_url: https://hooks.slack.com/abc\n"),
+		"cluster-logs.yaml": []byte("es_password: changeme\nes_username: elastic\nslack_webhook_url: https://hooks.slack.com/abc\n"),
+	}, have.Data)
+}
+
+func TestBuildPodTemplateSpecWithClusterCA(t *testing.T) {
+	elastalert := esv1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-elastalert",
+		},
+		Spec: esv1alpha1.ElastalertSpec{
+			Clusters: []esv1alpha1.ElasticsearchCluster{
+				{Name: "metrics", Host: "metrics.es", Port: 9200},
+				{Name: "logs", Host: "logs.es", Port: 9243, CA: logsCA},
+			},
+		},
+	}
+	have := BuildPodTemplateSpec(elastalert)
+	require.Contains(t, have.Spec.Volumes, corev1.Volume{
+		Name: "es-cluster-ca-logs",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: "logs-ca",
+				Items: []corev1.KeyToPath{
+					{Key: "ca.pem", Path: "ca.crt"},
+				},
+			},
+		},
+	})
+	require.Contains(t, have.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		Name:      "es-cluster-ca-logs",
+		MountPath: "/etc/elastalert/clusters/logs",
+		ReadOnly:  true,
+	})
+	for _, v := range have.Spec.Volumes {
+		require.NotEqual(t, "es-cluster-ca-metrics", v.Name)
+	}
+}
@@ -8,7 +8,10 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"reflect"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sort"
+	"strconv"
 )
 
 func GenerateNewConfigmap(Scheme *runtime.Scheme, e *esv1alpha1.Elastalert, suffix string) (*corev1.ConfigMap, error) {
@@ -44,6 +47,7 @@ func GenerateNewConfigmap(Scheme *runtime.Scheme, e *esv1alpha1.Elastalert, suff
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      e.Name + suffix,
 			Namespace: e.Namespace,
+			Labels:    InstanceLabels(e.Name),
 		},
 		Data: data,
 	}
@@ -88,6 +92,98 @@ func PatchConfigSettings(e *esv1alpha1.Elastalert, stringCert string) error {
 	return nil
 }
 
+// GenerateRuleConfigmaps renders the rules of the given Elastalert and splits them into as many
+// ConfigMaps as needed to keep each one under DefaultRuleShardSize. The first shard keeps the
+// plain "-rule" name, further shards are numbered "-rule-1", "-rule-2" and so on.
+func GenerateRuleConfigmaps(Scheme *runtime.Scheme, e *esv1alpha1.Elastalert) ([]corev1.ConfigMap, error) {
+	data, err := GenerateYamlMap(e.Spec.Rule)
+	if err != nil {
+		log.Error(
+			err,
+			"Failed to generate rules configmaps",
+			"Elastalert.Namespace", e.Namespace,
+			"Configmaps.Namespace", e.Namespace,
+		)
+		return nil, err
+	}
+	shards, err := ShardRuleData(data, DefaultRuleShardSize)
+	if err != nil {
+		return nil, err
+	}
+	var cms []corev1.ConfigMap
+	for i, shard := range shards {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      RuleShardName(e.Name, i),
+				Namespace: e.Namespace,
+				Labels: Merge(InstanceLabels(e.Name), map[string]string{
+					RuleShardLabel: strconv.Itoa(i),
+				}),
+			},
+			Data: shard,
+		}
+		if err = ctrl.SetControllerReference(e, cm, Scheme); err != nil {
+			log.Error(
+				err,
+				"Failed to generate rules configmaps",
+				"Elastalert.Namespace", e.Namespace,
+				"Configmaps.Namespace", e.Namespace,
+			)
+			return nil, err
+		}
+		cms = append(cms, *cm)
+	}
+	return cms, nil
+}
+
+// ShardRuleData packs rule files into groups whose total size of keys and values stays under limit.
+// Files are packed in name order so the result is stable. At least one (possibly empty) shard is
+// always returned, so that the rule volume has something to project.
+func ShardRuleData(data map[string]string, limit int) ([]map[string]string, error) {
+	var keys []string
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	shards := []map[string]string{{}}
+	size := 0
+	for _, k := range keys {
+		fileSize := len(k) + len(data[k])
+		if fileSize > limit {
+			return nil, fmt.Errorf("rule file %s is %d bytes, which exceeds the configmap size limit of %d bytes", k, fileSize, limit)
+		}
+		if size+fileSize > limit {
+			shards = append(shards, map[string]string{})
+			size = 0
+		}
+		shards[len(shards)-1][k] = data[k]
+		size += fileSize
+	}
+	return shards, nil
+}
+
+// CountRuleShards returns the number of rule ConfigMaps the given rules are split into.
+func CountRuleShards(ruleArray []esv1alpha1.FreeForm) int {
+	data, err := GenerateYamlMap(ruleArray)
+	if err != nil {
+		return 1
+	}
+	shards, err := ShardRuleData(data, DefaultRuleShardSize)
+	if err != nil {
+		return 1
+	}
+	return len(shards)
+}
+
+// RuleShardName returns the name of the rule ConfigMap with the given index.
+func RuleShardName(eaName string, index int) string {
+	if index == 0 {
+		return eaName + esv1alpha1.RuleSuffx
+	}
+	return fmt.Sprintf("%s%s-%d", eaName, esv1alpha1.RuleSuffx, index)
+}
+
 func ConfigMapsToMap(cms []corev1.ConfigMap) map[string]corev1.ConfigMap {
 	m := map[string]corev1.ConfigMap{}
 	for _, d := range cms {
@@ -114,22 +210,35 @@ func GenerateYamlMap(ruleArray []esv1alpha1.FreeForm) (map[string]string, error)
 	return data, nil
 }
 
+// PatchAlertSettings merges overall into every rule with the strategy of the rule, or the one of the Elastalert.
+// Rules referencing an alert profile are left to PatchAlertProfiles, which always deep merges overall, so they cannot
+// pick a strategy of their own.
 func PatchAlertSettings(e *esv1alpha1.Elastalert) error {
 	var ruleArray []esv1alpha1.FreeForm
-	alert, err := e.Spec.Alert.GetMap()
-	if err != nil {
-		return err
-	}
-	if alert == nil {
-		return nil
-	}
 	for _, v := range e.Spec.Rule {
 		rule, err := v.GetMap()
 		if err != nil {
 			return err
 		}
-		if rule["alert"] == nil {
-			MergeInterfaceMap(rule, alert)
+		strategy := e.Spec.OverallMergeStrategy
+		_, profiled := rule[esv1alpha1.AlertProfileRuleKey]
+		if s, ok := rule[esv1alpha1.MergeStrategyRuleKey]; ok {
+			if profiled {
+				return fmt.Errorf("rule %v sets both %s and %s, overall is always deep merged into rules using an alert profile",
+					rule["name"], esv1alpha1.AlertProfileRuleKey, esv1alpha1.MergeStrategyRuleKey)
+			}
+			strategy = esv1alpha1.MergeStrategy(fmt.Sprint(s))
+			delete(rule, esv1alpha1.MergeStrategyRuleKey)
+		}
+		if !profiled {
+			// decode overall for every rule, merging must not share nested maps between rules.
+			alert, err := e.Spec.Alert.GetMap()
+			if err != nil {
+				return err
+			}
+			if rule, err = mergeOverall(rule, alert, strategy); err != nil {
+				return err
+			}
 		}
 		ruleArray = append(ruleArray, esv1alpha1.NewFreeForm(rule))
 	}
@@ -138,6 +247,53 @@ func PatchAlertSettings(e *esv1alpha1.Elastalert) error {
 	return nil
 }
 
+func mergeOverall(rule, alert map[string]interface{}, strategy esv1alpha1.MergeStrategy) (map[string]interface{}, error) {
+	switch strategy {
+	case "", esv1alpha1.MergeStrategySkipIfPresent:
+		if rule["alert"] == nil {
+			MergeInterfaceMap(rule, alert)
+		}
+		return rule, nil
+	case esv1alpha1.MergeStrategyOverride:
+		return MergeInterfaceMap(rule, alert), nil
+	case esv1alpha1.MergeStrategyDeepMerge:
+		return DeepMergeInterfaceMap(alert, rule), nil
+	case esv1alpha1.MergeStrategyAppendAlertList:
+		alerts := alertList(rule["alert"])
+		for _, a := range alertList(alert["alert"]) {
+			alerts = appendIfMissing(alerts, a)
+		}
+		merged := DeepMergeInterfaceMap(alert, rule)
+		if len(alerts) != 0 {
+			merged["alert"] = alerts
+		}
+		return merged, nil
+	default:
+		return nil, fmt.Errorf("unknown overall merge strategy %v of rule %v", strategy, rule["name"])
+	}
+}
+
+// alertList returns the alert option of a rule as a list, it may be defined as a single alerter or a list of them.
+func alertList(alert interface{}) []interface{} {
+	switch a := alert.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return append([]interface{}{}, a...)
+	default:
+		return []interface{}{a}
+	}
+}
+
+func appendIfMissing(list []interface{}, item interface{}) []interface{} {
+	for _, v := range list {
+		if reflect.DeepEqual(v, item) {
+			return list
+		}
+	}
+	return append(list, item)
+}
+
 type RawConfig struct {
 	config map[string]interface{}
 	cert   string
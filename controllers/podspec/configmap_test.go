@@ -171,6 +171,9 @@ func TestGenerateNewConfigmap(t *testing.T) {
 			want: corev1.ConfigMap{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "test-elastalert-config",
+					Labels: map[string]string{
+						"es.noah.domain/elastalert": "test-elastalert",
+					},
 					OwnerReferences: []metav1.OwnerReference{
 						{
 							APIVersion:         "v1",
@@ -208,6 +211,9 @@ func TestGenerateNewConfigmap(t *testing.T) {
 			want: corev1.ConfigMap{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "test-elastalert-rule",
+					Labels: map[string]string{
+						"es.noah.domain/elastalert": "test-elastalert",
+					},
 					OwnerReferences: []metav1.OwnerReference{
 						{
 							APIVersion:         "v1",
@@ -361,3 +367,277 @@ func TestPatchAlertSettings(t *testing.T) {
 		})
 	}
 }
+
+func TestPatchAlertSettingsMergeStrategies(t *testing.T) {
+	overall := esv1alpha1.NewFreeForm(map[string]interface{}{
+		"alert":                   []interface{}{"slack"},
+		"slack_username_override": "overall",
+		"http_post_headers": map[string]interface{}{
+			"Content-Type": "application/json",
+			"X-Team":       "overall",
+		},
+	})
+	rule := map[string]interface{}{
+		"name":                   "test-elastalert",
+		"alert":                  []interface{}{"post"},
+		"slack_channel_override": "#rule",
+		"http_post_headers": map[string]interface{}{
+			"X-Team": "rule",
+		},
+	}
+	testCases := []struct {
+		name     string
+		strategy esv1alpha1.MergeStrategy
+		ruleKey  interface{}
+		want     map[string]interface{}
+		wantErr  bool
+	}{
+		{
+			name: "test skip if present by default",
+			want: rule,
+		},
+		{
+			name:     "test deep merge",
+			strategy: esv1alpha1.MergeStrategyDeepMerge,
+			want: map[string]interface{}{
+				"name":                    "test-elastalert",
+				"alert":                   []interface{}{"post"},
+				"slack_channel_override":  "#rule",
+				"slack_username_override": "overall",
+				"http_post_headers": map[string]interface{}{
+					"Content-Type": "application/json",
+					"X-Team":       "rule",
+				},
+			},
+		},
+		{
+			name:     "test append alert list selected by rule",
+			strategy: esv1alpha1.MergeStrategyOverride,
+			ruleKey:  "append-alert-list",
+			want: map[string]interface{}{
+				"name":                    "test-elastalert",
+				"alert":                   []interface{}{"post", "slack"},
+				"slack_channel_override":  "#rule",
+				"slack_username_override": "overall",
+				"http_post_headers": map[string]interface{}{
+					"Content-Type": "application/json",
+					"X-Team":       "rule",
+				},
+			},
+		},
+		{
+			name:     "test override",
+			strategy: esv1alpha1.MergeStrategyOverride,
+			want: map[string]interface{}{
+				"name":                    "test-elastalert",
+				"alert":                   []interface{}{"slack"},
+				"slack_channel_override":  "#rule",
+				"slack_username_override": "overall",
+				"http_post_headers": map[string]interface{}{
+					"Content-Type": "application/json",
+					"X-Team":       "overall",
+				},
+			},
+		},
+		{
+			name:    "test unknown strategy of rule",
+			ruleKey: "merge-somehow",
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := esv1alpha1.NewFreeForm(rule)
+			if tc.ruleKey != nil {
+				m, err := r.GetMap()
+				require.NoError(t, err)
+				m["overall_merge_strategy"] = tc.ruleKey
+				r = esv1alpha1.NewFreeForm(m)
+			}
+			e := &esv1alpha1.Elastalert{
+				Spec: esv1alpha1.ElastalertSpec{
+					Rule:                 []esv1alpha1.FreeForm{r, r},
+					Alert:                overall,
+					OverallMergeStrategy: tc.strategy,
+				},
+			}
+			err := PatchAlertSettings(e)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for _, v := range e.Spec.Rule {
+				have, err := v.GetMap()
+				require.NoError(t, err)
+				require.Equal(t, tc.want, have)
+			}
+		})
+	}
+}
+
+func TestPatchAlertSettingsProfileWithStrategy(t *testing.T) {
+	e := &esv1alpha1.Elastalert{
+		Spec: esv1alpha1.ElastalertSpec{
+			Rule: []esv1alpha1.FreeForm{
+				esv1alpha1.NewFreeForm(map[string]interface{}{
+					"name":                   "test-elastalert",
+					"alert_profile":          "oncall",
+					"overall_merge_strategy": "override",
+				}),
+			},
+			Alert: esv1alpha1.NewFreeForm(map[string]interface{}{"alert": []interface{}{"slack"}}),
+		},
+	}
+	err := PatchAlertSettings(e)
+	require.EqualError(t, err, "rule test-elastalert sets both alert_profile and overall_merge_strategy, overall is always deep merged into rules using an alert profile")
+
+	// the strategy of the Elastalert does not apply to rules using a profile.
+	e.Spec.Rule = []esv1alpha1.FreeForm{
+		esv1alpha1.NewFreeForm(map[string]interface{}{"name": "test-elastalert", "alert_profile": "oncall"}),
+	}
+	e.Spec.OverallMergeStrategy = esv1alpha1.MergeStrategyOverride
+	require.NoError(t, PatchAlertSettings(e))
+	have, err := e.Spec.Rule[0].GetMap()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"name": "test-elastalert", "alert_profile": "oncall"}, have)
+}
+
+func TestPatchAlertSettingsSkipsAlertProfiles(t *testing.T) {
+	e := &esv1alpha1.Elastalert{
+		Spec: esv1alpha1.ElastalertSpec{
+			Rule: []esv1alpha1.FreeForm{
+				esv1alpha1.NewFreeForm(map[string]interface{}{
+					"name": "test-elastalert", "alert_profile": "oncall", "overall_merge_strategy": "override",
+				}),
+			},
+			Alert: esv1alpha1.NewFreeForm(map[string]interface{}{
+				"alert": []interface{}{"slack"},
+			}),
+		},
+	}
+	require.NoError(t, PatchAlertSettings(e))
+	have, err := e.Spec.Rule[0].GetMap()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"name": "test-elastalert", "alert_profile": "oncall"}, have)
+}
+
+func TestShardRuleData(t *testing.T) {
+	testCases := []struct {
+		name    string
+		data    map[string]string
+		limit   int
+		want    []map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "test no rules",
+			data:  map[string]string{},
+			limit: 10,
+			want:  []map[string]string{{}},
+		},
+		{
+			name: "test rules fit into one shard",
+			data: map[string]string{
+				"a.yaml": "a",
+				"b.yaml": "b",
+			},
+			limit: 20,
+			want: []map[string]string{
+				{
+					"a.yaml": "a",
+					"b.yaml": "b",
+				},
+			},
+		},
+		{
+			name: "test rules split into shards by name order",
+			data: map[string]string{
+				"c.yaml": "c",
+				"a.yaml": "a",
+				"b.yaml": "b",
+			},
+			limit: 14,
+			want: []map[string]string{
+				{
+					"a.yaml": "a",
+					"b.yaml": "b",
+				},
+				{
+					"c.yaml": "c",
+				},
+			},
+		},
+		{
+			name: "test rule exceeds limit",
+			data: map[string]string{
+				"a.yaml": "aaaaaaaaaa",
+			},
+			limit:   10,
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			have, err := ShardRuleData(tc.data, tc.limit)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, have)
+		})
+	}
+}
+
+func TestGenerateRuleConfigmaps(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &esv1alpha1.Elastalert{})
+	elastalert := esv1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-elastalert",
+			Namespace: "esa1",
+		},
+		Spec: esv1alpha1.ElastalertSpec{
+			Rule: []esv1alpha1.FreeForm{
+				esv1alpha1.NewFreeForm(map[string]interface{}{
+					"name": "test-elastalert", "type": "any",
+				}),
+			},
+		},
+	}
+	want := []corev1.ConfigMap{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-elastalert-rule",
+				Namespace: "esa1",
+				Labels: map[string]string{
+					"es.noah.domain/elastalert": "test-elastalert",
+					"es.noah.domain/rule-shard": "0",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion:         "v1",
+						Kind:               "Elastalert",
+						Name:               "test-elastalert",
+						UID:                "",
+						Controller:         &varTrue,
+						BlockOwnerDeletion: &varTrue,
+					},
+				},
+			},
+			Data: map[string]string{
+				"test-elastalert.yaml": "name: test-elastalert\ntype: any\n",
+			},
+		},
+	}
+	have, err := GenerateRuleConfigmaps(s, &elastalert)
+	require.NoError(t, err)
+	require.Equal(t, want, have)
+	require.Equal(t, 1, CountRuleShards(elastalert.Spec.Rule))
+}
+
+func TestRuleShardName(t *testing.T) {
+	require.Equal(t, "my-esa-rule", RuleShardName("my-esa", 0))
+	require.Equal(t, "my-esa-rule-2", RuleShardName("my-esa", 2))
+}
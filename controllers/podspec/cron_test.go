@@ -0,0 +1,99 @@
+package podspec
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	testCases := []struct {
+		name     string
+		schedule string
+		from     time.Time
+		want     time.Time
+		wantErr  bool
+	}{
+		{
+			name:     "test daily",
+			schedule: "0 2 * * *",
+			from:     time.Date(2021, 8, 1, 1, 30, 0, 0, time.UTC),
+			want:     time.Date(2021, 8, 1, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "test next is strictly after",
+			schedule: "0 2 * * *",
+			from:     time.Date(2021, 8, 1, 2, 0, 0, 0, time.UTC),
+			want:     time.Date(2021, 8, 2, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "test step",
+			schedule: "*/15 * * * *",
+			from:     time.Date(2021, 8, 1, 2, 7, 30, 0, time.UTC),
+			want:     time.Date(2021, 8, 1, 2, 15, 0, 0, time.UTC),
+		},
+		{
+			name:     "test range with step and weekdays",
+			schedule: "0 9-17/4 * * 1-5",
+			from:     time.Date(2021, 8, 6, 14, 0, 0, 0, time.UTC),
+			want:     time.Date(2021, 8, 6, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "test sunday as 7",
+			schedule: "0 0 * * 7",
+			from:     time.Date(2021, 8, 2, 0, 0, 0, 0, time.UTC),
+			want:     time.Date(2021, 8, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "test day of month or day of week",
+			schedule: "0 0 1,15 * 1",
+			from:     time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC),
+			want:     time.Date(2021, 8, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "test descriptor",
+			schedule: "@monthly",
+			from:     time.Date(2021, 12, 5, 0, 0, 0, 0, time.UTC),
+			want:     time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "test daylight saving gap is skipped",
+			schedule: "30 2 * * *",
+			from:     time.Date(2021, 3, 13, 12, 0, 0, 0, newYork),
+			want:     time.Date(2021, 3, 15, 2, 30, 0, 0, newYork),
+		},
+		{
+			name:     "test never matching",
+			schedule: "0 0 30 2 *",
+			from:     time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "test missing field",
+			schedule: "0 2 * *",
+			wantErr:  true,
+		},
+		{
+			name:     "test out of range",
+			schedule: "60 * * * *",
+			wantErr:  true,
+		},
+		{
+			name:     "test invalid step",
+			schedule: "*/0 * * * *",
+			wantErr:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := parseCron(tc.schedule)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, tc.want.Equal(s.next(tc.from)), "want %s, have %s", tc.want, s.next(tc.from))
+		})
+	}
+}
@@ -14,12 +14,32 @@ const (
 	DefaultTerminationGracePeriodSeconds int64 = 10
 	DefaultElastAlertName                      = "elastalert"
 	DefautlImage                               = "toughnoah/elastalert:v1.0"
+	DefaultElastAlert2Image                    = "jertel/elastalert2:2.2.2"
 	DefaultCertVolumeName                      = "elasticsearch-cert"
 	DefaultCertSuffix                          = "-es-cert"
 	DefaultCertMountPath                       = "/ssl"
 	DefaultElasticCertName                     = "elasticCA.crt"
 	DefaultRulesFolder                         = "/etc/elastalert/rules/..data/"
 	DefaultElasticCertPath                     = "/ssl/elasticCA.crt"
+	ElastalertInstanceLabel                    = "es.noah.domain/elastalert"
+	RuleShardLabel                             = "es.noah.domain/rule-shard"
+	DefaultAlerterSecretSuffix                 = "-alerters"
+	DefaultAlerterVolumeName                   = "elastalert-alerters"
+	DefaultAlerterMountPath                    = "/etc/elastalert/alerters"
+	DefaultAlerterFileName                     = "alerters.yaml"
+	DefaultAlerterFilePath                     = "/etc/elastalert/alerters/alerters.yaml"
+	DefaultSMTPAuthFileName                    = "smtp_auth.yaml"
+	DefaultSMTPAuthFilePath                    = "/etc/elastalert/alerters/smtp_auth.yaml"
+	DefaultClusterCAVolumePrefix               = "es-cluster-ca-"
+	DefaultClusterCAMountPath                  = "/etc/elastalert/clusters"
+	DefaultClusterCAFileName                   = "ca.crt"
+	// PruneAnnotation set to "false" keeps an object owned by the instance from being pruned.
+	PruneAnnotation = "es.noah.domain/prune"
+	// PausedAnnotation set to "true" stops the operator from reconciling and observing the instance.
+	PausedAnnotation = "es.noah.domain/paused"
+	// DefaultRuleShardSize is the maximum size of the rule files packed into a single ConfigMap.
+	// It leaves some room for the object metadata under the 1MiB limit of the API server.
+	DefaultRuleShardSize = 900 * 1024
 )
 
 var (
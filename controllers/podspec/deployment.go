@@ -26,9 +26,17 @@ func GenerateNewDeployment(Scheme *runtime.Scheme, e *v1alpha1.Elastalert) (*app
 	return deploy, nil
 }
 
+// IsPaused tells whether reconciliation of the Elastalert is paused with PausedAnnotation.
+func IsPaused(e *v1alpha1.Elastalert) bool {
+	return e.Annotations[PausedAnnotation] == "true"
+}
+
 func BuildDeployment(elastalert v1alpha1.Elastalert) *appsv1.Deployment {
 	var replicas = new(int32)
 	*replicas = 1
+	if elastalert.Spec.Suspend {
+		*replicas = 0
+	}
 	podTemplate := BuildPodTemplateSpec(elastalert)
 	varTrue := true
 	//deliberate action to enable
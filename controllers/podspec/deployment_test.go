@@ -8,6 +8,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes/scheme"
 	"testing"
 )
@@ -45,7 +46,8 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 			want: v1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
-						"app": "elastalert",
+						"app":                       "elastalert",
+						"es.noah.domain/elastalert": "test-elastalert",
 					},
 					Annotations: map[string]string{
 						"sidecar.istio.io/inject":           "false",
@@ -127,6 +129,7 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 							VolumeSource: v1.VolumeSource{
 								Secret: &v1.SecretVolumeSource{
 									SecretName: "test-elastalert-es-cert",
+									Optional:   &varTrue,
 								},
 							},
 						},
@@ -143,9 +146,15 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 						{
 							Name: "test-elastalert-rule",
 							VolumeSource: v1.VolumeSource{
-								ConfigMap: &v1.ConfigMapVolumeSource{
-									LocalObjectReference: v1.LocalObjectReference{
-										Name: "test-elastalert-rule",
+								Projected: &v1.ProjectedVolumeSource{
+									Sources: []v1.VolumeProjection{
+										{
+											ConfigMap: &v1.ConfigMapProjection{
+												LocalObjectReference: v1.LocalObjectReference{
+													Name: "test-elastalert-rule",
+												},
+											},
+										},
 									},
 								},
 							},
@@ -189,8 +198,9 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 			want: v1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
-						"app":  "elastalert",
-						"test": "elastalert",
+						"app":                       "elastalert",
+						"es.noah.domain/elastalert": "test-elastalert",
+						"test":                      "elastalert",
 					},
 					Annotations: map[string]string{
 						"kubectl.kubernetes.io/restartedAt": "2021-05-17T01:38:44+08:00",
@@ -272,6 +282,7 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 							VolumeSource: v1.VolumeSource{
 								Secret: &v1.SecretVolumeSource{
 									SecretName: "test-elastalert-es-cert",
+									Optional:   &varTrue,
 								},
 							},
 						},
@@ -288,9 +299,15 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 						{
 							Name: "test-elastalert-rule",
 							VolumeSource: v1.VolumeSource{
-								ConfigMap: &v1.ConfigMapVolumeSource{
-									LocalObjectReference: v1.LocalObjectReference{
-										Name: "test-elastalert-rule",
+								Projected: &v1.ProjectedVolumeSource{
+									Sources: []v1.VolumeProjection{
+										{
+											ConfigMap: &v1.ConfigMapProjection{
+												LocalObjectReference: v1.LocalObjectReference{
+													Name: "test-elastalert-rule",
+												},
+											},
+										},
 									},
 								},
 							},
@@ -338,7 +355,8 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 			want: v1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
-						"app": "elastalert",
+						"app":                       "elastalert",
+						"es.noah.domain/elastalert": "test-elastalert",
 					},
 					Annotations: map[string]string{
 						"sidecar.istio.io/inject":           "false",
@@ -422,6 +440,7 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 							VolumeSource: v1.VolumeSource{
 								Secret: &v1.SecretVolumeSource{
 									SecretName: "test-elastalert-es-cert",
+									Optional:   &varTrue,
 								},
 							},
 						},
@@ -438,9 +457,15 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 						{
 							Name: "test-elastalert-rule",
 							VolumeSource: v1.VolumeSource{
-								ConfigMap: &v1.ConfigMapVolumeSource{
-									LocalObjectReference: v1.LocalObjectReference{
-										Name: "test-elastalert-rule",
+								Projected: &v1.ProjectedVolumeSource{
+									Sources: []v1.VolumeProjection{
+										{
+											ConfigMap: &v1.ConfigMapProjection{
+												LocalObjectReference: v1.LocalObjectReference{
+													Name: "test-elastalert-rule",
+												},
+											},
+										},
 									},
 								},
 							},
@@ -482,7 +507,8 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 			want: v1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
-						"app": "elastalert",
+						"app":                       "elastalert",
+						"es.noah.domain/elastalert": "test-elastalert",
 					},
 					Annotations: map[string]string{
 						"kubectl.kubernetes.io/restartedAt": "2021-05-17T01:38:44+08:00",
@@ -587,6 +613,7 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 							VolumeSource: v1.VolumeSource{
 								Secret: &v1.SecretVolumeSource{
 									SecretName: "test-elastalert-es-cert",
+									Optional:   &varTrue,
 								},
 							},
 						},
@@ -603,9 +630,15 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 						{
 							Name: "test-elastalert-rule",
 							VolumeSource: v1.VolumeSource{
-								ConfigMap: &v1.ConfigMapVolumeSource{
-									LocalObjectReference: v1.LocalObjectReference{
-										Name: "test-elastalert-rule",
+								Projected: &v1.ProjectedVolumeSource{
+									Sources: []v1.VolumeProjection{
+										{
+											ConfigMap: &v1.ConfigMapProjection{
+												LocalObjectReference: v1.LocalObjectReference{
+													Name: "test-elastalert-rule",
+												},
+											},
+										},
 									},
 								},
 							},
@@ -641,7 +674,8 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 			want: v1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
-						"app": "elastalert",
+						"app":                       "elastalert",
+						"es.noah.domain/elastalert": "test-elastalert",
 					},
 					Annotations: map[string]string{
 						"kubectl.kubernetes.io/restartedAt": "2021-05-17T01:38:44+08:00",
@@ -722,6 +756,7 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 							VolumeSource: v1.VolumeSource{
 								Secret: &v1.SecretVolumeSource{
 									SecretName: "test-elastalert-es-cert",
+									Optional:   &varTrue,
 								},
 							},
 						},
@@ -738,9 +773,15 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 						{
 							Name: "test-elastalert-rule",
 							VolumeSource: v1.VolumeSource{
-								ConfigMap: &v1.ConfigMapVolumeSource{
-									LocalObjectReference: v1.LocalObjectReference{
-										Name: "test-elastalert-rule",
+								Projected: &v1.ProjectedVolumeSource{
+									Sources: []v1.VolumeProjection{
+										{
+											ConfigMap: &v1.ConfigMapProjection{
+												LocalObjectReference: v1.LocalObjectReference{
+													Name: "test-elastalert-rule",
+												},
+											},
+										},
 									},
 								},
 							},
@@ -804,7 +845,8 @@ func TestBuildDeployment(t *testing.T) {
 					Template: v1.PodTemplateSpec{
 						ObjectMeta: metav1.ObjectMeta{
 							Labels: map[string]string{
-								"app": "elastalert",
+								"app":                       "elastalert",
+								"es.noah.domain/elastalert": "test-elastalert",
 							},
 							Annotations: map[string]string{
 								"kubectl.kubernetes.io/restartedAt": "2021-05-17T01:38:44+08:00",
@@ -885,6 +927,7 @@ func TestBuildDeployment(t *testing.T) {
 									VolumeSource: v1.VolumeSource{
 										Secret: &v1.SecretVolumeSource{
 											SecretName: "test-elastalert-es-cert",
+											Optional:   &varTrue,
 										},
 									},
 								},
@@ -901,9 +944,15 @@ func TestBuildDeployment(t *testing.T) {
 								{
 									Name: "test-elastalert-rule",
 									VolumeSource: v1.VolumeSource{
-										ConfigMap: &v1.ConfigMapVolumeSource{
-											LocalObjectReference: v1.LocalObjectReference{
-												Name: "test-elastalert-rule",
+										Projected: &v1.ProjectedVolumeSource{
+											Sources: []v1.VolumeProjection{
+												{
+													ConfigMap: &v1.ConfigMapProjection{
+														LocalObjectReference: v1.LocalObjectReference{
+															Name: "test-elastalert-rule",
+														},
+													},
+												},
 											},
 										},
 									},
@@ -979,7 +1028,8 @@ func TestGenerateNewDeployment(t *testing.T) {
 					Template: v1.PodTemplateSpec{
 						ObjectMeta: metav1.ObjectMeta{
 							Labels: map[string]string{
-								"app": "elastalert",
+								"app":                       "elastalert",
+								"es.noah.domain/elastalert": "test-elastalert",
 							},
 							Annotations: map[string]string{
 								"kubectl.kubernetes.io/restartedAt": "2021-05-17T01:38:44+08:00",
@@ -1060,6 +1110,7 @@ func TestGenerateNewDeployment(t *testing.T) {
 									VolumeSource: v1.VolumeSource{
 										Secret: &v1.SecretVolumeSource{
 											SecretName: "test-elastalert-es-cert",
+											Optional:   &varTrue,
 										},
 									},
 								},
@@ -1076,9 +1127,15 @@ func TestGenerateNewDeployment(t *testing.T) {
 								{
 									Name: "test-elastalert-rule",
 									VolumeSource: v1.VolumeSource{
-										ConfigMap: &v1.ConfigMapVolumeSource{
-											LocalObjectReference: v1.LocalObjectReference{
-												Name: "test-elastalert-rule",
+										Projected: &v1.ProjectedVolumeSource{
+											Sources: []v1.VolumeProjection{
+												{
+													ConfigMap: &v1.ConfigMapProjection{
+														LocalObjectReference: v1.LocalObjectReference{
+															Name: "test-elastalert-rule",
+														},
+													},
+												},
 											},
 										},
 									},
@@ -1108,3 +1165,27 @@ func TestGenerateNewDeployment(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildDeploymentSuspended(t *testing.T) {
+	elastalert := v1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-elastalert",
+		},
+		Spec: v1alpha1.ElastalertSpec{
+			Suspend: true,
+		},
+	}
+	have := BuildDeployment(elastalert)
+	require.Equal(t, int32(0), *have.Spec.Replicas)
+	require.False(t, IsPaused(&elastalert))
+	elastalert.Annotations = map[string]string{"es.noah.domain/paused": "true"}
+	require.True(t, IsPaused(&elastalert))
+}
+
+func TestInstanceSelector(t *testing.T) {
+	pod := BuildPodTemplateSpec(v1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "my-esa"},
+	})
+	require.True(t, InstanceSelector().Matches(labels.Set(pod.Labels)))
+	require.False(t, InstanceSelector().Matches(labels.Set{"app": "other"}))
+}
@@ -0,0 +1,133 @@
+package podspec
+
+import (
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"sort"
+	"strings"
+)
+
+// flavorDialect holds what differs between the ElastAlert implementations.
+type flavorDialect struct {
+	image           string
+	command         []string
+	livenessCommand []string
+	// unsupportedAlerters are alerter names the implementation does not know.
+	unsupportedAlerters []string
+	// unsupportedRuleKeys are rule options the implementation dropped, mapped to a hint about their replacement.
+	unsupportedRuleKeys map[string]string
+	// unsupportedConfigKeys are config.yaml options the implementation renamed or dropped, mapped to a hint about their
+	// replacement.
+	unsupportedConfigKeys map[string]string
+}
+
+var flavorDialects = map[esv1alpha1.Flavor]flavorDialect{
+	esv1alpha1.FlavorElastAlert: {
+		image:           DefautlImage,
+		command:         []string{"elastalert", "--config", "/etc/elastalert/config.yaml", "--verbose"},
+		livenessCommand: []string{"sh", "-c", "ps -ef|grep -v grep|grep elastalert"},
+		unsupportedAlerters: []string{
+			"alertmanager", "chatwork", "datadog", "dingtalk", "discord", "googlechat",
+		},
+	},
+	esv1alpha1.FlavorElastAlert2: {
+		image:   DefaultElastAlert2Image,
+		command: []string{"python3", "-m", "elastalert.elastalert", "--config", "/etc/elastalert/config.yaml", "--verbose"},
+		// the ElastAlert 2 image ships without ps.
+		livenessCommand:     []string{"grep", "-q", "elastalert", "/proc/1/cmdline"},
+		unsupportedAlerters: []string{"hipchat", "stride"},
+		unsupportedRuleKeys: map[string]string{
+			"generate_kibana_link":    "use generate_kibana_discover_url instead",
+			"use_kibana_dashboard":    "use generate_kibana_discover_url instead",
+			"use_kibana4_dashboard":   "use generate_kibana_discover_url instead",
+			"kibana4_start_timedelta": "use kibana_discover_from_timedelta instead",
+			"kibana4_end_timedelta":   "use kibana_discover_to_timedelta instead",
+		},
+		unsupportedConfigKeys: map[string]string{
+			"boto_profile": "use profile instead",
+		},
+	},
+}
+
+func dialectOf(e *esv1alpha1.Elastalert) flavorDialect {
+	if d, ok := flavorDialects[e.Spec.Flavor]; ok {
+		return d
+	}
+	return flavorDialects[esv1alpha1.FlavorElastAlert]
+}
+
+// ElastalertImage returns the image run by the Elastalert, spec.image or the default one of its flavor.
+func ElastalertImage(e *esv1alpha1.Elastalert) string {
+	if e.Spec.Image != "" {
+		return e.Spec.Image
+	}
+	return dialectOf(e).image
+}
+
+// ElastalertVersion returns the version of the image run by the Elastalert, which is its tag.
+func ElastalertVersion(e *esv1alpha1.Elastalert) string {
+	return ImageVersion(ElastalertImage(e))
+}
+
+// ImageVersion returns the tag of an image reference, "latest" if it has none.
+func ImageVersion(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return "latest"
+}
+
+// ValidateFlavorSettings checks the config and the rules against the dialect of the flavor, it must run after the
+// config and every rule are patched.
+func ValidateFlavorSettings(e *esv1alpha1.Elastalert) error {
+	d := dialectOf(e)
+	config, err := e.Spec.ConfigSetting.GetMap()
+	if err != nil {
+		return err
+	}
+	if key, ok := firstKey(config, d.unsupportedConfigKeys); ok {
+		return fmt.Errorf("config sets %s unsupported by %s, %s", key, flavorName(e), d.unsupportedConfigKeys[key])
+	}
+	for _, v := range e.Spec.Rule {
+		rule, err := v.GetMap()
+		if err != nil {
+			return err
+		}
+		if key, ok := firstKey(rule, d.unsupportedRuleKeys); ok {
+			return fmt.Errorf("rule %v sets %s unsupported by %s, %s", rule["name"], key, flavorName(e), d.unsupportedRuleKeys[key])
+		}
+		for _, alert := range alertList(rule["alert"]) {
+			for _, unsupported := range d.unsupportedAlerters {
+				if alert == unsupported {
+					return fmt.Errorf("rule %v uses alerter %s unsupported by %s", rule["name"], unsupported, flavorName(e))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// firstKey returns the first key of m, in sorted order, which is one of keys.
+func firstKey(m map[string]interface{}, keys map[string]string) (string, bool) {
+	var found []string
+	for k := range keys {
+		if _, ok := m[k]; ok {
+			found = append(found, k)
+		}
+	}
+	if len(found) == 0 {
+		return "", false
+	}
+	sort.Strings(found)
+	return found[0], true
+}
+
+func flavorName(e *esv1alpha1.Elastalert) esv1alpha1.Flavor {
+	if e.Spec.Flavor == "" {
+		return esv1alpha1.FlavorElastAlert
+	}
+	return e.Spec.Flavor
+}
@@ -0,0 +1,130 @@
+package podspec
+
+import (
+	"github.com/stretchr/testify/require"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"testing"
+)
+
+func TestElastalertVersion(t *testing.T) {
+	testCases := []struct {
+		name   string
+		flavor esv1alpha1.Flavor
+		image  string
+		want   string
+	}{
+		{
+			name: "test default image",
+			want: "v1.0",
+		},
+		{
+			name:   "test default elastalert2 image",
+			flavor: esv1alpha1.FlavorElastAlert2,
+			want:   "2.2.2",
+		},
+		{
+			name:  "test image with registry port",
+			image: "registry:5000/elastalert:2.1.0",
+			want:  "2.1.0",
+		},
+		{
+			name:  "test image without tag",
+			image: "registry:5000/elastalert",
+			want:  "latest",
+		},
+		{
+			name:  "test image with digest",
+			image: "elastalert:2.1.0@sha256:abc",
+			want:  "2.1.0",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &esv1alpha1.Elastalert{
+				Spec: esv1alpha1.ElastalertSpec{
+					Image:  tc.image,
+					Flavor: tc.flavor,
+				},
+			}
+			require.Equal(t, tc.want, ElastalertVersion(e))
+		})
+	}
+}
+
+func TestValidateFlavorSettings(t *testing.T) {
+	testCases := []struct {
+		name    string
+		flavor  esv1alpha1.Flavor
+		config  map[string]interface{}
+		rule    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "test supported rule",
+			rule: map[string]interface{}{"name": "rule", "alert": []interface{}{"post"}, "generate_kibana_link": true},
+		},
+		{
+			name:    "test elastalert2 alerter on elastalert",
+			rule:    map[string]interface{}{"name": "rule", "alert": "discord"},
+			wantErr: true,
+		},
+		{
+			name:   "test elastalert2 alerter on elastalert2",
+			flavor: esv1alpha1.FlavorElastAlert2,
+			rule:   map[string]interface{}{"name": "rule", "alert": "discord"},
+		},
+		{
+			name:    "test removed alerter on elastalert2",
+			flavor:  esv1alpha1.FlavorElastAlert2,
+			rule:    map[string]interface{}{"name": "rule", "alert": []interface{}{"post", "hipchat"}},
+			wantErr: true,
+		},
+		{
+			name:    "test removed rule key on elastalert2",
+			flavor:  esv1alpha1.FlavorElastAlert2,
+			rule:    map[string]interface{}{"name": "rule", "use_kibana4_dashboard": "http://kibana"},
+			wantErr: true,
+		},
+		{
+			name:   "test renamed config key on elastalert",
+			config: map[string]interface{}{"boto_profile": "default"},
+			rule:   map[string]interface{}{"name": "rule"},
+		},
+		{
+			name:    "test renamed config key on elastalert2",
+			flavor:  esv1alpha1.FlavorElastAlert2,
+			config:  map[string]interface{}{"es_host": "es", "boto_profile": "default"},
+			rule:    map[string]interface{}{"name": "rule"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &esv1alpha1.Elastalert{
+				Spec: esv1alpha1.ElastalertSpec{
+					Flavor:        tc.flavor,
+					ConfigSetting: esv1alpha1.NewFreeForm(tc.config),
+					Rule:          []esv1alpha1.FreeForm{esv1alpha1.NewFreeForm(tc.rule)},
+				},
+			}
+			err := ValidateFlavorSettings(e)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestBuildPodTemplateSpecElastAlert2(t *testing.T) {
+	elastalert := esv1alpha1.Elastalert{
+		Spec: esv1alpha1.ElastalertSpec{
+			Flavor: esv1alpha1.FlavorElastAlert2,
+		},
+	}
+	have := BuildPodTemplateSpec(elastalert)
+	require.Equal(t, "jertel/elastalert2:2.2.2", have.Spec.Containers[0].Image)
+	require.Equal(t, []string{"python3", "-m", "elastalert.elastalert", "--config", "/etc/elastalert/config.yaml", "--verbose"}, have.Spec.Containers[0].Command)
+	require.Equal(t, []string{"grep", "-q", "elastalert", "/proc/1/cmdline"}, have.Spec.Containers[0].LivenessProbe.Exec.Command)
+}
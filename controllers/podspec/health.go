@@ -0,0 +1,35 @@
+package podspec
+
+import (
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"time"
+)
+
+// HealthInterval returns how often the health of the Elastalert is checked.
+func HealthInterval(e *esv1alpha1.Elastalert) time.Duration {
+	if p := e.Spec.HealthPolicy; p != nil && p.Interval != nil && p.Interval.Duration > 0 {
+		return p.Interval.Duration
+	}
+	return esv1alpha1.ElastAlertObserveInterval
+}
+
+// HealthGracePeriod returns how long after a rollout unavailable replicas of the Elastalert are tolerated.
+func HealthGracePeriod(e *esv1alpha1.Elastalert) time.Duration {
+	if p := e.Spec.HealthPolicy; p != nil && p.GracePeriod != nil {
+		return p.GracePeriod.Duration
+	}
+	return 0
+}
+
+// HealthFailureThreshold returns the number of consecutive failed checks marking the Elastalert FAILED.
+func HealthFailureThreshold(e *esv1alpha1.Elastalert) int32 {
+	if p := e.Spec.HealthPolicy; p != nil && p.FailureThreshold > 0 {
+		return p.FailureThreshold
+	}
+	return esv1alpha1.DefaultHealthFailureThreshold
+}
+
+// FailOnCrashLoop tells whether a pod of the Elastalert in CrashLoopBackOff fails it at once.
+func FailOnCrashLoop(e *esv1alpha1.Elastalert) bool {
+	return e.Spec.HealthPolicy != nil && e.Spec.HealthPolicy.FailOnCrashLoop
+}
@@ -0,0 +1,54 @@
+package podspec
+
+import (
+	"github.com/stretchr/testify/require"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestHealthPolicy(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		policy          *esv1alpha1.HealthPolicy
+		interval        time.Duration
+		gracePeriod     time.Duration
+		threshold       int32
+		failOnCrashLoop bool
+	}{
+		{
+			desc:      "test defaults",
+			interval:  time.Minute,
+			threshold: 1,
+		},
+		{
+			desc:      "test empty policy",
+			policy:    &esv1alpha1.HealthPolicy{Interval: &metav1.Duration{}},
+			interval:  time.Minute,
+			threshold: 1,
+		},
+		{
+			desc: "test policy",
+			policy: &esv1alpha1.HealthPolicy{
+				Interval:         &metav1.Duration{Duration: 30 * time.Second},
+				GracePeriod:      &metav1.Duration{Duration: 5 * time.Minute},
+				FailureThreshold: 3,
+				FailOnCrashLoop:  true,
+			},
+			interval:        30 * time.Second,
+			gracePeriod:     5 * time.Minute,
+			threshold:       3,
+			failOnCrashLoop: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			e := &esv1alpha1.Elastalert{Spec: esv1alpha1.ElastalertSpec{HealthPolicy: tc.policy}}
+			require.Equal(t, tc.interval, HealthInterval(e))
+			require.Equal(t, tc.gracePeriod, HealthGracePeriod(e))
+			require.Equal(t, tc.threshold, HealthFailureThreshold(e))
+			require.Equal(t, tc.failOnCrashLoop, FailOnCrashLoop(e))
+		})
+	}
+}
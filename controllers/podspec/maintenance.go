@@ -0,0 +1,123 @@
+package podspec
+
+import (
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sort"
+	"time"
+)
+
+// MaintenanceState is the effect of the maintenance windows of an Elastalert at a point in time.
+type MaintenanceState struct {
+	// Status is nil when the Elastalert has no maintenance window.
+	Status *esv1alpha1.MaintenanceStatus
+	// NextTransition is when a window starts or ends next, the zero time if none does.
+	NextTransition time.Time
+}
+
+// EvaluateMaintenanceWindows tells which maintenance windows of the Elastalert are active at now.
+func EvaluateMaintenanceWindows(e *esv1alpha1.Elastalert, now time.Time) (*MaintenanceState, error) {
+	state := &MaintenanceState{}
+	if len(e.Spec.MaintenanceWindows) == 0 {
+		return state, nil
+	}
+	status := &esv1alpha1.MaintenanceStatus{}
+	var activeUntil, nextWindow time.Time
+	muted := map[string]bool{}
+	for i, w := range e.Spec.MaintenanceWindows {
+		schedule, err := parseCron(w.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("maintenance window %d: %v", i, err)
+		}
+		if w.Duration.Duration <= 0 {
+			return nil, fmt.Errorf("maintenance window %d: duration must be positive", i)
+		}
+		loc := time.UTC
+		if w.TimeZone != "" {
+			if loc, err = time.LoadLocation(w.TimeZone); err != nil {
+				return nil, fmt.Errorf("maintenance window %d: %v", i, err)
+			}
+		}
+		// the window is active if it started after now minus its duration, the last start wins for overlapping ones.
+		var start time.Time
+		t := schedule.next(now.In(loc).Add(-w.Duration.Duration))
+		for !t.IsZero() && !t.After(now) {
+			start = t
+			t = schedule.next(t)
+		}
+		if !t.IsZero() {
+			nextWindow = earliest(nextWindow, t)
+			state.NextTransition = earliest(state.NextTransition, t)
+		}
+		if start.IsZero() {
+			continue
+		}
+		end := start.Add(w.Duration.Duration)
+		state.NextTransition = earliest(state.NextTransition, end)
+		if end.After(activeUntil) {
+			activeUntil = end
+		}
+		if len(w.Rules) == 0 {
+			status.Suspended = true
+		}
+		for _, name := range w.Rules {
+			muted[name] = true
+		}
+	}
+	for name := range muted {
+		status.MutedRules = append(status.MutedRules, name)
+	}
+	sort.Strings(status.MutedRules)
+	if !activeUntil.IsZero() {
+		status.ActiveUntil = &metav1.Time{Time: activeUntil.UTC()}
+	}
+	if !nextWindow.IsZero() {
+		status.NextWindow = &metav1.Time{Time: nextWindow.UTC()}
+	}
+	state.Status = status
+	return state, nil
+}
+
+// MaintenanceSuspended tells whether an active maintenance window recorded in status suspends the Elastalert.
+func MaintenanceSuspended(e *esv1alpha1.Elastalert) bool {
+	return e.Status.Maintenance != nil && e.Status.Maintenance.Suspended
+}
+
+// PatchMaintenanceSettings applies the active maintenance windows recorded in status, it suspends the Elastalert
+// or disables the muted rules with is_enabled.
+func PatchMaintenanceSettings(e *esv1alpha1.Elastalert) error {
+	if e.Status.Maintenance == nil {
+		return nil
+	}
+	if e.Status.Maintenance.Suspended {
+		e.Spec.Suspend = true
+	}
+	if len(e.Status.Maintenance.MutedRules) == 0 {
+		return nil
+	}
+	muted := map[string]bool{}
+	for _, name := range e.Status.Maintenance.MutedRules {
+		muted[name] = true
+	}
+	var rules []esv1alpha1.FreeForm
+	for _, v := range e.Spec.Rule {
+		rule, err := v.GetMap()
+		if err != nil {
+			return err
+		}
+		if name, ok := rule["name"].(string); ok && muted[name] {
+			rule["is_enabled"] = false
+		}
+		rules = append(rules, esv1alpha1.NewFreeForm(rule))
+	}
+	e.Spec.Rule = rules
+	return nil
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || b.Before(a) {
+		return b
+	}
+	return a
+}
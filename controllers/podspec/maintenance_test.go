@@ -0,0 +1,134 @@
+package podspec
+
+import (
+	"github.com/stretchr/testify/require"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestEvaluateMaintenanceWindows(t *testing.T) {
+	windows := []esv1alpha1.MaintenanceWindow{
+		{
+			Schedule: "0 2 * * *",
+			Duration: metav1.Duration{Duration: time.Hour},
+		},
+		{
+			Schedule: "0 9 * * *",
+			Duration: metav1.Duration{Duration: 2 * time.Hour},
+			TimeZone: "Asia/Shanghai",
+			Rules:    []string{"disk", "cpu"},
+		},
+	}
+	testCases := []struct {
+		name    string
+		windows []esv1alpha1.MaintenanceWindow
+		now     time.Time
+		want    *MaintenanceState
+		wantErr bool
+	}{
+		{
+			name: "test without windows",
+			now:  time.Date(2021, 8, 1, 2, 30, 0, 0, time.UTC),
+			want: &MaintenanceState{},
+		},
+		{
+			name:    "test outside windows",
+			windows: windows,
+			now:     time.Date(2021, 8, 1, 0, 30, 0, 0, time.UTC),
+			want: &MaintenanceState{
+				Status: &esv1alpha1.MaintenanceStatus{
+					// 09:00 in Shanghai.
+					NextWindow: &metav1.Time{Time: time.Date(2021, 8, 1, 1, 0, 0, 0, time.UTC)},
+				},
+				NextTransition: time.Date(2021, 8, 1, 1, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "test overlapping windows",
+			windows: windows,
+			now:     time.Date(2021, 8, 1, 2, 30, 0, 0, time.UTC),
+			want: &MaintenanceState{
+				Status: &esv1alpha1.MaintenanceStatus{
+					Suspended:   true,
+					MutedRules:  []string{"cpu", "disk"},
+					ActiveUntil: &metav1.Time{Time: time.Date(2021, 8, 1, 3, 0, 0, 0, time.UTC)},
+					NextWindow:  &metav1.Time{Time: time.Date(2021, 8, 2, 1, 0, 0, 0, time.UTC)},
+				},
+				NextTransition: time.Date(2021, 8, 1, 3, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "test window ends exactly now",
+			windows: windows[:1],
+			now:     time.Date(2021, 8, 1, 3, 0, 0, 0, time.UTC),
+			want: &MaintenanceState{
+				Status: &esv1alpha1.MaintenanceStatus{
+					NextWindow: &metav1.Time{Time: time.Date(2021, 8, 2, 2, 0, 0, 0, time.UTC)},
+				},
+				NextTransition: time.Date(2021, 8, 2, 2, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "test invalid schedule",
+			windows: []esv1alpha1.MaintenanceWindow{{Schedule: "0 2 * *", Duration: metav1.Duration{Duration: time.Hour}}},
+			wantErr: true,
+		},
+		{
+			name:    "test invalid time zone",
+			windows: []esv1alpha1.MaintenanceWindow{{Schedule: "0 2 * * *", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "Mars/Olympus"}},
+			wantErr: true,
+		},
+		{
+			name:    "test zero duration",
+			windows: []esv1alpha1.MaintenanceWindow{{Schedule: "0 2 * * *"}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &esv1alpha1.Elastalert{
+				Spec: esv1alpha1.ElastalertSpec{
+					MaintenanceWindows: tc.windows,
+				},
+			}
+			have, err := EvaluateMaintenanceWindows(e, tc.now)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, have)
+		})
+	}
+}
+
+func TestPatchMaintenanceSettings(t *testing.T) {
+	e := &esv1alpha1.Elastalert{
+		Spec: esv1alpha1.ElastalertSpec{
+			Rule: []esv1alpha1.FreeForm{
+				esv1alpha1.NewFreeForm(map[string]interface{}{"name": "disk", "type": "any"}),
+				esv1alpha1.NewFreeForm(map[string]interface{}{"name": "memory", "type": "any"}),
+			},
+		},
+		Status: esv1alpha1.ElastalertStatus{
+			Maintenance: &esv1alpha1.MaintenanceStatus{
+				MutedRules: []string{"disk"},
+			},
+		},
+	}
+	require.NoError(t, PatchMaintenanceSettings(e))
+	require.False(t, e.Spec.Suspend)
+	have, err := e.Spec.Rule[0].GetMap()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"name": "disk", "type": "any", "is_enabled": false}, have)
+	have, err = e.Spec.Rule[1].GetMap()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"name": "memory", "type": "any"}, have)
+
+	e.Status.Maintenance = &esv1alpha1.MaintenanceStatus{Suspended: true}
+	require.NoError(t, PatchMaintenanceSettings(e))
+	require.True(t, e.Spec.Suspend)
+	require.True(t, MaintenanceSuspended(e))
+}
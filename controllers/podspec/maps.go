@@ -46,6 +46,27 @@ func MergeInterfaceMap(dest, src map[string]interface{}) map[string]interface{}
 	return dest
 }
 
+// DeepMergeInterfaceMap merges source into destination recursively: nested maps present in both are merged
+// key by key, any other value of source overwrites the one in destination.
+func DeepMergeInterfaceMap(dest, src map[string]interface{}) map[string]interface{} {
+	if dest == nil {
+		if src == nil {
+			return nil
+		}
+		dest = make(map[string]interface{}, len(src))
+	}
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		destMap, destIsMap := dest[k].(map[string]interface{})
+		if srcIsMap && destIsMap {
+			dest[k] = DeepMergeInterfaceMap(destMap, srcMap)
+			continue
+		}
+		dest[k] = v
+	}
+	return dest
+}
+
 // MergePreservingExistingKeys merges source into destination while skipping any keys that exist in the destination.
 func MergePreservingExistingKeys(dest, src map[string]string) map[string]string {
 	if dest == nil {
@@ -351,3 +351,57 @@ func TestMergeInterfaceMap(t *testing.T) {
 		})
 	}
 }
+
+func TestDeepMergeInterfaceMap(t *testing.T) {
+	tests := []struct {
+		name string
+		dest map[string]interface{}
+		src  map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			name: "when dest is nil",
+			src:  map[string]interface{}{"x": "y"},
+			want: map[string]interface{}{"x": "y"},
+		},
+		{
+			name: "when src is nil",
+			dest: map[string]interface{}{"x": "y"},
+			want: map[string]interface{}{"x": "y"},
+		},
+		{
+			name: "when both maps are nil",
+		},
+		{
+			name: "when keys are the same but value are different",
+			dest: map[string]interface{}{"x": "p", "a": "q"},
+			src:  map[string]interface{}{"x": "y"},
+			want: map[string]interface{}{"x": "y", "a": "q"},
+		},
+		{
+			name: "when nested maps are merged",
+			dest: map[string]interface{}{"headers": map[string]interface{}{"a": "1", "b": "2"}},
+			src:  map[string]interface{}{"headers": map[string]interface{}{"b": "3", "c": "4"}},
+			want: map[string]interface{}{"headers": map[string]interface{}{"a": "1", "b": "3", "c": "4"}},
+		},
+		{
+			name: "when lists are overwritten",
+			dest: map[string]interface{}{"alert": []interface{}{"post"}},
+			src:  map[string]interface{}{"alert": []interface{}{"slack"}},
+			want: map[string]interface{}{"alert": []interface{}{"slack"}},
+		},
+		{
+			name: "when map overwrites a scalar",
+			dest: map[string]interface{}{"x": "y"},
+			src:  map[string]interface{}{"x": map[string]interface{}{"a": "b"}},
+			want: map[string]interface{}{"x": map[string]interface{}{"a": "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			have := DeepMergeInterfaceMap(tt.dest, tt.src)
+			require.Equal(t, tt.want, have)
+		})
+	}
+}
@@ -3,6 +3,8 @@ package podspec
 import (
 	"github.com/toughnoah/elastalert-operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 	"time"
 )
 
@@ -11,19 +13,27 @@ func BuildPodTemplateSpec(elastalert v1alpha1.Elastalert) corev1.PodTemplateSpec
 		"kubectl.kubernetes.io/restartedAt": GetUtcTimeString(),
 	}
 	DefaultAnnotations = Merge(DefaultAnnotations, elastalert.Annotations)
-	var DefaultCommand = []string{"elastalert", "--config", "/etc/elastalert/config.yaml", "--verbose"}
-	volumes, volumeMounts := buildVolumes(elastalert.Name)
-	labelselector := buildLabels()
+	dialect := dialectOf(&elastalert)
+	volumes, volumeMounts := buildVolumes(elastalert.Name, CountRuleShards(elastalert.Spec.Rule))
+	if HasAlerterSecrets(&elastalert) {
+		alerterVolume, alerterVolumeMount := buildAlerterVolume(elastalert.Name)
+		volumes = append(volumes, alerterVolume)
+		volumeMounts = append(volumeMounts, alerterVolumeMount)
+	}
+	caVolumes, caVolumeMounts := buildClusterCAVolumes(elastalert.Spec.Clusters)
+	volumes = append(volumes, caVolumes...)
+	volumeMounts = append(volumeMounts, caVolumeMounts...)
+	labelselector := Merge(buildLabels(), InstanceLabels(elastalert.Name))
 	builder := NewPodTemplateBuilder(elastalert.Spec.PodTemplateSpec, DefaultElastAlertName)
 	builder = builder.
 		WithLabels(labelselector).
 		WithAnnotations(DefaultAnnotations).
-		WithDockerImage(elastalert.Spec.Image, DefautlImage).
+		WithDockerImage(elastalert.Spec.Image, dialect.image).
 		WithResources(DefaultResources).
 		WithTerminationGracePeriod(DefaultTerminationGracePeriodSeconds).
 		WithPorts(GetDefaultContainerPorts()).
 		WithAffinity(DefaultAffinity(elastalert.Name)).
-		WithCommand(DefaultCommand).
+		WithCommand(dialect.command).
 		WithInitContainers().
 		WithVolumes(volumes...).
 		WithVolumeMounts(volumeMounts...).
@@ -46,11 +56,7 @@ func BuildPodTemplateSpec(elastalert v1alpha1.Elastalert) corev1.PodTemplateSpec
 		corev1.Probe{
 			Handler: corev1.Handler{
 				Exec: &corev1.ExecAction{
-					Command: []string{
-						"sh",
-						"-c",
-						"ps -ef|grep -v grep|grep elastalert",
-					},
+					Command: dialect.livenessCommand,
 				},
 			},
 			InitialDelaySeconds: 50,
@@ -62,38 +68,61 @@ func BuildPodTemplateSpec(elastalert v1alpha1.Elastalert) corev1.PodTemplateSpec
 	return builder.PodTemplate
 }
 
-func buildVolumes(eaName string) ([]corev1.Volume, []corev1.VolumeMount) {
+func buildVolumes(eaName string, ruleShards int) ([]corev1.Volume, []corev1.VolumeMount) {
 	var elastAlertVolumes []corev1.Volume
 	var elastAlertVolumesMounts []corev1.VolumeMount
 
-	var volumesTypeMap = map[string]string{
-		v1alpha1.RuleSuffx:   v1alpha1.RuleMountPath,
-		v1alpha1.ConfigSuffx: v1alpha1.ConfigMountPath,
-	}
-	for typeSuffix, Path := range volumesTypeMap {
-		elastalertVolume := corev1.Volume{
-			Name: eaName + typeSuffix,
-			VolumeSource: corev1.VolumeSource{
-				ConfigMap: &corev1.ConfigMapVolumeSource{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: eaName + typeSuffix,
-					},
+	configVolume := corev1.Volume{
+		Name: eaName + v1alpha1.ConfigSuffx,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: eaName + v1alpha1.ConfigSuffx,
 				},
 			},
-		}
-		elastalertVolumeMount := corev1.VolumeMount{
-			Name:      eaName + typeSuffix,
-			MountPath: Path,
-		}
-		elastAlertVolumes = append(elastAlertVolumes, elastalertVolume)
-		elastAlertVolumesMounts = append(elastAlertVolumesMounts, elastalertVolumeMount)
+		},
+	}
+	configVolumeMount := corev1.VolumeMount{
+		Name:      eaName + v1alpha1.ConfigSuffx,
+		MountPath: v1alpha1.ConfigMountPath,
+	}
+	elastAlertVolumes = append(elastAlertVolumes, configVolume)
+	elastAlertVolumesMounts = append(elastAlertVolumesMounts, configVolumeMount)
+
+	// rules may be split into several configmaps, project all of them into the single rules folder.
+	var ruleSources []corev1.VolumeProjection
+	for i := 0; i < ruleShards; i++ {
+		ruleSources = append(ruleSources, corev1.VolumeProjection{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: RuleShardName(eaName, i),
+				},
+			},
+		})
+	}
+	ruleVolume := corev1.Volume{
+		Name: eaName + v1alpha1.RuleSuffx,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: ruleSources,
+			},
+		},
 	}
+	ruleVolumeMount := corev1.VolumeMount{
+		Name:      eaName + v1alpha1.RuleSuffx,
+		MountPath: v1alpha1.RuleMountPath,
+	}
+	elastAlertVolumes = append(elastAlertVolumes, ruleVolume)
+	elastAlertVolumesMounts = append(elastAlertVolumesMounts, ruleVolumeMount)
 
+	// the cert secret only exists when a cert is given, so it is mounted as optional.
+	optional := true
 	certVolume := &corev1.Volume{
 		Name: DefaultCertVolumeName,
 		VolumeSource: corev1.VolumeSource{
 			Secret: &corev1.SecretVolumeSource{
 				SecretName: eaName + DefaultCertSuffix,
+				Optional:   &optional,
 			},
 		},
 	}
@@ -107,6 +136,24 @@ func buildVolumes(eaName string) ([]corev1.Volume, []corev1.VolumeMount) {
 	return elastAlertVolumes, elastAlertVolumesMounts
 }
 
+// buildAlerterVolume mounts the secret holding the alerter secrets, which rules import.
+func buildAlerterVolume(eaName string) (corev1.Volume, corev1.VolumeMount) {
+	alerterVolume := corev1.Volume{
+		Name: DefaultAlerterVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: eaName + DefaultAlerterSecretSuffix,
+			},
+		},
+	}
+	alerterVolumeMount := corev1.VolumeMount{
+		Name:      DefaultAlerterVolumeName,
+		MountPath: DefaultAlerterMountPath,
+		ReadOnly:  true,
+	}
+	return alerterVolume, alerterVolumeMount
+}
+
 // PodTemplateBuilder helps with building a pod template inheriting values
 // from a user-provided pod template. It focuses on building a pod with
 // one main Container.
@@ -156,6 +203,20 @@ func buildLabels() map[string]string {
 	return map[string]string{"app": "elastalert"}
 }
 
+// InstanceLabels returns the labels marking an object as owned by the given Elastalert instance.
+func InstanceLabels(eaName string) map[string]string {
+	return map[string]string{ElastalertInstanceLabel: eaName}
+}
+
+// InstanceSelector selects the objects owned by any Elastalert instance.
+func InstanceSelector() labels.Selector {
+	requirement, err := labels.NewRequirement(ElastalertInstanceLabel, selection.Exists, nil)
+	if err != nil {
+		panic(err)
+	}
+	return labels.NewSelector().Add(*requirement)
+}
+
 func GetUtcTimeString() string {
 	return time.Now().UTC().Format("2006-01-02T15:04:05+08:00")
 }
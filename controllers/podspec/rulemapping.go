@@ -0,0 +1,198 @@
+package podspec
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// strftimeDirective matches the date formats of an index with use_strftime_index.
+	strftimeDirective = regexp.MustCompile(`%[a-zA-Z%]`)
+
+	dateTypes    = []string{"date", "date_nanos"}
+	numericTypes = []string{"long", "integer", "short", "byte", "double", "float", "half_float", "scaled_float", "unsigned_long"}
+
+	// ruleKeyOptions are the rule options naming a field, or a list of fields, which ElastAlert aggregates on.
+	ruleKeyOptions = []string{"query_key", "compare_key", "aggregation_key", "cardinality_field", "top_count_keys"}
+	// numericAggregations are the metric_agg_type values which need a numeric or date metric_agg_key.
+	numericAggregations = []string{"min", "max", "avg", "sum", "percentiles"}
+	// fieldQueries are the queries of a filter keyed by field name.
+	fieldQueries = []string{"term", "terms", "range", "prefix", "wildcard", "regexp", "match", "match_phrase"}
+)
+
+// RuleIndexPattern returns the index pattern the rule searches, with the date formats of use_strftime_index replaced
+// by wildcards.
+func RuleIndexPattern(rule map[string]interface{}) string {
+	index, _ := rule["index"].(string)
+	if strftime, _ := rule["use_strftime_index"].(bool); strftime {
+		index = strftimeDirective.ReplaceAllString(index, "*")
+	}
+	return index
+}
+
+// ValidateRuleFields returns a warning for each field the rule references which is missing from the mappings of its
+// index, or whose type does not suit how the rule uses it. fields are the types of the fields by dotted name.
+func ValidateRuleFields(rule map[string]interface{}, fields map[string][]string) []string {
+	if len(fields) == 0 {
+		return []string{fmt.Sprintf("index %s matches no index with mappings", RuleIndexPattern(rule))}
+	}
+	var warnings []string
+	warn := func(format string, args ...interface{}) {
+		warning := fmt.Sprintf(format, args...)
+		for _, w := range warnings {
+			if w == warning {
+				return
+			}
+		}
+		warnings = append(warnings, warning)
+	}
+	// exists reports whether the field is mapped, warning about it otherwise.
+	exists := func(option, field string) bool {
+		if strings.HasPrefix(field, "_") {
+			// metadata fields such as _id are not part of the mappings.
+			return false
+		}
+		if _, ok := fields[field]; !ok {
+			warn("%s %s is not in the mappings of %s", option, field, RuleIndexPattern(rule))
+			return false
+		}
+		return true
+	}
+
+	timestampField, _ := rule["timestamp_field"].(string)
+	if timestampField == "" {
+		timestampField = "@timestamp"
+	}
+	if exists("timestamp_field", timestampField) {
+		expected := dateTypes
+		if t, _ := rule["timestamp_type"].(string); t == "unix" || t == "unix_ms" {
+			expected = append(append([]string{}, dateTypes...), numericTypes...)
+		}
+		if !hasOnlyTypes(fields[timestampField], expected) {
+			warn("timestamp_field %s is mapped as %s, not as a date", timestampField, strings.Join(fields[timestampField], ", "))
+		}
+	}
+
+	keys := map[string]interface{}{}
+	for _, option := range ruleKeyOptions {
+		keys[option] = rule[option]
+	}
+	if rule["type"] == "new_term" {
+		keys["fields"] = rule["fields"]
+	}
+	for _, option := range append(ruleKeyOptions, "fields") {
+		for _, field := range fieldNames(keys[option]) {
+			if exists(option, field) && contains(fields[field], "text") {
+				// aggregations and terms lookups on a text field fail.
+				if _, ok := fields[field+".keyword"]; ok {
+					warn("%s %s is a text field, use %s.keyword", option, field, field)
+				} else {
+					warn("%s %s is a text field, it needs a keyword field", option, field)
+				}
+			}
+		}
+	}
+
+	if field, ok := rule["metric_agg_key"].(string); ok && exists("metric_agg_key", field) {
+		aggregation, _ := rule["metric_agg_type"].(string)
+		if contains(numericAggregations, aggregation) && !hasOnlyTypes(fields[field], append(append([]string{}, numericTypes...), dateTypes...)) {
+			warn("metric_agg_key %s is mapped as %s, %s needs a numeric field", field, strings.Join(fields[field], ", "), aggregation)
+		}
+	}
+
+	for _, reference := range filterFields(rule["filter"]) {
+		if exists(reference.query+" filter on", reference.field) && reference.query == "term" && contains(fields[reference.field], "text") {
+			// terms are matched against the analyzed tokens, not the value.
+			if _, ok := fields[reference.field+".keyword"]; ok {
+				warn("term filter on text field %s rarely matches, use %s.keyword", reference.field, reference.field)
+			} else {
+				warn("term filter on text field %s rarely matches", reference.field)
+			}
+		}
+	}
+	return warnings
+}
+
+// fieldReference is a field a query of a filter references.
+type fieldReference struct {
+	query string
+	field string
+}
+
+// filterFields returns the fields the queries of a filter reference, in a stable order.
+func filterFields(filter interface{}) []fieldReference {
+	var references []fieldReference
+	switch f := filter.(type) {
+	case []interface{}:
+		for _, v := range f {
+			references = append(references, filterFields(v)...)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(f))
+		for k := range f {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			query, ok := f[k].(map[string]interface{})
+			switch {
+			case ok && contains(fieldQueries, k):
+				fieldKeys := make([]string, 0, len(query))
+				for field := range query {
+					if field != "boost" {
+						fieldKeys = append(fieldKeys, field)
+					}
+				}
+				sort.Strings(fieldKeys)
+				for _, field := range fieldKeys {
+					references = append(references, fieldReference{query: k, field: field})
+				}
+			case ok && k == "exists":
+				if field, ok := query["field"].(string); ok {
+					references = append(references, fieldReference{query: k, field: field})
+				}
+			default:
+				references = append(references, filterFields(f[k])...)
+			}
+		}
+	}
+	return references
+}
+
+// fieldNames returns the field names of a rule option holding a field, a comma separated list or a list of fields.
+func fieldNames(option interface{}) []string {
+	var names []string
+	switch o := option.(type) {
+	case string:
+		for _, name := range strings.Split(o, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	case []interface{}:
+		for _, v := range o {
+			names = append(names, fieldNames(v)...)
+		}
+	}
+	return names
+}
+
+func hasOnlyTypes(types, allowed []string) bool {
+	for _, t := range types {
+		if !contains(allowed, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package podspec
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRuleIndexPattern(t *testing.T) {
+	require.Equal(t, "logs-*", RuleIndexPattern(map[string]interface{}{"index": "logs-*"}))
+	require.Equal(t, "logs-%Y.%m.%d", RuleIndexPattern(map[string]interface{}{"index": "logs-%Y.%m.%d"}))
+	require.Equal(t, "logs-*.*.*", RuleIndexPattern(map[string]interface{}{"index": "logs-%Y.%m.%d", "use_strftime_index": true}))
+	require.Equal(t, "", RuleIndexPattern(map[string]interface{}{}))
+}
+
+func TestValidateRuleFields(t *testing.T) {
+	fields := map[string][]string{
+		"@timestamp":        {"date"},
+		"epoch":             {"long"},
+		"message":           {"text"},
+		"host.name":         {"text"},
+		"host.name.keyword": {"keyword"},
+		"user":              {"keyword"},
+		"latency":           {"keyword", "long"},
+		"bytes":             {"long"},
+	}
+	testCases := []struct {
+		name   string
+		rule   map[string]interface{}
+		fields map[string][]string
+		want   []string
+	}{
+		{
+			name: "test valid rule",
+			rule: map[string]interface{}{
+				"index":           "logs-*",
+				"type":            "metric_aggregation",
+				"query_key":       []interface{}{"user", "host.name.keyword"},
+				"metric_agg_key":  "bytes",
+				"metric_agg_type": "avg",
+				"filter": []interface{}{
+					map[string]interface{}{"term": map[string]interface{}{"user": "root"}},
+					map[string]interface{}{"query": map[string]interface{}{"query_string": map[string]interface{}{"query": "message: error"}}},
+					map[string]interface{}{"exists": map[string]interface{}{"field": "_id"}},
+				},
+			},
+			fields: fields,
+		},
+		{
+			name:   "test missing timestamp field",
+			rule:   map[string]interface{}{"index": "logs-*", "timestamp_field": "ts"},
+			fields: fields,
+			want:   []string{"timestamp_field ts is not in the mappings of logs-*"},
+		},
+		{
+			name:   "test timestamp type mismatch",
+			rule:   map[string]interface{}{"index": "logs-*", "timestamp_field": "epoch"},
+			fields: fields,
+			want:   []string{"timestamp_field epoch is mapped as long, not as a date"},
+		},
+		{
+			name:   "test unix timestamp",
+			rule:   map[string]interface{}{"index": "logs-*", "timestamp_field": "epoch", "timestamp_type": "unix"},
+			fields: fields,
+		},
+		{
+			name: "test unknown and text keys",
+			rule: map[string]interface{}{
+				"index":       "logs-*",
+				"query_key":   "host.name",
+				"compare_key": "hostname",
+				"type":        "new_term",
+				"fields":      []interface{}{"message", []interface{}{"user", "src_ip"}},
+			},
+			fields: fields,
+			want: []string{
+				"query_key host.name is a text field, use host.name.keyword",
+				"compare_key hostname is not in the mappings of logs-*",
+				"fields message is a text field, it needs a keyword field",
+				"fields src_ip is not in the mappings of logs-*",
+			},
+		},
+		{
+			name: "test metric aggregation type mismatch",
+			rule: map[string]interface{}{
+				"index":           "logs-*",
+				"metric_agg_key":  "latency",
+				"metric_agg_type": "max",
+			},
+			fields: fields,
+			want:   []string{"metric_agg_key latency is mapped as keyword, long, max needs a numeric field"},
+		},
+		{
+			name: "test filters",
+			rule: map[string]interface{}{
+				"index": "logs-*",
+				"filter": []interface{}{
+					map[string]interface{}{"term": map[string]interface{}{"host.name": "web-1"}},
+					map[string]interface{}{"term": map[string]interface{}{"message": "error"}},
+					map[string]interface{}{"bool": map[string]interface{}{
+						"must_not": []interface{}{
+							map[string]interface{}{"range": map[string]interface{}{"bytez": map[string]interface{}{"gt": 10}}},
+							map[string]interface{}{"exists": map[string]interface{}{"field": "tags"}},
+						},
+					}},
+				},
+			},
+			fields: fields,
+			want: []string{
+				"term filter on text field host.name rarely matches, use host.name.keyword",
+				"term filter on text field message rarely matches",
+				"range filter on bytez is not in the mappings of logs-*",
+				"exists filter on tags is not in the mappings of logs-*",
+			},
+		},
+		{
+			name: "test no mappings",
+			rule: map[string]interface{}{"index": "logs-%Y", "use_strftime_index": true},
+			want: []string{"index logs-* matches no index with mappings"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, ValidateRuleFields(tc.rule, tc.fields))
+		})
+	}
+}
@@ -29,6 +29,9 @@ func TestGenerateCertSecret(t *testing.T) {
 			want: v1.Secret{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "test" + DefaultCertSuffix,
+					Labels: map[string]string{
+						"es.noah.domain/elastalert": "test",
+					},
 					OwnerReferences: []metav1.OwnerReference{
 						{
 							APIVersion:         "v1",
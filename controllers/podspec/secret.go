@@ -29,6 +29,7 @@ func BuildCertSecret(e *esv1alpha1.Elastalert) *corev1.Secret {
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      e.Name + DefaultCertSuffix,
 			Namespace: e.Namespace,
+			Labels:    InstanceLabels(e.Name),
 		},
 		Data: data,
 	}
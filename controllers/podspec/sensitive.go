@@ -0,0 +1,31 @@
+package podspec
+
+import "strings"
+
+// SensitiveNames are the substrings of the names of options, headers and logged fields whose values are secrets,
+// e.g. es_password, slack_webhook_url, pagerduty_service_key, opsgenie_key or an Authorization header.
+var SensitiveNames = []string{"password", "passwd", "secret", "token", "auth", "key", "webhook"}
+
+// fieldOptions are the rule options matching SensitiveNames which name fields of the documents rather than secrets.
+var fieldOptions = map[string]bool{
+	"query_key":           true,
+	"compare_key":         true,
+	"aggregation_key":     true,
+	"metric_agg_key":      true,
+	"top_count_keys":      true,
+	"use_keyword_postfix": true,
+}
+
+// IsSensitiveName tells whether the value of the option, header or field named name is a secret.
+func IsSensitiveName(name string) bool {
+	name = strings.ToLower(name)
+	if fieldOptions[name] {
+		return false
+	}
+	for _, s := range SensitiveNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package podspec
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestIsSensitiveName(t *testing.T) {
+	testCases := []struct {
+		name string
+		want bool
+	}{
+		{name: "es_password", want: true},
+		{name: "slack_webhook_url", want: true},
+		{name: "pagerduty_service_key", want: true},
+		{name: "opsgenie_key", want: true},
+		{name: "victorops_api_key", want: true},
+		{name: "alerta_api_key", want: true},
+		{name: "Authorization", want: true},
+		{name: "telegram_bot_token", want: true},
+		{name: "query_key", want: false},
+		{name: "top_count_keys", want: false},
+		{name: "es_host", want: false},
+		{name: "http_post_headers", want: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, IsSensitiveName(tc.name))
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package podspec
+
+import (
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	// WritebackCleanupFinalizer removes the state of the instance from the writeback index before it is deleted.
+	WritebackCleanupFinalizer = "es.noah.domain/writeback-cleanup"
+	// WritebackOwnerMetaKey is the _meta key of a writeback index mapping naming the cluster and index it was created for.
+	WritebackOwnerMetaKey = "es.noah.domain/elastalert"
+	// DefaultWritebackCleanupTimeout is how long the cleanup is retried before the instance is deleted anyway.
+	DefaultWritebackCleanupTimeout = 5 * time.Minute
+	// WritebackIndexSyncInterval is how often the writeback indices are created, cleaned up and measured.
+	WritebackIndexSyncInterval = 10 * time.Minute
+)
+
+// writebackIndexSuffixes are the suffixes of the indices ElastAlert derives from writeback_index.
+var writebackIndexSuffixes = []string{"", "_status", "_silence", "_error", "_past"}
+
+var (
+	keywordMapping = map[string]interface{}{"type": "keyword"}
+	dateMapping    = map[string]interface{}{"type": "date"}
+	// opaqueMapping stores an object without indexing its fields, whose types vary between rules.
+	opaqueMapping = map[string]interface{}{"type": "object", "enabled": false}
+
+	// writebackIndexProperties are the mappings of the indices by suffix, as elastalert-create-index sets them.
+	writebackIndexProperties = map[string]map[string]interface{}{
+		"": {
+			"rule_name":    keywordMapping,
+			"@timestamp":   dateMapping,
+			"alert_time":   dateMapping,
+			"match_time":   dateMapping,
+			"match_body":   opaqueMapping,
+			"aggregate_id": keywordMapping,
+		},
+		"_status": {
+			"rule_name":  keywordMapping,
+			"@timestamp": dateMapping,
+		},
+		"_silence": {
+			"rule_name":  keywordMapping,
+			"until":      dateMapping,
+			"@timestamp": dateMapping,
+		},
+		"_error": {
+			"data":       opaqueMapping,
+			"@timestamp": dateMapping,
+		},
+		"_past": {
+			"rule_name":    keywordMapping,
+			"match_body":   opaqueMapping,
+			"aggregate_id": keywordMapping,
+			"@timestamp":   dateMapping,
+		},
+	}
+)
+
+// WritebackIndex returns the writeback_index of the config of the Elastalert.
+func WritebackIndex(e *esv1alpha1.Elastalert) (string, error) {
+	config, err := e.Spec.ConfigSetting.GetMap()
+	if err != nil {
+		return "", err
+	}
+	if index, ok := config["writeback_index"].(string); ok && index != "" {
+		return index, nil
+	}
+	return esv1alpha1.DefaultWritebackIndex, nil
+}
+
+// WritebackIndices returns the writeback index and the indices ElastAlert derives from it.
+func WritebackIndices(e *esv1alpha1.Elastalert) ([]string, error) {
+	index, err := WritebackIndex(e)
+	if err != nil {
+		return nil, err
+	}
+	var indices []string
+	for _, suffix := range writebackIndexSuffixes {
+		indices = append(indices, index+suffix)
+	}
+	return indices, nil
+}
+
+// WritebackStatusIndex returns the index ElastAlert records the runs of the rules of the Elastalert in.
+func WritebackStatusIndex(e *esv1alpha1.Elastalert) (string, error) {
+	index, err := WritebackIndex(e)
+	if err != nil {
+		return "", err
+	}
+	return index + "_status", nil
+}
+
+// WritebackOwner returns the value of WritebackOwnerMetaKey for the indices created for the Elastalert, the cluster and
+// the writeback index it resolves to, as "<es_host>:<es_port>/<writeback_index>". Instances sharing the index share it.
+func WritebackOwner(e *esv1alpha1.Elastalert) (string, error) {
+	config, err := e.Spec.ConfigSetting.GetMap()
+	if err != nil {
+		return "", err
+	}
+	index, err := WritebackIndex(e)
+	if err != nil {
+		return "", err
+	}
+	host, _ := config["es_host"].(string)
+	port := fmt.Sprint(config["es_port"])
+	if config["es_port"] == nil {
+		port = "9200"
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), port) + "/" + index, nil
+}
+
+// WritebackRuleNames returns the names of the rules of the Elastalert, under which ElastAlert writes their documents
+// to the writeback index.
+func WritebackRuleNames(e *esv1alpha1.Elastalert) ([]string, error) {
+	var names []string
+	for _, v := range e.Spec.Rule {
+		rule, err := v.GetMap()
+		if err != nil {
+			return nil, err
+		}
+		if name, ok := rule["name"].(string); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// WritebackRuleQuery returns the query matching the writeback documents of the rules of the Elastalert, nil if it has
+// no named rule. Silences of a query_key are stored as "<rule name>.<key value>", so prefixes match as well.
+func WritebackRuleQuery(e *esv1alpha1.Elastalert) (map[string]interface{}, error) {
+	names, err := WritebackRuleNames(e)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	var terms []interface{}
+	var should []interface{}
+	for _, name := range names {
+		terms = append(terms, name)
+		should = append(should, map[string]interface{}{
+			"prefix": map[string]interface{}{"rule_name": name + "."},
+		})
+	}
+	should = append([]interface{}{
+		map[string]interface{}{
+			"terms": map[string]interface{}{"rule_name": terms},
+		},
+	}, should...)
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should":               should,
+			"minimum_should_match": 1,
+		},
+	}, nil
+}
+
+// WritebackCleanupTimeout returns how long the writeback cleanup of the Elastalert is retried.
+func WritebackCleanupTimeout(e *esv1alpha1.Elastalert) time.Duration {
+	if e.Spec.WritebackCleanup != nil && e.Spec.WritebackCleanup.Timeout != nil {
+		return e.Spec.WritebackCleanup.Timeout.Duration
+	}
+	return DefaultWritebackCleanupTimeout
+}
+
+// WritebackIndexBodies returns the body creating each writeback index of the Elastalert, by index name. The mappings
+// name the owner in their _meta, so that the index is known to be created by the operator.
+func WritebackIndexBodies(e *esv1alpha1.Elastalert) (map[string]map[string]interface{}, error) {
+	index, err := WritebackIndex(e)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := WritebackOwner(e)
+	if err != nil {
+		return nil, err
+	}
+	bodies := map[string]map[string]interface{}{}
+	for _, suffix := range writebackIndexSuffixes {
+		bodies[index+suffix] = map[string]interface{}{
+			"mappings": map[string]interface{}{
+				"_meta":      map[string]interface{}{WritebackOwnerMetaKey: owner},
+				"properties": writebackIndexProperties[suffix],
+			},
+		}
+	}
+	return bodies, nil
+}
+
+// WritebackRetention returns the retention of the writeback indices of the Elastalert, zero when documents are kept forever.
+func WritebackRetention(e *esv1alpha1.Elastalert) time.Duration {
+	if e.Spec.WritebackIndex == nil || e.Spec.WritebackIndex.Retention == nil {
+		return 0
+	}
+	return e.Spec.WritebackIndex.Retention.Duration
+}
+
+// WritebackRetentionQueries returns the query matching the writeback documents older than the retention, by index
+// name. Silences are kept until they expire.
+func WritebackRetentionQueries(e *esv1alpha1.Elastalert, retention time.Duration) (map[string]map[string]interface{}, error) {
+	index, err := WritebackIndex(e)
+	if err != nil {
+		return nil, err
+	}
+	queries := map[string]map[string]interface{}{}
+	for _, suffix := range writebackIndexSuffixes {
+		field := "@timestamp"
+		if suffix == "_silence" {
+			field = "until"
+		}
+		queries[index+suffix] = map[string]interface{}{
+			"range": map[string]interface{}{
+				field: map[string]interface{}{"lt": fmt.Sprintf("now-%ds", int64(retention.Seconds()))},
+			},
+		}
+	}
+	return queries, nil
+}
@@ -0,0 +1,138 @@
+package podspec
+
+import (
+	"github.com/stretchr/testify/require"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestWritebackIndices(t *testing.T) {
+	e := &esv1alpha1.Elastalert{}
+	have, err := WritebackIndices(e)
+	require.NoError(t, err)
+	require.Equal(t, []string{"elastalert_status", "elastalert_status_status", "elastalert_status_silence", "elastalert_status_error", "elastalert_status_past"}, have)
+
+	e.Spec.ConfigSetting = esv1alpha1.NewFreeForm(map[string]interface{}{"writeback_index": "alerts"})
+	have, err = WritebackIndices(e)
+	require.NoError(t, err)
+	require.Equal(t, []string{"alerts", "alerts_status", "alerts_silence", "alerts_error", "alerts_past"}, have)
+	index, err := WritebackStatusIndex(e)
+	require.NoError(t, err)
+	require.Equal(t, "alerts_status", index)
+}
+
+func TestWritebackRuleQuery(t *testing.T) {
+	e := &esv1alpha1.Elastalert{
+		Spec: esv1alpha1.ElastalertSpec{
+			Rule: []esv1alpha1.FreeForm{
+				esv1alpha1.NewFreeForm(map[string]interface{}{"name": "disk"}),
+				esv1alpha1.NewFreeForm(map[string]interface{}{"type": "any"}),
+				esv1alpha1.NewFreeForm(map[string]interface{}{"name": "cpu"}),
+			},
+		},
+	}
+	have, err := WritebackRuleQuery(e)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should": []interface{}{
+				map[string]interface{}{"terms": map[string]interface{}{"rule_name": []interface{}{"disk", "cpu"}}},
+				map[string]interface{}{"prefix": map[string]interface{}{"rule_name": "disk."}},
+				map[string]interface{}{"prefix": map[string]interface{}{"rule_name": "cpu."}},
+			},
+			"minimum_should_match": 1,
+		},
+	}, have)
+
+	names, err := WritebackRuleNames(e)
+	require.NoError(t, err)
+	require.Equal(t, []string{"disk", "cpu"}, names)
+
+	have, err = WritebackRuleQuery(&esv1alpha1.Elastalert{})
+	require.NoError(t, err)
+	require.Nil(t, have)
+}
+
+func TestWritebackCleanupTimeout(t *testing.T) {
+	e := &esv1alpha1.Elastalert{}
+	require.Equal(t, 5*time.Minute, WritebackCleanupTimeout(e))
+	e.Spec.WritebackCleanup = &esv1alpha1.WritebackCleanup{Timeout: &metav1.Duration{Duration: time.Minute}}
+	require.Equal(t, time.Minute, WritebackCleanupTimeout(e))
+}
+
+func TestWritebackIndexBodies(t *testing.T) {
+	e := &esv1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "my-esa"},
+		Spec: esv1alpha1.ElastalertSpec{
+			ConfigSetting: esv1alpha1.NewFreeForm(map[string]interface{}{"es_host": "es.local", "writeback_index": "alerts"}),
+		},
+	}
+	have, err := WritebackIndexBodies(e)
+	require.NoError(t, err)
+	require.Len(t, have, 5)
+	require.Equal(t, map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"_meta": map[string]interface{}{"es.noah.domain/elastalert": "es.local:9200/alerts"},
+			"properties": map[string]interface{}{
+				"rule_name":  map[string]interface{}{"type": "keyword"},
+				"until":      map[string]interface{}{"type": "date"},
+				"@timestamp": map[string]interface{}{"type": "date"},
+			},
+		},
+	}, have["alerts_silence"])
+}
+
+func TestWritebackOwner(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config map[string]interface{}
+		want   string
+	}{
+		{
+			desc:   "test default index",
+			config: map[string]interface{}{"es_host": "es.local"},
+			want:   "es.local:9200/elastalert_status",
+		},
+		{
+			desc:   "test port and index",
+			config: map[string]interface{}{"es_host": "es.local", "es_port": 9243, "writeback_index": "alerts"},
+			want:   "es.local:9243/alerts",
+		},
+		{
+			desc:   "test ipv6 host",
+			config: map[string]interface{}{"es_host": "fd00::1", "writeback_index": "alerts"},
+			want:   "[fd00::1]:9200/alerts",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			e := &esv1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "my-esa"},
+				Spec:       esv1alpha1.ElastalertSpec{ConfigSetting: esv1alpha1.NewFreeForm(tc.config)},
+			}
+			have, err := WritebackOwner(e)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, have)
+		})
+	}
+}
+
+func TestWritebackRetention(t *testing.T) {
+	e := &esv1alpha1.Elastalert{}
+	require.Equal(t, time.Duration(0), WritebackRetention(e))
+	e.Spec.WritebackIndex = &esv1alpha1.WritebackIndexLifecycle{}
+	require.Equal(t, time.Duration(0), WritebackRetention(e))
+	e.Spec.WritebackIndex.Retention = &metav1.Duration{Duration: 24 * time.Hour}
+	require.Equal(t, 24*time.Hour, WritebackRetention(e))
+
+	have, err := WritebackRetentionQueries(e, 24*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"range": map[string]interface{}{"@timestamp": map[string]interface{}{"lt": "now-86400s"}},
+	}, have["elastalert_status_status"])
+	require.Equal(t, map[string]interface{}{
+		"range": map[string]interface{}{"until": map[string]interface{}{"lt": "now-86400s"}},
+	}, have["elastalert_status_silence"])
+}
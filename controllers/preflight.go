@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/elasticsearch"
+	"github.com/toughnoah/elastalert-operator/controllers/event"
+	ob "github.com/toughnoah/elastalert-operator/controllers/observer"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+)
+
+const (
+	// preflightTimeout bounds the whole preflight against elasticsearch.
+	preflightTimeout = 10 * time.Second
+	// preflightRetryInterval is how long to wait before retrying a failed preflight.
+	preflightRetryInterval = 30 * time.Second
+)
+
+// preflight checks that the elasticsearch cluster of the Elastalert is reachable with the config it is about to run
+// with, and records the outcome in the ElasticsearchReachable condition.
+func (r *ElastalertReconciler) preflight(ctx context.Context, e *esv1alpha1.Elastalert, cc *esv1alpha1.ElastalertClusterConfig) error {
+	health, err := pingElasticsearch(ctx, r.esClientFactory(), e, cc)
+	if err != nil {
+		log.Error(err, "Failed elasticsearch preflight", "Elastalert.Namespace", e.Namespace, "Elastalert.Name", e.Name)
+		reason := elasticsearch.PreflightInvalidConfig
+		var preflightErr *elasticsearch.PreflightError
+		if errors.As(err, &preflightErr) {
+			reason = preflightErr.Reason
+		}
+		// a failed status update is logged, the preflight itself decides the rollout.
+		_ = ob.UpdateElasticsearchCondition(r.Client, ctx, e, metav1.ConditionFalse, reason, fmt.Sprintf("Elasticsearch preflight failed: %v.", err))
+		return err
+	}
+	if cond := meta.FindStatusCondition(e.Status.Condictions, esv1alpha1.ElasticsearchReachableType); cond != nil && cond.Status == metav1.ConditionFalse {
+		ob.EmitK8sEvent(r.Recorder, e, corev1.EventTypeNormal, event.EventReasonElasticsearchReachable, fmt.Sprintf("Elasticsearch cluster %s is reachable again.", health.ClusterName))
+	}
+	_ = ob.UpdateElasticsearchCondition(r.Client, ctx, e, metav1.ConditionTrue, esv1alpha1.ElasticsearchReachableReason,
+		fmt.Sprintf("Elasticsearch cluster %s is reachable, its health is %s.", health.ClusterName, health.Status))
+	return nil
+}
+
+// pingElasticsearch runs the preflight of the client of the Elastalert merged with its ElastalertClusterConfig.
+func pingElasticsearch(ctx context.Context, factory elasticsearch.ClientFactory, e *esv1alpha1.Elastalert, cc *esv1alpha1.ElastalertClusterConfig) (*elasticsearch.ClusterHealth, error) {
+	merged := e.DeepCopy()
+	if _, err := podspec.MergeClusterConfig(merged, cc); err != nil {
+		return nil, &elasticsearch.PreflightError{Reason: elasticsearch.PreflightInvalidConfig, Err: err}
+	}
+	es, err := factory(merged)
+	if err != nil {
+		return nil, &elasticsearch.PreflightError{Reason: elasticsearch.PreflightInvalidConfig, Err: err}
+	}
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+	return es.Preflight(ctx)
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/elasticsearch"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+	"time"
+)
+
+func TestReconcilePreflight(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &v1alpha1.Elastalert{})
+	nsn := types.NamespacedName{Name: "my-esa", Namespace: "esa1"}
+	testCases := []struct {
+		desc             string
+		es               *fakeESClient
+		config           map[string]interface{}
+		wantRequeue      time.Duration
+		wantStatus       metav1.ConditionStatus
+		wantReason       string
+		wantPhase        string
+		wantDeployment   bool
+		wantPreflightRun bool
+	}{
+		{
+			desc:             "test elasticsearch reachable",
+			es:               &fakeESClient{health: &elasticsearch.ClusterHealth{ClusterName: "es", Status: "green"}},
+			config:           map[string]interface{}{"es_host": "es.local"},
+			wantStatus:       metav1.ConditionTrue,
+			wantReason:       "PreflightSucceeded",
+			wantPhase:        "INITIALIZING",
+			wantDeployment:   true,
+			wantPreflightRun: true,
+		},
+		{
+			desc: "test bad credentials",
+			es: &fakeESClient{err: &elasticsearch.PreflightError{
+				Reason: elasticsearch.PreflightAuthenticationFailed,
+				Err:    errors.New("GET /_cluster/health: 401 Unauthorized"),
+			}},
+			config:           map[string]interface{}{"es_host": "es.local"},
+			wantRequeue:      30 * time.Second,
+			wantStatus:       metav1.ConditionFalse,
+			wantReason:       "AuthenticationFailed",
+			wantPhase:        "FAILED",
+			wantPreflightRun: true,
+		},
+		{
+			desc:        "test missing es_host",
+			es:          &fakeESClient{},
+			config:      map[string]interface{}{"run_every": "1m"},
+			wantRequeue: 30 * time.Second,
+			wantStatus:  metav1.ConditionFalse,
+			wantReason:  "InvalidConfig",
+			wantPhase:   "FAILED",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithRuntimeObjects(
+				&v1alpha1.Elastalert{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace:  "esa1",
+						Name:       "my-esa",
+						Generation: int64(1),
+					},
+					Spec: v1alpha1.ElastalertSpec{
+						ConfigSetting: v1alpha1.NewFreeForm(tc.config),
+						Rule: []v1alpha1.FreeForm{
+							v1alpha1.NewFreeForm(map[string]interface{}{
+								"name": "test-elastalert", "type": "any",
+							}),
+						},
+					},
+				},
+			).Build()
+			r := &ElastalertReconciler{
+				Client:   c,
+				Scheme:   s,
+				Recorder: record.NewBroadcaster().NewRecorder(s, corev1.EventSource{}),
+				ESClientFactory: func(e *v1alpha1.Elastalert) (elasticsearch.Client, error) {
+					if _, err := elasticsearch.ConfigFor(e); err != nil {
+						return nil, err
+					}
+					return tc.es, nil
+				},
+				Preflight: true,
+			}
+
+			result, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: nsn})
+			require.NoError(t, err)
+			require.Equal(t, tc.wantRequeue, result.RequeueAfter)
+			require.Equal(t, tc.wantPreflightRun, len(tc.es.calls) != 0)
+			have := &v1alpha1.Elastalert{}
+			require.NoError(t, c.Get(context.Background(), nsn, have))
+			cond := meta.FindStatusCondition(have.Status.Condictions, "ElasticsearchReachable")
+			require.NotNil(t, cond)
+			require.Equal(t, tc.wantStatus, cond.Status)
+			require.Equal(t, tc.wantReason, cond.Reason)
+			require.Equal(t, tc.wantPhase, have.Status.Phase)
+			err = c.Get(context.Background(), nsn, &appsv1.Deployment{})
+			if tc.wantDeployment {
+				require.NoError(t, err)
+			} else {
+				require.True(t, k8serrors.IsNotFound(err))
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// pruneResources deletes the configmaps and secrets owned by the Elastalert instance that are no longer
+// part of its desired state, e.g. rule shards that are not needed anymore or the cert secret once the
+// cert is cleared. An object is owned when it carries the instance label or is controlled by the instance, as the
+// objects created before the label was set are. Objects annotated with es.noah.domain/prune: "false" are kept. It
+// returns the objects that were deleted.
+func pruneResources(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert) ([]string, error) {
+	var pruned []string
+
+	desiredConfigMaps := desiredConfigMapNames(e)
+	cms := &corev1.ConfigMapList{}
+	if err := c.List(ctx, cms, client.InNamespace(e.Namespace)); err != nil {
+		return nil, err
+	}
+	for i := range cms.Items {
+		cm := &cms.Items[i]
+		if !isPrunable(cm, e, desiredConfigMaps) {
+			continue
+		}
+		if err := deleteOwnedObject(c, ctx, cm); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, fmt.Sprintf("configmap %s", cm.Name))
+	}
+
+	desiredSecrets := desiredSecretNames(e)
+	secrets := &corev1.SecretList{}
+	if err := c.List(ctx, secrets, client.InNamespace(e.Namespace)); err != nil {
+		return pruned, err
+	}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if !isPrunable(secret, e, desiredSecrets) {
+			continue
+		}
+		if err := deleteOwnedObject(c, ctx, secret); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, fmt.Sprintf("secret %s", secret.Name))
+	}
+
+	log.V(1).Info(
+		"Prune resources successfully",
+		"Elastalert.Namespace", e.Namespace,
+		"Elastalert.Name", e.Name,
+		"Pruned", pruned,
+	)
+	return pruned, nil
+}
+
+func desiredConfigMapNames(e *esv1alpha1.Elastalert) map[string]bool {
+	desired := map[string]bool{
+		e.Name + esv1alpha1.ConfigSuffx: true,
+	}
+	for i := 0; i < podspec.CountRuleShards(e.Spec.Rule); i++ {
+		desired[podspec.RuleShardName(e.Name, i)] = true
+	}
+	return desired
+}
+
+func desiredSecretNames(e *esv1alpha1.Elastalert) map[string]bool {
+	desired := map[string]bool{}
+	if e.Spec.Cert != "" {
+		desired[e.Name+podspec.DefaultCertSuffix] = true
+	}
+	if podspec.HasAlerterSecrets(e) {
+		desired[e.Name+podspec.DefaultAlerterSecretSuffix] = true
+	}
+	return desired
+}
+
+func isPrunable(obj client.Object, e *esv1alpha1.Elastalert, desired map[string]bool) bool {
+	if desired[obj.GetName()] {
+		return false
+	}
+	if obj.GetAnnotations()[podspec.PruneAnnotation] == "false" {
+		return false
+	}
+	return obj.GetLabels()[podspec.ElastalertInstanceLabel] == e.Name || metav1.IsControlledBy(obj, e)
+}
+
+func deleteOwnedObject(c client.Client, ctx context.Context, obj client.Object) error {
+	if err := c.Delete(ctx, obj); err != nil && !k8serrors.IsNotFound(err) {
+		log.Error(err, "Failed to prune resource", "Namespace", obj.GetNamespace(), "Name", obj.GetName())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+func TestPruneResources(t *testing.T) {
+	controller := true
+	testCases := []struct {
+		desc           string
+		elastalert     v1alpha1.Elastalert
+		c              client.Client
+		wantPruned     []string
+		wantConfigMaps []string
+		wantSecrets    []string
+	}{
+		{
+			desc: "test prune stale rule shards",
+			elastalert: v1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "esa1",
+					Name:      "my-esa",
+				},
+			},
+			c: fake.NewClientBuilder().WithRuntimeObjects(
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "esa1",
+						Name:      "my-esa-rule",
+						Labels:    map[string]string{podspec.ElastalertInstanceLabel: "my-esa"},
+					},
+				},
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "esa1",
+						Name:      "my-esa-rule-1",
+						Labels:    map[string]string{podspec.ElastalertInstanceLabel: "my-esa"},
+					},
+				},
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "esa1",
+						Name:      "other-esa-rule-1",
+						Labels:    map[string]string{podspec.ElastalertInstanceLabel: "other-esa"},
+					},
+				},
+			).Build(),
+			wantPruned:     []string{"configmap my-esa-rule-1"},
+			wantConfigMaps: []string{"my-esa-rule", "other-esa-rule-1"},
+		},
+		{
+			desc: "test prune cert secret after cert is cleared",
+			elastalert: v1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "esa1",
+					Name:      "my-esa",
+				},
+			},
+			c: fake.NewClientBuilder().WithRuntimeObjects(
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "esa1",
+						Name:      "my-esa" + podspec.DefaultCertSuffix,
+						Labels:    map[string]string{podspec.ElastalertInstanceLabel: "my-esa"},
+					},
+				},
+			).Build(),
+			wantPruned: []string{"secret my-esa-es-cert"},
+		},
+		{
+			desc: "test keep unlabelled objects",
+			elastalert: v1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "esa1",
+					Name:      "my-esa",
+				},
+			},
+			c: fake.NewClientBuilder().WithRuntimeObjects(
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "esa1",
+						Name:      "smtp",
+					},
+				},
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "esa1",
+						Name:      "my-esa-notes",
+					},
+				},
+			).Build(),
+			wantConfigMaps: []string{"my-esa-notes"},
+			wantSecrets:    []string{"smtp"},
+		},
+		{
+			desc: "test prune unlabelled objects controlled by the instance",
+			elastalert: v1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "esa1",
+					Name:      "my-esa",
+					UID:       "my-esa-uid",
+				},
+			},
+			c: fake.NewClientBuilder().WithRuntimeObjects(
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "esa1",
+						Name:      "my-esa-rule-1",
+						OwnerReferences: []metav1.OwnerReference{
+							{APIVersion: "es.noah.domain/v1alpha1", Kind: "Elastalert", Name: "my-esa", UID: "my-esa-uid", Controller: &controller},
+						},
+					},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "esa1",
+						Name:      "my-esa" + podspec.DefaultCertSuffix,
+						OwnerReferences: []metav1.OwnerReference{
+							{APIVersion: "es.noah.domain/v1alpha1", Kind: "Elastalert", Name: "my-esa", UID: "my-esa-uid", Controller: &controller},
+						},
+					},
+				},
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "esa1",
+						Name:      "my-esa-notes",
+					},
+				},
+			).Build(),
+			wantPruned:     []string{"configmap my-esa-rule-1", "secret my-esa-es-cert"},
+			wantConfigMaps: []string{"my-esa-notes"},
+		},
+		{
+			desc: "test keep desired cert secret and annotated configmap",
+			elastalert: v1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "esa1",
+					Name:      "my-esa",
+				},
+				Spec: v1alpha1.ElastalertSpec{
+					Cert: "abc",
+				},
+			},
+			c: fake.NewClientBuilder().WithRuntimeObjects(
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "esa1",
+						Name:      "my-esa" + podspec.DefaultCertSuffix,
+						Labels:    map[string]string{podspec.ElastalertInstanceLabel: "my-esa"},
+					},
+				},
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace:   "esa1",
+						Name:        "my-esa-debug",
+						Labels:      map[string]string{podspec.ElastalertInstanceLabel: "my-esa"},
+						Annotations: map[string]string{podspec.PruneAnnotation: "false"},
+					},
+				},
+			).Build(),
+			wantConfigMaps: []string{"my-esa-debug"},
+			wantSecrets:    []string{"my-esa-es-cert"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			pruned, err := pruneResources(tc.c, context.Background(), &tc.elastalert)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantPruned, pruned)
+
+			cms := corev1.ConfigMapList{}
+			require.NoError(t, tc.c.List(context.Background(), &cms))
+			var cmNames []string
+			for _, cm := range cms.Items {
+				cmNames = append(cmNames, cm.Name)
+			}
+			assert.ElementsMatch(t, tc.wantConfigMaps, cmNames)
+
+			secrets := corev1.SecretList{}
+			require.NoError(t, tc.c.List(context.Background(), &secrets))
+			var secretNames []string
+			for _, se := range secrets.Items {
+				secretNames = append(secretNames, se.Name)
+			}
+			assert.ElementsMatch(t, tc.wantSecrets, secretNames)
+		})
+	}
+}
+
+func TestPruneResourcesListError(t *testing.T) {
+	_, err := pruneResources(&ErrorClient{}, context.Background(), &v1alpha1.Elastalert{})
+	require.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"context"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Resources are the objects the operator applies for an Elastalert.
+type Resources struct {
+	Secrets    []corev1.Secret
+	ConfigMaps []corev1.ConfigMap
+	Deployment *appsv1.Deployment
+}
+
+// Objects returns the resources in the order Reconcile applies them.
+func (r *Resources) Objects() []client.Object {
+	var objects []client.Object
+	for i := range r.Secrets {
+		objects = append(objects, &r.Secrets[i])
+	}
+	for i := range r.ConfigMaps {
+		objects = append(objects, &r.ConfigMaps[i])
+	}
+	if r.Deployment != nil {
+		objects = append(objects, r.Deployment)
+	}
+	return objects
+}
+
+// Render builds the resources Reconcile applies for the Elastalert with the same steps, reading the
+// ElastalertClusterConfig, the alert profiles and the secrets it references with c. Nothing is written, so that the
+// resources can be reviewed without a cluster. e is patched the way Reconcile patches it.
+func Render(c client.Client, scheme *runtime.Scheme, ctx context.Context, e *esv1alpha1.Elastalert) (*Resources, error) {
+	cc, err := resolveClusterConfig(c, ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	merged := e.DeepCopy()
+	if _, err = podspec.MergeClusterConfig(merged, cc); err != nil {
+		return nil, err
+	}
+	e.Spec = merged.Spec
+	if err = podspec.PatchMaintenanceSettings(e); err != nil {
+		return nil, err
+	}
+	resources := &Resources{}
+	if e.Spec.Cert != "" {
+		secret, err := podspec.GenerateCertSecret(scheme, e)
+		if err != nil {
+			return nil, err
+		}
+		resources.Secrets = append(resources.Secrets, *secret)
+	}
+	if podspec.HasAlerterSecrets(e) {
+		values, err := resolveAlerterSecrets(c, ctx, e)
+		if err != nil {
+			return nil, err
+		}
+		secret, err := podspec.GenerateAlerterSecret(scheme, e, values)
+		if err != nil {
+			return nil, err
+		}
+		resources.Secrets = append(resources.Secrets, *secret)
+	}
+	if resources.ConfigMaps, err = renderConfigMaps(c, scheme, ctx, e); err != nil {
+		return nil, err
+	}
+	if resources.Deployment, err = podspec.GenerateNewDeployment(scheme, e); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		&v1alpha1.ElastalertClusterConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "default"},
+			Spec: v1alpha1.ElastalertClusterConfigSpec{
+				Config: v1alpha1.NewFreeForm(map[string]interface{}{"es_host": "es.default"}),
+				Cert:   "default-cert",
+			},
+		},
+	).Build()
+	e := &v1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "my-esa"},
+		Spec: v1alpha1.ElastalertSpec{
+			ConfigSetting: v1alpha1.NewFreeForm(map[string]interface{}{"run_every": map[string]interface{}{"minutes": 1}}),
+			Rule: []v1alpha1.FreeForm{
+				v1alpha1.NewFreeForm(map[string]interface{}{"name": "test-rule", "type": "any"}),
+			},
+		},
+	}
+	have, err := Render(c, s, context.Background(), e)
+	require.NoError(t, err)
+	var names []string
+	for _, obj := range have.Objects() {
+		names = append(names, obj.GetName())
+	}
+	require.Equal(t, []string{"my-esa-es-cert", "my-esa-rule", "my-esa-config", "my-esa"}, names)
+	require.Contains(t, have.ConfigMaps[1].Data["config.yaml"], "es_host: es.default")
+
+	// nothing is written.
+	configMaps := &corev1.ConfigMapList{}
+	require.NoError(t, c.List(context.Background(), configMaps, client.InNamespace("esa1")))
+	require.Empty(t, configMaps.Items)
+}
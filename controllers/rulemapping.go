@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/elasticsearch"
+	"github.com/toughnoah/elastalert-operator/controllers/event"
+	"github.com/toughnoah/elastalert-operator/controllers/lint"
+	ob "github.com/toughnoah/elastalert-operator/controllers/observer"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	corev1 "k8s.io/api/core/v1"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"time"
+)
+
+// ruleValidationTimeout bounds fetching the mappings of every rule.
+const ruleValidationTimeout = 30 * time.Second
+
+// validateRules lints the rules and checks the fields they reference against the mappings of their index, as far as
+// the Elastalert opts in, records the warnings in status and emits an event for each rule with warnings. It never
+// blocks a rollout.
+func (r *ElastalertReconciler) validateRules(ctx context.Context, e *esv1alpha1.Elastalert) {
+	var rules []esv1alpha1.RuleStatus
+	if e.Spec.LintRules {
+		linted, err := ruleLintWarnings(r.Client, ctx, e)
+		if err != nil {
+			log.Error(err, "Failed to lint rules", "Elastalert.Namespace", e.Namespace, "Elastalert.Name", e.Name)
+			ob.EmitFailure(r.Recorder, e, event.EventReasonError, "Failed to lint rules", err)
+			return
+		}
+		rules = mergeRuleStatus(rules, linted)
+	}
+	if e.Spec.ValidateRuleMappings {
+		validationCtx, cancel := context.WithTimeout(ctx, ruleValidationTimeout)
+		defer cancel()
+		validated, err := ruleMappingWarnings(validationCtx, r.esClientFactory(), e)
+		if err != nil {
+			log.Error(err, "Failed to validate rules against index mappings", "Elastalert.Namespace", e.Namespace, "Elastalert.Name", e.Name)
+			ob.EmitFailure(r.Recorder, e, event.EventReasonError, "Failed to validate rules against index mappings", err)
+			return
+		}
+		rules = mergeRuleStatus(rules, validated)
+	}
+	for _, rule := range rules {
+		if len(rule.Warnings) != 0 {
+			ob.EmitK8sEvent(r.Recorder, e, corev1.EventTypeWarning, event.EventReasonRuleInvalid, fmt.Sprintf("Rule %s: %s.", rule.Name, strings.Join(rule.Warnings, "; ")))
+		}
+	}
+	if reflect.DeepEqual(e.Status.Rules, rules) {
+		return
+	}
+	original := e.DeepCopy()
+	e.Status.Rules = rules
+	if err := r.Status().Patch(ctx, e, client.MergeFrom(original)); err != nil {
+		log.Error(err, "Failed to update rules status", "Elastalert.Namespace", e.Namespace, "Elastalert.Name", e.Name)
+	}
+}
+
+// ruleLintWarnings lints the rules the way they are rendered, a rule without findings has no warnings.
+func ruleLintWarnings(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert) ([]esv1alpha1.RuleStatus, error) {
+	patched := e.DeepCopy()
+	if err := patchRules(c, ctx, patched); err != nil {
+		return nil, err
+	}
+	var rules []map[string]interface{}
+	for _, v := range patched.Spec.Rule {
+		rule, err := v.GetMap()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	findings := lint.Rules(rules)
+	var statuses []esv1alpha1.RuleStatus
+	seen := map[string]bool{}
+	for i, rule := range rules {
+		name := lint.RuleName(rule, i)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		status := esv1alpha1.RuleStatus{Name: name}
+		for _, f := range findings {
+			if f.Rule == name {
+				status.Warnings = append(status.Warnings, f.String())
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// mergeRuleStatus appends the warnings of each rule of add to the status of the same rule, in the order of the rules.
+func mergeRuleStatus(rules, add []esv1alpha1.RuleStatus) []esv1alpha1.RuleStatus {
+	for _, a := range add {
+		merged := false
+		for i := range rules {
+			if rules[i].Name == a.Name {
+				rules[i].Warnings = append(rules[i].Warnings, a.Warnings...)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			rules = append(rules, a)
+		}
+	}
+	return rules
+}
+
+// ruleMappingWarnings validates each rule against the mappings of its index. Rules searching another cluster than the
+// one of the config are left out.
+func ruleMappingWarnings(ctx context.Context, factory elasticsearch.ClientFactory, e *esv1alpha1.Elastalert) ([]esv1alpha1.RuleStatus, error) {
+	es, err := factory(e)
+	if err != nil {
+		return nil, err
+	}
+	mappings := map[string]map[string][]string{}
+	var rules []esv1alpha1.RuleStatus
+	for _, v := range e.Spec.Rule {
+		rule, err := v.GetMap()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := rule[esv1alpha1.ClusterRuleKey]; ok {
+			continue
+		}
+		if _, ok := rule["es_host"]; ok {
+			continue
+		}
+		status := esv1alpha1.RuleStatus{Name: fmt.Sprint(rule["name"])}
+		index := podspec.RuleIndexPattern(rule)
+		if index == "" {
+			status.Warnings = []string{"index is not set"}
+			rules = append(rules, status)
+			continue
+		}
+		fields, ok := mappings[index]
+		if !ok {
+			if fields, err = es.FieldMappings(ctx, index); err != nil {
+				return nil, err
+			}
+			mappings[index] = fields
+		}
+		status.Warnings = podspec.ValidateRuleFields(rule, fields)
+		rules = append(rules, status)
+	}
+	return rules, nil
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/elasticsearch"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+func TestValidateRuleMappings(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	nsn := types.NamespacedName{Namespace: "esa1", Name: "my-esa"}
+	logs := map[string][]string{
+		"@timestamp":        {"date"},
+		"host.name":         {"text"},
+		"host.name.keyword": {"keyword"},
+		"status":            {"keyword"},
+	}
+	testCases := []struct {
+		desc      string
+		validate  bool
+		es        *fakeESClient
+		status    []v1alpha1.RuleStatus
+		wantRules []v1alpha1.RuleStatus
+		wantCalls []string
+	}{
+		{
+			desc:     "test warnings per rule",
+			validate: true,
+			es:       &fakeESClient{fields: map[string]map[string][]string{"logs-*": logs}},
+			wantRules: []v1alpha1.RuleStatus{
+				{Name: "ok"},
+				{Name: "typo", Warnings: []string{
+					"query_key host.name is a text field, use host.name.keyword",
+					"term filter on stauts is not in the mappings of logs-*",
+				}},
+				{Name: "no-index", Warnings: []string{"index is not set"}},
+			},
+			// the mappings of an index pattern are fetched once.
+			wantCalls: []string{"FieldMappings"},
+		},
+		{
+			desc:      "test elasticsearch failure keeps status",
+			validate:  true,
+			es:        &fakeESClient{err: errors.New("connection refused")},
+			status:    []v1alpha1.RuleStatus{{Name: "ok"}},
+			wantRules: []v1alpha1.RuleStatus{{Name: "ok"}},
+			wantCalls: []string{"FieldMappings"},
+		},
+		{
+			desc:   "test disabled clears status",
+			es:     &fakeESClient{},
+			status: []v1alpha1.RuleStatus{{Name: "ok"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			e := &v1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "my-esa"},
+				Spec: v1alpha1.ElastalertSpec{
+					ConfigSetting: v1alpha1.NewFreeForm(map[string]interface{}{"es_host": "es.local"}),
+					Rule: []v1alpha1.FreeForm{
+						v1alpha1.NewFreeForm(map[string]interface{}{
+							"name":  "ok",
+							"index": "logs-*",
+							"filter": []interface{}{
+								map[string]interface{}{"term": map[string]interface{}{"status": "error"}},
+							},
+						}),
+						v1alpha1.NewFreeForm(map[string]interface{}{
+							"name":      "typo",
+							"index":     "logs-*",
+							"query_key": "host.name",
+							"filter": []interface{}{
+								map[string]interface{}{"term": map[string]interface{}{"stauts": "error"}},
+							},
+						}),
+						v1alpha1.NewFreeForm(map[string]interface{}{"name": "no-index"}),
+						// rules searching another cluster are left out.
+						v1alpha1.NewFreeForm(map[string]interface{}{"name": "remote", "index": "metrics", "es_cluster": "remote"}),
+					},
+					ValidateRuleMappings: tc.validate,
+				},
+				Status: v1alpha1.ElastalertStatus{Rules: tc.status},
+			}
+			c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(e).Build()
+			r := &ElastalertReconciler{
+				Client:   c,
+				Scheme:   s,
+				Recorder: record.NewBroadcaster().NewRecorder(s, corev1.EventSource{}),
+				ESClientFactory: func(e *v1alpha1.Elastalert) (elasticsearch.Client, error) {
+					return tc.es, nil
+				},
+			}
+			have := &v1alpha1.Elastalert{}
+			require.NoError(t, c.Get(context.Background(), nsn, have))
+			r.validateRules(context.Background(), have)
+			require.NoError(t, c.Get(context.Background(), nsn, have))
+			require.Equal(t, tc.wantRules, have.Status.Rules)
+			require.Equal(t, tc.wantCalls, tc.es.calls)
+		})
+	}
+}
+
+func TestValidateRulesLint(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	nsn := types.NamespacedName{Namespace: "esa1", Name: "my-esa"}
+	e := &v1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "my-esa"},
+		Spec: v1alpha1.ElastalertSpec{
+			ConfigSetting: v1alpha1.NewFreeForm(map[string]interface{}{"es_host": "es.local"}),
+			// rules are linted once overall is merged into them.
+			Alert: v1alpha1.NewFreeForm(map[string]interface{}{"alert": []interface{}{"slack"}}),
+			Rule: []v1alpha1.FreeForm{
+				v1alpha1.NewFreeForm(map[string]interface{}{
+					"name":       "ok",
+					"type":       "frequency",
+					"index":      "logs-*",
+					"num_events": 5,
+					"timeframe":  map[string]interface{}{"minutes": 5},
+				}),
+				v1alpha1.NewFreeForm(map[string]interface{}{
+					"name":      "incomplete",
+					"type":      "frequency",
+					"index":     "logs-*",
+					"query_key": "host.name",
+					"timeframe": map[string]interface{}{"minute": 5},
+				}),
+			},
+			LintRules:            true,
+			ValidateRuleMappings: true,
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(e).Build()
+	r := &ElastalertReconciler{
+		Client:   c,
+		Scheme:   s,
+		Recorder: record.NewBroadcaster().NewRecorder(s, corev1.EventSource{}),
+		ESClientFactory: func(e *v1alpha1.Elastalert) (elasticsearch.Client, error) {
+			return &fakeESClient{fields: map[string]map[string][]string{"logs-*": {
+				"@timestamp": {"date"},
+				"host.name":  {"text"},
+			}}}, nil
+		},
+	}
+	have := &v1alpha1.Elastalert{}
+	require.NoError(t, c.Get(context.Background(), nsn, have))
+	r.validateRules(context.Background(), have)
+	require.NoError(t, c.Get(context.Background(), nsn, have))
+	require.Equal(t, []v1alpha1.RuleStatus{
+		{Name: "ok"},
+		{Name: "incomplete", Warnings: []string{
+			"error: frequency rule needs num_events",
+			"error: timeframe has unknown unit minute, use weeks, days, hours, minutes, seconds, milliseconds or microseconds",
+			"query_key host.name is a text field, it needs a keyword field",
+		}},
+	}, have.Status.Rules)
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
+)
+
+// hashSecrets hashes the values of the Secrets referenced by the Elastalert, the alerter and cluster credentials and
+// the cluster CAs, so that a rotation is applied like a spec change. It is empty when the Elastalert references none.
+func hashSecrets(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert) (string, error) {
+	values, err := resolveAlerterSecrets(c, ctx, e)
+	if err != nil {
+		return "", err
+	}
+	for _, cluster := range e.Spec.Clusters {
+		if cluster.CA == nil {
+			continue
+		}
+		secret := &corev1.Secret{}
+		if err = c.Get(ctx, types.NamespacedName{Namespace: e.Namespace, Name: cluster.CA.Name}, secret); err != nil {
+			if k8serrors.IsNotFound(err) {
+				// the pods cannot start without a required CA, which the health checks report.
+				continue
+			}
+			log.Error(err, "Failed to get cluster CA Secret", "Elastalert.Namespace", e.Namespace, "Secret.Name", cluster.CA.Name)
+			return "", err
+		}
+		values["ca/"+cluster.Name] = string(secret.Data[cluster.CA.Key])
+	}
+	if len(values) == 0 {
+		return "", nil
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write([]byte(values[k]))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// secretsChanged determines if the values of the Secrets referenced by the Elastalert differ from the ones last
+// applied to it.
+func secretsChanged(e *esv1alpha1.Elastalert, hash string, err error) bool {
+	// let applyAlerterSecret surface the error.
+	return err != nil || hash != e.Status.SecretsHash
+}
+
+// referencedSecrets returns the names of the Secrets the Elastalert references.
+func referencedSecrets(e *esv1alpha1.Elastalert) map[string]bool {
+	names := map[string]bool{}
+	for _, ref := range podspec.SecretRefs(e) {
+		names[ref.Name] = true
+	}
+	for _, cluster := range e.Spec.Clusters {
+		if cluster.CA != nil {
+			names[cluster.CA.Name] = true
+		}
+	}
+	return names
+}
+
+// elastalertsForSecret maps a Secret to the Elastalerts of its namespace referencing it.
+func elastalertsForSecret(c client.Client) func(obj client.Object) []reconcile.Request {
+	return func(obj client.Object) []reconcile.Request {
+		list := &esv1alpha1.ElastalertList{}
+		if err := c.List(context.Background(), list, client.InNamespace(obj.GetNamespace())); err != nil {
+			log.Error(err, "Failed to list Elastalerts for Secret", "Secret.Namespace", obj.GetNamespace(), "Secret.Name", obj.GetName())
+			return nil
+		}
+		var requests []reconcile.Request
+		for i := range list.Items {
+			if referencedSecrets(&list.Items[i])[obj.GetName()] {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: list.Items[i].Namespace,
+					Name:      list.Items[i].Name,
+				}})
+			}
+		}
+		return requests
+	}
+}
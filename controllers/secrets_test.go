@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+)
+
+var logsCluster = v1alpha1.ElasticsearchCluster{
+	Name: "logs",
+	Host: "logs.es",
+	Port: 9243,
+	CA: &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "logs-ca"},
+		Key:                  "ca.pem",
+	},
+	Credentials: &v1alpha1.ElasticsearchCredentials{
+		Username: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "logs"}, Key: "user"},
+		Password: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "logs"}, Key: "password"},
+	},
+}
+
+func TestHashSecrets(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	require.NoError(t, corev1.AddToScheme(s))
+	e := &v1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "my-elastalert"},
+		Spec:       v1alpha1.ElastalertSpec{Clusters: []v1alpha1.ElasticsearchCluster{logsCluster}},
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "logs"},
+		Data:       map[string][]byte{"user": []byte("elastic"), "password": []byte("changeme")},
+	}
+	ca := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "logs-ca"},
+		Data:       map[string][]byte{"ca.pem": []byte("ca")},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(credentials, ca).Build()
+	hash, err := hashSecrets(c, context.Background(), e)
+	require.NoError(t, err)
+	e.Status.SecretsHash = hash
+	require.False(t, secretsChanged(e, hash, nil))
+
+	testCases := []struct {
+		desc   string
+		secret *corev1.Secret
+		key    string
+		value  string
+	}{
+		{desc: "test rotated password", secret: credentials, key: "password", value: "rotated"},
+		{desc: "test renewed ca", secret: ca, key: "ca.pem", value: "renewed"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			secret := tc.secret.DeepCopy()
+			require.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "esa1", Name: secret.Name}, secret))
+			old := string(secret.Data[tc.key])
+			secret.Data[tc.key] = []byte(tc.value)
+			require.NoError(t, c.Update(context.Background(), secret))
+			have, err := hashSecrets(c, context.Background(), e)
+			require.NoError(t, err)
+			require.True(t, secretsChanged(e, have, err))
+
+			secret.Data[tc.key] = []byte(old)
+			require.NoError(t, c.Update(context.Background(), secret))
+			have, err = hashSecrets(c, context.Background(), e)
+			require.NoError(t, err)
+			require.False(t, secretsChanged(e, have, err))
+		})
+	}
+
+	require.NoError(t, c.Delete(context.Background(), credentials))
+	have, err := hashSecrets(c, context.Background(), e)
+	require.Error(t, err)
+	require.True(t, secretsChanged(e, have, err))
+}
+
+func TestElastalertsForSecret(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		&v1alpha1.Elastalert{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "uses-logs"},
+			Spec:       v1alpha1.ElastalertSpec{Clusters: []v1alpha1.ElasticsearchCluster{logsCluster}},
+		},
+		&v1alpha1.Elastalert{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "no-clusters"},
+		},
+		&v1alpha1.Elastalert{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "esa2", Name: "uses-logs"},
+			Spec:       v1alpha1.ElastalertSpec{Clusters: []v1alpha1.ElasticsearchCluster{logsCluster}},
+		},
+	).Build()
+	testCases := []struct {
+		desc   string
+		secret string
+		want   []reconcile.Request
+	}{
+		{
+			desc:   "test credentials secret",
+			secret: "logs",
+			want:   []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "esa1", Name: "uses-logs"}}},
+		},
+		{
+			desc:   "test ca secret",
+			secret: "logs-ca",
+			want:   []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "esa1", Name: "uses-logs"}}},
+		},
+		{
+			desc:   "test unreferenced secret",
+			secret: "other",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			have := elastalertsForSecret(c)(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: tc.secret},
+			})
+			require.Equal(t, tc.want, have)
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	esv1alpha1 "github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/elasticsearch"
+	"github.com/toughnoah/elastalert-operator/controllers/event"
+	"github.com/toughnoah/elastalert-operator/controllers/metrics"
+	ob "github.com/toughnoah/elastalert-operator/controllers/observer"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"time"
+)
+
+const (
+	// writebackCleanupRetryInterval is how long to wait before retrying a failed writeback cleanup.
+	writebackCleanupRetryInterval = 30 * time.Second
+	// writebackSyncTimeout bounds a whole writeback index sync against elasticsearch, so that a slow cluster never
+	// holds a worker.
+	writebackSyncTimeout = time.Minute
+	// ruleRunsTimeout bounds reading the latest runs of the rules from elasticsearch.
+	ruleRunsTimeout = 10 * time.Second
+)
+
+// ensureWritebackFinalizer adds the writeback cleanup finalizer when the Elastalert opts in, and removes it otherwise.
+func ensureWritebackFinalizer(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert) error {
+	wanted := e.Spec.WritebackCleanup != nil
+	if wanted == controllerutil.ContainsFinalizer(e, podspec.WritebackCleanupFinalizer) {
+		return nil
+	}
+	patch := client.MergeFrom(e.DeepCopy())
+	if wanted {
+		controllerutil.AddFinalizer(e, podspec.WritebackCleanupFinalizer)
+	} else {
+		controllerutil.RemoveFinalizer(e, podspec.WritebackCleanupFinalizer)
+	}
+	if err := c.Patch(ctx, e, patch); err != nil {
+		log.Error(err, "Failed to update writeback cleanup finalizer", "Elastalert.Namespace", e.Namespace, "Elastalert.Name", e.Name)
+		return err
+	}
+	return nil
+}
+
+// finalizeWriteback cleans up the writeback index of a deleted Elastalert and removes the finalizer. A failed cleanup
+// is retried until the timeout of the Elastalert, then given up so that the deletion never blocks forever.
+func (r *ElastalertReconciler) finalizeWriteback(ctx context.Context, e *esv1alpha1.Elastalert) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(e, podspec.WritebackCleanupFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	timeout := podspec.WritebackCleanupTimeout(e)
+	remaining := e.DeletionTimestamp.Add(timeout).Sub(r.now())
+	if remaining <= 0 {
+		ob.EmitK8sEvent(r.Recorder, e, corev1.EventTypeWarning, event.EventReasonError, fmt.Sprintf("Gave up cleaning up the writeback index after %s.", timeout))
+		return ctrl.Result{}, removeWritebackFinalizer(r.Client, ctx, e)
+	}
+	cleanupCtx, cancel := context.WithTimeout(ctx, remaining)
+	defer cancel()
+	message, err := cleanupWriteback(r.Client, cleanupCtx, r.esClientFactory(), e)
+	if err != nil {
+		log.Error(err, "Failed to clean up writeback index", "Elastalert.Namespace", e.Namespace, "Elastalert.Name", e.Name)
+		ob.EmitK8sEvent(r.Recorder, e, corev1.EventTypeWarning, event.EventReasonError, fmt.Sprintf("Failed to clean up the writeback index, retrying: %v.", err))
+		if remaining > writebackCleanupRetryInterval {
+			remaining = writebackCleanupRetryInterval
+		}
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+	ob.EmitK8sEvent(r.Recorder, e, corev1.EventTypeNormal, event.EventReasonDeleted, message)
+	return ctrl.Result{}, removeWritebackFinalizer(r.Client, ctx, e)
+}
+
+// cleanupWriteback deletes the writeback indices if the operator created them and no other Elastalert writes back to
+// them, and the documents of its rules otherwise. It returns a message describing what was deleted.
+func cleanupWriteback(c client.Client, ctx context.Context, factory elasticsearch.ClientFactory, e *esv1alpha1.Elastalert) (string, error) {
+	es, merged, err := writebackClient(c, ctx, factory, e)
+	if err != nil {
+		return "", err
+	}
+	indices, err := podspec.WritebackIndices(merged)
+	if err != nil {
+		return "", err
+	}
+	meta, found, err := es.IndexMeta(ctx, indices[0])
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return fmt.Sprintf("Writeback index %s does not exist, nothing to clean up.", indices[0]), nil
+	}
+	owner, err := podspec.WritebackOwner(merged)
+	if err != nil {
+		return "", err
+	}
+	if meta[podspec.WritebackOwnerMetaKey] == owner {
+		shared, err := writebackShared(c, ctx, e, owner)
+		if err != nil {
+			return "", err
+		}
+		if !shared {
+			if err = es.DeleteIndices(ctx, indices); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Deleted writeback index %s created by the operator.", indices[0]), nil
+		}
+	}
+	query, err := podspec.WritebackRuleQuery(merged)
+	if err != nil {
+		return "", err
+	}
+	if query == nil {
+		return "No rule to clean up from the writeback index.", nil
+	}
+	deleted, err := es.DeleteByQuery(ctx, indices, query)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Deleted %d documents of the rules from writeback index %s.", deleted, indices[0]), nil
+}
+
+// writebackShared determines if another Elastalert writes back to the same index of the same cluster, whose documents
+// deleting the index would take along.
+func writebackShared(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert, owner string) (bool, error) {
+	list := &esv1alpha1.ElastalertList{}
+	if err := c.List(ctx, list); err != nil {
+		log.Error(err, "Failed to list Elastalerts sharing the writeback index", "Elastalert.Namespace", e.Namespace, "Elastalert.Name", e.Name)
+		return false, err
+	}
+	for i := range list.Items {
+		other := &list.Items[i]
+		if other.Namespace == e.Namespace && other.Name == e.Name {
+			continue
+		}
+		cc, err := resolveClusterConfig(c, ctx, other)
+		if err != nil {
+			return false, err
+		}
+		if _, err = podspec.MergeClusterConfig(other, cc); err != nil {
+			// an instance whose config cannot be resolved may still use the index.
+			return true, nil
+		}
+		if o, err := podspec.WritebackOwner(other); err != nil || o == owner {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// syncWritebackIndex creates the missing writeback indices of the Elastalert, applies its retention and records their
+// size in status, at most once per WritebackIndexSyncInterval. It returns when to sync again, zero if the Elastalert
+// does not let the operator manage its writeback indices. A failed sync is reported with an event and retried at the
+// next one.
+func (r *ElastalertReconciler) syncWritebackIndex(ctx context.Context, e *esv1alpha1.Elastalert) time.Duration {
+	if e.Spec.WritebackIndex == nil {
+		return 0
+	}
+	now := r.now()
+	if last := e.Status.WritebackIndexSyncTime; last != nil {
+		if next := last.Add(podspec.WritebackIndexSyncInterval).Sub(now); next > 0 {
+			return next
+		}
+	}
+	original := e.DeepCopy()
+	syncCtx, cancel := context.WithTimeout(ctx, writebackSyncTimeout)
+	defer cancel()
+	indices, err := manageWritebackIndex(r.Client, syncCtx, r.esClientFactory(), e)
+	if err != nil {
+		log.Error(err, "Failed to sync writeback index", "Elastalert.Namespace", e.Namespace, "Elastalert.Name", e.Name)
+		ob.EmitK8sEvent(r.Recorder, e, corev1.EventTypeWarning, event.EventReasonError, fmt.Sprintf("Failed to sync the writeback index: %v.", err))
+	} else {
+		e.Status.WritebackIndices = indices
+	}
+	e.Status.WritebackIndexSyncTime = &metav1.Time{Time: now}
+	if err = r.Status().Patch(ctx, e, client.MergeFrom(original)); err != nil {
+		log.Error(err, "Failed to update writeback index status", "Elastalert.Namespace", e.Namespace, "Elastalert.Name", e.Name)
+	}
+	return podspec.WritebackIndexSyncInterval
+}
+
+// manageWritebackIndex creates the missing writeback indices with their mappings, applies the retention of the
+// Elastalert and returns the size of the indices.
+func manageWritebackIndex(c client.Client, ctx context.Context, factory elasticsearch.ClientFactory, e *esv1alpha1.Elastalert) ([]esv1alpha1.WritebackIndexStatus, error) {
+	es, merged, err := writebackClient(c, ctx, factory, e)
+	if err != nil {
+		return nil, err
+	}
+	indices, err := podspec.WritebackIndices(merged)
+	if err != nil {
+		return nil, err
+	}
+	bodies, err := podspec.WritebackIndexBodies(merged)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range indices {
+		_, found, err := es.IndexMeta(ctx, index)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			continue
+		}
+		if err = es.CreateIndex(ctx, index, bodies[index]); err != nil {
+			return nil, err
+		}
+		log.V(1).Info("Create writeback index successfully", "Elastalert.Namespace", e.Namespace, "Index", index)
+	}
+	if retention := podspec.WritebackRetention(merged); retention > 0 {
+		queries, err := podspec.WritebackRetentionQueries(merged, retention)
+		if err != nil {
+			return nil, err
+		}
+		for _, index := range indices {
+			if _, err = es.DeleteByQuery(ctx, []string{index}, queries[index]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	stats, err := es.IndexStats(ctx, indices)
+	if err != nil {
+		return nil, err
+	}
+	var status []esv1alpha1.WritebackIndexStatus
+	for _, index := range indices {
+		if s, ok := stats[index]; ok {
+			status = append(status, esv1alpha1.WritebackIndexStatus{Name: index, Documents: s.Documents, SizeBytes: s.SizeBytes})
+		}
+	}
+	return status, nil
+}
+
+// syncRuleLastRuns reports the latest runs of the rules of the Elastalert read from its writeback index, at most once
+// per WritebackIndexSyncInterval, whether or not the operator manages the index. It returns when to sync again, zero
+// if the operator does not read the runs or the Elastalert is suspended or paused, as its rules do not run then. A
+// failure is only logged, the stale runs are what the alerts on them are about.
+func (r *ElastalertReconciler) syncRuleLastRuns(ctx context.Context, e *esv1alpha1.Elastalert) time.Duration {
+	nsn := types.NamespacedName{Namespace: e.Namespace, Name: e.Name}
+	if !r.RuleRuns || e.Spec.Suspend || podspec.MaintenanceSuspended(e) || podspec.IsPaused(e) {
+		r.ruleRunsSyncTimes.Delete(nsn)
+		return 0
+	}
+	now := r.now()
+	if last, ok := r.ruleRunsSyncTimes.Load(nsn); ok {
+		if next := last.(time.Time).Add(podspec.WritebackIndexSyncInterval).Sub(now); next > 0 {
+			return next
+		}
+	}
+	r.ruleRunsSyncTimes.Store(nsn, now)
+	runsCtx, cancel := context.WithTimeout(ctx, ruleRunsTimeout)
+	defer cancel()
+	runs, err := ruleLastRuns(r.Client, runsCtx, r.esClientFactory(), e)
+	if err != nil {
+		log.Error(err, "Failed to read the latest runs of the rules", "Elastalert.Namespace", e.Namespace, "Elastalert.Name", e.Name)
+		return podspec.WritebackIndexSyncInterval
+	}
+	metrics.SetRuleLastRuns(nsn, runs)
+	return podspec.WritebackIndexSyncInterval
+}
+
+// ruleLastRuns reads the latest runs of the rules of the Elastalert from the status index of its writeback index.
+func ruleLastRuns(c client.Client, ctx context.Context, factory elasticsearch.ClientFactory, e *esv1alpha1.Elastalert) (map[string]time.Time, error) {
+	es, merged, err := writebackClient(c, ctx, factory, e)
+	if err != nil {
+		return nil, err
+	}
+	statusIndex, err := podspec.WritebackStatusIndex(merged)
+	if err != nil {
+		return nil, err
+	}
+	names, err := podspec.WritebackRuleNames(merged)
+	if err != nil {
+		return nil, err
+	}
+	return es.LastRuns(ctx, statusIndex, names)
+}
+
+// writebackClient connects to the elasticsearch cluster of the Elastalert with the config it runs with, including the
+// defaults of its ElastalertClusterConfig. It returns the Elastalert with the merged config as well.
+func writebackClient(c client.Client, ctx context.Context, factory elasticsearch.ClientFactory, e *esv1alpha1.Elastalert) (elasticsearch.Client, *esv1alpha1.Elastalert, error) {
+	cc, err := resolveClusterConfig(c, ctx, e)
+	if err != nil {
+		return nil, nil, err
+	}
+	merged := e.DeepCopy()
+	if _, err = podspec.MergeClusterConfig(merged, cc); err != nil {
+		return nil, nil, err
+	}
+	es, err := factory(merged)
+	if err != nil {
+		return nil, nil, err
+	}
+	return es, merged, nil
+}
+
+func removeWritebackFinalizer(c client.Client, ctx context.Context, e *esv1alpha1.Elastalert) error {
+	patch := client.MergeFrom(e.DeepCopy())
+	controllerutil.RemoveFinalizer(e, podspec.WritebackCleanupFinalizer)
+	if err := c.Patch(ctx, e, patch); err != nil {
+		log.Error(err, "Failed to remove writeback cleanup finalizer", "Elastalert.Namespace", e.Namespace, "Elastalert.Name", e.Name)
+		return err
+	}
+	return nil
+}
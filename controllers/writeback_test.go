@@ -0,0 +1,581 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"github.com/toughnoah/elastalert-operator/api/v1alpha1"
+	"github.com/toughnoah/elastalert-operator/controllers/elasticsearch"
+	"github.com/toughnoah/elastalert-operator/controllers/metrics"
+	"io/ioutil"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+	"net/http"
+	"net/http/httptest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeESClient struct {
+	meta     map[string]interface{}
+	found    bool
+	err      error
+	calls    []string
+	queried  []string
+	query    map[string]interface{}
+	deletedN int64
+	health   *elasticsearch.ClusterHealth
+	fields   map[string]map[string][]string
+	runs     map[string]time.Time
+	runsErr  error
+	rules    []string
+	// deadline tells whether the context of the last IndexMeta or LastRuns call had a deadline.
+	deadline bool
+}
+
+func (f *fakeESClient) DeleteByQuery(ctx context.Context, indices []string, query map[string]interface{}) (int64, error) {
+	f.calls = append(f.calls, "DeleteByQuery")
+	f.queried = indices
+	f.query = query
+	return f.deletedN, f.err
+}
+
+func (f *fakeESClient) DeleteIndices(ctx context.Context, indices []string) error {
+	f.calls = append(f.calls, "DeleteIndices")
+	f.queried = indices
+	return f.err
+}
+
+func (f *fakeESClient) IndexMeta(ctx context.Context, index string) (map[string]interface{}, bool, error) {
+	f.calls = append(f.calls, "IndexMeta")
+	_, f.deadline = ctx.Deadline()
+	return f.meta, f.found, f.err
+}
+
+func (f *fakeESClient) CreateIndex(ctx context.Context, index string, body map[string]interface{}) error {
+	f.calls = append(f.calls, "CreateIndex")
+	return f.err
+}
+
+func (f *fakeESClient) IndexStats(ctx context.Context, indices []string) (map[string]elasticsearch.IndexStats, error) {
+	f.calls = append(f.calls, "IndexStats")
+	return nil, f.err
+}
+
+func (f *fakeESClient) Preflight(ctx context.Context) (*elasticsearch.ClusterHealth, error) {
+	f.calls = append(f.calls, "Preflight")
+	return f.health, f.err
+}
+
+func (f *fakeESClient) FieldMappings(ctx context.Context, index string) (map[string][]string, error) {
+	f.calls = append(f.calls, "FieldMappings")
+	return f.fields[index], f.err
+}
+
+func (f *fakeESClient) LastRuns(ctx context.Context, index string, rules []string) (map[string]time.Time, error) {
+	f.calls = append(f.calls, "LastRuns")
+	_, f.deadline = ctx.Deadline()
+	f.queried = []string{index}
+	f.rules = rules
+	return f.runs, f.runsErr
+}
+
+// esStandIn is an in-memory elasticsearch serving the requests of the writeback index sync. Documents only hold
+// their dates.
+type esStandIn struct {
+	mu       sync.Mutex
+	indices  map[string][]map[string]time.Time
+	mappings map[string]interface{}
+	requests []string
+}
+
+func newESStandIn() *esStandIn {
+	return &esStandIn{
+		indices:  map[string][]map[string]time.Time{},
+		mappings: map[string]interface{}{},
+	}
+}
+
+func (es *esStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.requests = append(es.requests, r.Method+" "+r.URL.Path)
+	body := map[string]interface{}{}
+	if data, _ := ioutil.ReadAll(r.Body); len(data) != 0 {
+		if err := json.Unmarshal(data, &body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	write := func(v interface{}) {
+		data, _ := json.Marshal(v)
+		w.Write(data)
+	}
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		if _, ok := es.indices[parts[0]]; ok {
+			w.WriteHeader(http.StatusBadRequest)
+			write(map[string]interface{}{"error": map[string]interface{}{"type": "resource_already_exists_exception"}})
+			return
+		}
+		es.indices[parts[0]] = nil
+		es.mappings[parts[0]] = body["mappings"]
+		write(map[string]interface{}{"acknowledged": true})
+	case len(parts) == 1:
+		w.WriteHeader(http.StatusNotFound)
+	case parts[1] == "_mapping":
+		if _, ok := es.indices[parts[0]]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		write(map[string]interface{}{parts[0]: map[string]interface{}{"mappings": es.mappings[parts[0]]}})
+	case parts[1] == "_delete_by_query":
+		var deleted int
+		ranges := body["query"].(map[string]interface{})["range"].(map[string]interface{})
+		for _, index := range strings.Split(parts[0], ",") {
+			var kept []map[string]time.Time
+			for _, doc := range es.indices[index] {
+				older := false
+				for field, cond := range ranges {
+					age, _ := time.ParseDuration(strings.TrimPrefix(cond.(map[string]interface{})["lt"].(string), "now-"))
+					older = doc[field].Before(time.Now().Add(-age))
+				}
+				if older {
+					deleted++
+				} else {
+					kept = append(kept, doc)
+				}
+			}
+			es.indices[index] = kept
+		}
+		write(map[string]interface{}{"deleted": deleted, "failures": []interface{}{}})
+	case parts[1] == "_stats":
+		stats := map[string]interface{}{}
+		for _, index := range strings.Split(parts[0], ",") {
+			if docs, ok := es.indices[index]; ok {
+				stats[index] = map[string]interface{}{"primaries": map[string]interface{}{
+					"docs":  map[string]interface{}{"count": len(docs)},
+					"store": map[string]interface{}{"size_in_bytes": 100 * len(docs)},
+				}}
+			}
+		}
+		write(map[string]interface{}{"indices": stats})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestSyncWritebackIndex(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	nsn := types.NamespacedName{Namespace: "esa1", Name: "my-esa"}
+	now := time.Now().Truncate(time.Second)
+	testCases := []struct {
+		desc        string
+		lifecycle   *v1alpha1.WritebackIndexLifecycle
+		existing    map[string][]map[string]time.Time
+		wantIndices []v1alpha1.WritebackIndexStatus
+	}{
+		{
+			desc:      "test create indices",
+			lifecycle: &v1alpha1.WritebackIndexLifecycle{},
+			wantIndices: []v1alpha1.WritebackIndexStatus{
+				{Name: "alerts"}, {Name: "alerts_status"}, {Name: "alerts_silence"}, {Name: "alerts_error"}, {Name: "alerts_past"},
+			},
+		},
+		{
+			desc:      "test delete documents older than the retention",
+			lifecycle: &v1alpha1.WritebackIndexLifecycle{Retention: &metav1.Duration{Duration: 24 * time.Hour}},
+			existing: map[string][]map[string]time.Time{
+				"alerts_status": {
+					{"@timestamp": now.Add(-48 * time.Hour)},
+					{"@timestamp": now.Add(-time.Hour)},
+				},
+				"alerts_silence": {
+					// an old silence still active is kept.
+					{"@timestamp": now.Add(-48 * time.Hour), "until": now.Add(time.Hour)},
+					{"@timestamp": now.Add(-48 * time.Hour), "until": now.Add(-47 * time.Hour)},
+				},
+			},
+			wantIndices: []v1alpha1.WritebackIndexStatus{
+				{Name: "alerts"},
+				{Name: "alerts_status", Documents: 1, SizeBytes: 100},
+				{Name: "alerts_silence", Documents: 1, SizeBytes: 100},
+				{Name: "alerts_error"},
+				{Name: "alerts_past"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			es := newESStandIn()
+			for index, docs := range tc.existing {
+				es.indices[index] = docs
+			}
+			server := httptest.NewServer(es)
+			defer server.Close()
+			e := &v1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "my-esa"},
+				Spec: v1alpha1.ElastalertSpec{
+					ConfigSetting: v1alpha1.NewFreeForm(map[string]interface{}{
+						"es_host":         "es.local",
+						"writeback_index": "alerts",
+					}),
+					WritebackIndex: tc.lifecycle,
+				},
+			}
+			c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(e).Build()
+			fakeClock := clock.NewFakeClock(now)
+			r := &ElastalertReconciler{
+				Client:   c,
+				Scheme:   s,
+				Recorder: record.NewBroadcaster().NewRecorder(s, corev1.EventSource{}),
+				Clock:    fakeClock,
+				ESClientFactory: func(e *v1alpha1.Elastalert) (elasticsearch.Client, error) {
+					return elasticsearch.NewClient(elasticsearch.Config{URL: server.URL})
+				},
+			}
+			have := &v1alpha1.Elastalert{}
+			require.NoError(t, c.Get(context.Background(), nsn, have))
+			require.Equal(t, 10*time.Minute, r.syncWritebackIndex(context.Background(), have))
+			require.NoError(t, c.Get(context.Background(), nsn, have))
+			require.Equal(t, tc.wantIndices, have.Status.WritebackIndices)
+			require.Equal(t, now.Unix(), have.Status.WritebackIndexSyncTime.Unix())
+			mapping := es.mappings["alerts_error"].(map[string]interface{})
+			require.Equal(t, map[string]interface{}{"es.noah.domain/elastalert": "es.local:9200/alerts"}, mapping["_meta"])
+
+			// the indices are synced again after the interval only.
+			requests := len(es.requests)
+			fakeClock.Step(4 * time.Minute)
+			require.Equal(t, 6*time.Minute, r.syncWritebackIndex(context.Background(), have))
+			require.Len(t, es.requests, requests)
+			fakeClock.Step(6 * time.Minute)
+			require.Equal(t, 10*time.Minute, r.syncWritebackIndex(context.Background(), have))
+			require.Greater(t, len(es.requests), requests)
+		})
+	}
+}
+
+func TestSyncWritebackIndexError(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	nsn := types.NamespacedName{Namespace: "esa1", Name: "my-esa"}
+	now := time.Date(2021, 8, 1, 2, 0, 0, 0, time.UTC)
+	e := &v1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "my-esa"},
+		Spec: v1alpha1.ElastalertSpec{
+			ConfigSetting:  v1alpha1.NewFreeForm(map[string]interface{}{"es_host": "es.local"}),
+			WritebackIndex: &v1alpha1.WritebackIndexLifecycle{},
+		},
+		Status: v1alpha1.ElastalertStatus{
+			WritebackIndices: []v1alpha1.WritebackIndexStatus{{Name: "elastalert_status", Documents: 3, SizeBytes: 300}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(e).Build()
+	es := &fakeESClient{err: errors.New("connection refused")}
+	r := &ElastalertReconciler{
+		Client:   c,
+		Scheme:   s,
+		Recorder: record.NewBroadcaster().NewRecorder(s, corev1.EventSource{}),
+		Clock:    clock.NewFakeClock(now),
+		ESClientFactory: func(e *v1alpha1.Elastalert) (elasticsearch.Client, error) {
+			return es, nil
+		},
+	}
+	have := &v1alpha1.Elastalert{}
+	require.NoError(t, c.Get(context.Background(), nsn, have))
+	require.Equal(t, 10*time.Minute, r.syncWritebackIndex(context.Background(), have))
+	require.Equal(t, []string{"IndexMeta"}, es.calls)
+	require.True(t, es.deadline)
+	require.NoError(t, c.Get(context.Background(), nsn, have))
+	// the last known sizes are kept.
+	require.Equal(t, e.Status.WritebackIndices, have.Status.WritebackIndices)
+	require.Equal(t, now.Unix(), have.Status.WritebackIndexSyncTime.Unix())
+
+	have.Spec.WritebackIndex = nil
+	require.Equal(t, time.Duration(0), r.syncWritebackIndex(context.Background(), have))
+}
+
+func TestSyncRuleLastRuns(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	nsn := types.NamespacedName{Namespace: "esa1", Name: "my-esa"}
+	lastRun := time.Date(2021, 8, 1, 2, 0, 0, 0, time.UTC)
+	// the runs are read whether or not the operator manages the writeback index.
+	e := &v1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "my-esa"},
+		Spec: v1alpha1.ElastalertSpec{
+			ConfigSetting: v1alpha1.NewFreeForm(map[string]interface{}{"es_host": "es.local", "writeback_index": "alerts"}),
+			Rule: []v1alpha1.FreeForm{
+				v1alpha1.NewFreeForm(map[string]interface{}{"name": "errors", "type": "any"}),
+				v1alpha1.NewFreeForm(map[string]interface{}{"name": "audit", "type": "any"}),
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(e).Build()
+	es := &fakeESClient{runs: map[string]time.Time{"errors": lastRun}}
+	fakeClock := clock.NewFakeClock(lastRun.Add(time.Hour))
+	r := &ElastalertReconciler{
+		Client:   c,
+		Scheme:   s,
+		Recorder: record.NewBroadcaster().NewRecorder(s, corev1.EventSource{}),
+		Clock:    fakeClock,
+		ESClientFactory: func(e *v1alpha1.Elastalert) (elasticsearch.Client, error) {
+			return es, nil
+		},
+	}
+	defer metrics.Forget(nsn)
+	// the runs are only read when the operator opts in.
+	require.Equal(t, time.Duration(0), r.syncRuleLastRuns(context.Background(), e))
+	require.Empty(t, es.calls)
+
+	r.RuleRuns = true
+	require.Equal(t, 10*time.Minute, r.syncRuleLastRuns(context.Background(), e))
+	require.Equal(t, []string{"alerts_status"}, es.queried)
+	require.Equal(t, []string{"errors", "audit"}, es.rules)
+	require.True(t, es.deadline)
+	require.Equal(t, map[string]float64{"errors": float64(lastRun.Unix())}, gatherRuleLastRuns(t, nsn))
+
+	// not read again before the interval.
+	fakeClock.Step(4 * time.Minute)
+	require.Equal(t, 6*time.Minute, r.syncRuleLastRuns(context.Background(), e))
+	require.Equal(t, []string{"LastRuns"}, es.calls)
+
+	// a failed read keeps the runs last reported.
+	fakeClock.Step(6 * time.Minute)
+	es.runsErr = errors.New("unreachable")
+	require.Equal(t, 10*time.Minute, r.syncRuleLastRuns(context.Background(), e))
+	require.Equal(t, []string{"LastRuns", "LastRuns"}, es.calls)
+	require.Equal(t, map[string]float64{"errors": float64(lastRun.Unix())}, gatherRuleLastRuns(t, nsn))
+
+	// the rules of a suspended or paused Elastalert do not run.
+	fakeClock.Step(10 * time.Minute)
+	suspended := e.DeepCopy()
+	suspended.Spec.Suspend = true
+	require.Equal(t, time.Duration(0), r.syncRuleLastRuns(context.Background(), suspended))
+	paused := e.DeepCopy()
+	paused.Annotations = map[string]string{"es.noah.domain/paused": "true"}
+	require.Equal(t, time.Duration(0), r.syncRuleLastRuns(context.Background(), paused))
+	require.Equal(t, []string{"LastRuns", "LastRuns"}, es.calls)
+}
+
+// gatherRuleLastRuns returns the rule_last_run metric of the Elastalert by rule.
+func gatherRuleLastRuns(t *testing.T, nsn types.NamespacedName) map[string]float64 {
+	families, err := ctrlmetrics.Registry.Gather()
+	require.NoError(t, err)
+	runs := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != metrics.RuleLastRun.Name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels[metrics.NamespaceLabel] == nsn.Namespace && labels[metrics.ElastalertLabel] == nsn.Name {
+				runs[labels[metrics.RuleLabel]] = m.GetGauge().GetValue()
+			}
+		}
+	}
+	return runs
+}
+
+func TestEnsureWritebackFinalizer(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	e := &v1alpha1.Elastalert{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "esa1", Name: "my-esa"},
+		Spec:       v1alpha1.ElastalertSpec{WritebackCleanup: &v1alpha1.WritebackCleanup{}},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(e).Build()
+	require.NoError(t, ensureWritebackFinalizer(c, context.Background(), e))
+	have := &v1alpha1.Elastalert{}
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "esa1", Name: "my-esa"}, have))
+	require.Equal(t, []string{"es.noah.domain/writeback-cleanup"}, have.Finalizers)
+
+	have.Spec.WritebackCleanup = nil
+	require.NoError(t, ensureWritebackFinalizer(c, context.Background(), have))
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "esa1", Name: "my-esa"}, have))
+	require.Empty(t, have.Finalizers)
+}
+
+func TestFinalizeWriteback(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	nsn := types.NamespacedName{Namespace: "esa1", Name: "my-esa"}
+	deletedAt := time.Date(2021, 8, 1, 2, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		desc          string
+		es            *fakeESClient
+		now           time.Time
+		wantCalls     []string
+		wantIndices   []string
+		wantRequeue   time.Duration
+		wantFinalizer bool
+	}{
+		{
+			desc:        "test delete index created by the operator",
+			es:          &fakeESClient{found: true, meta: map[string]interface{}{"es.noah.domain/elastalert": "es.local:9200/alerts"}},
+			now:         deletedAt,
+			wantCalls:   []string{"IndexMeta", "DeleteIndices"},
+			wantIndices: []string{"alerts", "alerts_status", "alerts_silence", "alerts_error", "alerts_past"},
+		},
+		{
+			desc:        "test delete documents of the rules",
+			es:          &fakeESClient{found: true, meta: map[string]interface{}{"es.noah.domain/elastalert": "other.local:9200/alerts"}, deletedN: 4},
+			now:         deletedAt,
+			wantCalls:   []string{"IndexMeta", "DeleteByQuery"},
+			wantIndices: []string{"alerts", "alerts_status", "alerts_silence", "alerts_error", "alerts_past"},
+		},
+		{
+			desc:      "test missing index",
+			es:        &fakeESClient{},
+			now:       deletedAt,
+			wantCalls: []string{"IndexMeta"},
+		},
+		{
+			desc:          "test failure is retried",
+			es:            &fakeESClient{err: errors.New("connection refused")},
+			now:           deletedAt.Add(time.Minute),
+			wantCalls:     []string{"IndexMeta"},
+			wantRequeue:   30 * time.Second,
+			wantFinalizer: true,
+		},
+		{
+			desc:          "test last retry before the timeout",
+			es:            &fakeESClient{err: errors.New("connection refused")},
+			now:           deletedAt.Add(4*time.Minute + 50*time.Second),
+			wantCalls:     []string{"IndexMeta"},
+			wantRequeue:   10 * time.Second,
+			wantFinalizer: true,
+		},
+		{
+			desc: "test give up after the timeout",
+			es:   &fakeESClient{err: errors.New("connection refused")},
+			now:  deletedAt.Add(5 * time.Minute),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			e := &v1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:         "esa1",
+					Name:              "my-esa",
+					Finalizers:        []string{"es.noah.domain/writeback-cleanup"},
+					DeletionTimestamp: &metav1.Time{Time: deletedAt},
+				},
+				Spec: v1alpha1.ElastalertSpec{
+					ConfigSetting: v1alpha1.NewFreeForm(map[string]interface{}{
+						"es_host":         "es.local",
+						"writeback_index": "alerts",
+					}),
+					Rule: []v1alpha1.FreeForm{
+						v1alpha1.NewFreeForm(map[string]interface{}{"name": "disk", "type": "any"}),
+					},
+					WritebackCleanup: &v1alpha1.WritebackCleanup{},
+				},
+			}
+			c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(e).Build()
+			r := &ElastalertReconciler{
+				Client:   c,
+				Scheme:   s,
+				Recorder: record.NewBroadcaster().NewRecorder(s, corev1.EventSource{}),
+				Clock:    clock.NewFakeClock(tc.now),
+				ESClientFactory: func(e *v1alpha1.Elastalert) (elasticsearch.Client, error) {
+					return tc.es, nil
+				},
+			}
+			result, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: nsn})
+			require.NoError(t, err)
+			require.Equal(t, tc.wantRequeue, result.RequeueAfter)
+			require.Equal(t, tc.wantCalls, tc.es.calls)
+			require.Equal(t, tc.wantIndices, tc.es.queried)
+			have := &v1alpha1.Elastalert{}
+			err = c.Get(context.Background(), nsn, have)
+			if tc.wantFinalizer {
+				require.NoError(t, err)
+				require.NotEmpty(t, have.Finalizers)
+				return
+			}
+			// the object is gone once its last finalizer is removed.
+			require.True(t, k8serrors.IsNotFound(err) || err == nil && len(have.Finalizers) == 0)
+		})
+	}
+}
+
+func TestFinalizeWritebackSharedIndex(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	nsn := types.NamespacedName{Namespace: "esa1", Name: "my-esa"}
+	deletedAt := time.Date(2021, 8, 1, 2, 0, 0, 0, time.UTC)
+	indices := []string{"elastalert_status", "elastalert_status_status", "elastalert_status_silence", "elastalert_status_error", "elastalert_status_past"}
+	testCases := []struct {
+		desc      string
+		otherHost string
+		wantCalls []string
+	}{
+		{
+			desc:      "test keep index shared with another instance",
+			otherHost: "es.local",
+			wantCalls: []string{"IndexMeta", "DeleteByQuery"},
+		},
+		{
+			desc:      "test delete index when the other instance uses another cluster",
+			otherHost: "other.local",
+			wantCalls: []string{"IndexMeta", "DeleteIndices"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			e := &v1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:         "esa1",
+					Name:              "my-esa",
+					Finalizers:        []string{"es.noah.domain/writeback-cleanup"},
+					DeletionTimestamp: &metav1.Time{Time: deletedAt},
+				},
+				Spec: v1alpha1.ElastalertSpec{
+					ConfigSetting: v1alpha1.NewFreeForm(map[string]interface{}{"es_host": "es.local"}),
+					Rule: []v1alpha1.FreeForm{
+						v1alpha1.NewFreeForm(map[string]interface{}{"name": "disk", "type": "any"}),
+					},
+					WritebackCleanup: &v1alpha1.WritebackCleanup{},
+				},
+			}
+			other := &v1alpha1.Elastalert{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "esa2", Name: "other-esa"},
+				Spec: v1alpha1.ElastalertSpec{
+					ConfigSetting: v1alpha1.NewFreeForm(map[string]interface{}{"es_host": tc.otherHost}),
+				},
+			}
+			es := &fakeESClient{found: true, meta: map[string]interface{}{"es.noah.domain/elastalert": "es.local:9200/elastalert_status"}}
+			c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(e, other).Build()
+			r := &ElastalertReconciler{
+				Client:   c,
+				Scheme:   s,
+				Recorder: record.NewBroadcaster().NewRecorder(s, corev1.EventSource{}),
+				Clock:    clock.NewFakeClock(deletedAt),
+				ESClientFactory: func(e *v1alpha1.Elastalert) (elasticsearch.Client, error) {
+					return es, nil
+				},
+			}
+			_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: nsn})
+			require.NoError(t, err)
+			require.Equal(t, tc.wantCalls, es.calls)
+			require.Equal(t, indices, es.queried)
+		})
+	}
+}
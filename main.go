@@ -18,14 +18,20 @@ package main
 
 import (
 	"flag"
-	"github.com/toughnoah/elastalert-operator/controllers/observer"
+	"github.com/toughnoah/elastalert-operator/controllers/event"
+	ob "github.com/toughnoah/elastalert-operator/controllers/observer"
+	"github.com/toughnoah/elastalert-operator/controllers/podspec"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
 	"os"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -53,11 +59,23 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var preflight bool
+	var podLogTail bool
+	var ruleRuns bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&preflight, "es-preflight", false,
+		"Check that elasticsearch is reachable from the operator before rolling out a new config. "+
+			"It needs the operator to reach the clusters the instances write to.")
+	flag.BoolVar(&podLogTail, "pod-log-tail", false,
+		"Record the last lines of the log of a failed pod in status.lastFailure, with the secrets found redacted. "+
+			"The log may still hold sensitive data, anyone who can read the Elastalert can read it.")
+	flag.BoolVar(&ruleRuns, "rule-last-runs", false,
+		"Read when the rules last ran from the writeback indices every 10 minutes, for the rule_last_run metric. "+
+			"It needs the operator to reach the clusters the instances write to.")
 
 	opts := zap.Options{}
 	opts.BindFlags(flag.CommandLine)
@@ -72,17 +90,34 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "a81002ee.noah.domain",
+		// the health checks only read the pods of the instances, caching every pod of the cluster would waste memory.
+		NewCache: cache.BuilderWithOptions(cache.Options{SelectorsByObject: cache.SelectorsByObject{
+			&corev1.Pod{}: {Label: podspec.InstanceSelector()},
+		}}),
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create clientset")
+		os.Exit(1)
+	}
+
+	var podLogs ob.PodLogs
+	if podLogTail {
+		podLogs = ob.NewPodLogs(clientset)
+	}
 	if err = (&controllers.ElastalertReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("elastalert"),
-		Observer: *observer.NewManager(),
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Recorder:  event.NewRecorder(mgr.GetEventRecorderFor("elastalert")),
+		Clock:     clock.RealClock{},
+		Preflight: preflight,
+		PodLogs:   podLogs,
+		RuleRuns:  ruleRuns,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Elastalert")
 		os.Exit(1)